@@ -0,0 +1,110 @@
+package yamagiconf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Audit checks src against type T like LoadWithOptions but instead of
+// stopping at the first violation it returns a *MultiError listing
+// everything found wrong with the pair, which is useful as a CI gate.
+// Returns nil if src loads successfully.
+//
+// If T itself violates the type rules the violations are listed like
+// ValidateTypeAll would and src isn't checked since it can't be loaded.
+// Otherwise the list consists of the YAML subset violations found by
+// CheckYAMLSubset, followed by the first violation of the document structure
+// and values (such as a missing or unknown field) if any, followed by all
+// violations of Validate methods and struct tags (see WithAllErrors)
+// which are only checked if the document could be decoded.
+// Structural violations located at the same position as a subset violation
+// are considered duplicates and omitted. Violations of values taken from
+// the document are located in src, violations of values set from env vars
+// carry the Go path instead.
+func Audit[T any](src []byte, opts ...Option) *MultiError {
+	o := newOptions(opts)
+	tp := reflect.TypeFor[T]()
+	v := typeValidator{
+		all: true, allowRecursive: o.maxDepth > 0, polymorphic: o.polymorphic,
+		allowPlatformInts: o.allowPlatformInts,
+	}
+	v.validate(tp)
+	if len(v.errs) > 0 {
+		return &MultiError{Errors: v.errs}
+	}
+
+	var errs []error
+	located := map[[2]int]bool{}
+	for _, v := range CheckYAMLSubset(src) {
+		errs = append(errs, v)
+		located[[2]int{v.Line, v.Column}] = true
+	}
+	structural := func(err error) *MultiError {
+		var e *Error
+		isDuplicate := errors.As(err, &e) && located[[2]int{e.Line, e.Column}] ||
+			len(errs) > 0 && errs[len(errs)-1].Error() == err.Error()
+		if !isDuplicate {
+			errs = append(errs, err)
+		}
+		return &MultiError{Errors: errs}
+	}
+
+	if len(src) == 0 && !o.allowEmptyFile {
+		return structural(ErrYAMLEmptyFile)
+	}
+	// Treat an empty source as an empty mapping.
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+	if len(src) > 0 {
+		var err error
+		if node, err = parseDocument(src, o.allowMultiDoc); err != nil {
+			return structural(err)
+		}
+	}
+	o.source = newSourceIndex(src, node)
+
+	// Collect the violations of validators separately instead
+	// of stopping at the first one.
+	o.typeValidated, o.allErrors = true, true
+	o.phases = PhaseAll &^ (PhaseValidators | PhaseGoValidator)
+	var config T
+	if err := loadNode(o, &config, node); err != nil {
+		return structural(err)
+	}
+	err := o.validateConfig(reflect.ValueOf(config))
+	var m *MultiError
+	if errors.As(err, &m) {
+		for _, err := range m.Errors {
+			var e *Error
+			if errors.As(err, &e) {
+				o.locate(tp, e, node)
+			}
+			errs = append(errs, err)
+		}
+	} else if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) < 1 {
+		return nil
+	}
+	return &MultiError{Errors: errs}
+}
+
+// locate sets the location of e in the source according to its Go path
+// unless the value was set from an env var.
+func (o *options) locate(tp reflect.Type, e *Error, node *yaml.Node) {
+	if _, ok := o.envSource(e.GoPath); ok {
+		return
+	}
+	n, yamlTag := findNodeByValidatorNamespace(o, tp, e.GoPath, node)
+	located := o.errorAt(n.Line, n.Column, e.GoPath, e.Err)
+	if located.Offset == -1 {
+		return
+	}
+	if yamlTag != "" {
+		located.Err = fmt.Errorf("%q: %w", yamlTag, e.Err)
+	}
+	*e = *located
+}
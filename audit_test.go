@@ -0,0 +1,94 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host" validate:"required"`
+		Port uint16 `yaml:"port" validate:"min=1024"`
+	}
+	type TestConfig struct {
+		Name    ValidatedString `yaml:"name"`
+		Server  Server          `yaml:"server"`
+		Workers uint8           `yaml:"workers" multipleof:"2" env:"WORKERS"`
+		Tags    []string        `yaml:"tags" sorted:"asc"`
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		err := yamagiconf.Audit[TestConfig]([]byte(`name: valid
+server:
+  host: localhost
+  port: 8080
+workers: 4
+tags: [a, b]
+`))
+		require.Nil(t, err)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		t.Setenv("WORKERS", "3")
+		err := yamagiconf.Audit[TestConfig]([]byte(`name: invalid
+server:
+  host: ""
+  port: 80
+workers: 4
+tags: [b, a]
+`))
+		require.NotNil(t, err)
+		require.ErrorIs(t, err, yamagiconf.ErrNotMultipleOf)
+		require.ErrorIs(t, err, yamagiconf.ErrNotSorted)
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Workers: value 3 must be a multiple of 2
+at 6:11: "tags": not sorted in ascending order: index 1 is less than index 0
+at 1:7: "name": validation: is not 'valid'
+at 3:9: "host": violates validation rule: "required"
+at 4:9: "port": violates validation rule: "min"`, err.Error())
+	})
+
+	t.Run("document", func(t *testing.T) {
+		err := yamagiconf.Audit[TestConfig]([]byte(`name: &n valid
+server:
+  host: !!str localhost
+  port: 8080
+workers: yes
+tags: []
+`))
+		require.NotNil(t, err)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLAnchorUnused)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLTagUsed)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadBoolLiteral)
+		require.Len(t, err.Errors, 3)
+	})
+
+	t.Run("structure", func(t *testing.T) {
+		err := yamagiconf.Audit[TestConfig]([]byte(`name: valid
+server:
+  host: localhost
+workers: 4
+tags: []
+`))
+		require.NotNil(t, err)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Len(t, err.Errors, 1)
+	})
+
+	t.Run("type", func(t *testing.T) {
+		type InvalidConfig struct {
+			Count int      `yaml:"count"`
+			Name  string   `yaml:"name" format:"guid"`
+			Tags  []string `yaml:"tags" sorted:"up"`
+		}
+		err := yamagiconf.Audit[InvalidConfig]([]byte("count: 1\nname: x\ntags: []\n"))
+		require.NotNil(t, err)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeUnsupported)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidFormatTag)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidSortedTag)
+		require.Len(t, err.Errors, 3)
+	})
+}
@@ -0,0 +1,97 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// validateSumBudgetField returns an error if f has a `sumbudget` struct tag
+// that doesn't reference another exported time.Duration field of struct
+// parent or if f isn't a time.Duration, a pointer to it or a slice
+// or array of those.
+func validateSumBudgetField(parent reflect.Type, f reflect.StructField) error {
+	name, ok := f.Tag.Lookup("sumbudget")
+	if !ok {
+		return nil
+	}
+	g, ok := parent.FieldByName(name)
+	if !ok || !g.IsExported() || name == f.Name {
+		return fmt.Errorf("%w: %q: field not found", ErrTypeInvalidSumBudgetTag, name)
+	}
+	if gt := g.Type; gt != typeTimeDuration &&
+		(gt.Kind() != reflect.Pointer || gt.Elem() != typeTimeDuration) {
+		return fmt.Errorf("%w: %q: field is of type %s, expected time.Duration",
+			ErrTypeInvalidSumBudgetTag, name, g.Type.String())
+	}
+	t := f.Type
+	if k := t.Kind(); k == reflect.Slice || k == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != typeTimeDuration {
+		return fmt.Errorf("%w: %s is not a time.Duration",
+			ErrTypeInvalidSumBudgetTag, f.Type.String())
+	}
+	return nil
+}
+
+// checkSumBudgetField reports the group of fields of struct v with
+// a `sumbudget` struct tag referencing the same sibling field as f
+// if their durations sum up to more than the duration of the sibling.
+// Only the first field of a group is checked and path is its Go path.
+// Slice and array items are summed up individually. Nil pointers are not
+// summed up and groups with a nil budget are not checked.
+func checkSumBudgetField(
+	w *fieldWalker, path string, v reflect.Value, f reflect.StructField, _ reflect.Value,
+) error {
+	name, ok := f.Tag.Lookup("sumbudget")
+	if !ok {
+		return nil
+	}
+	tp := v.Type()
+	var sum time.Duration
+	for i := range tp.NumField() {
+		g := tp.Field(i)
+		if n, ok := g.Tag.Lookup("sumbudget"); !ok || n != name {
+			continue
+		}
+		if i < f.Index[0] {
+			return nil // Not the first field of the group.
+		}
+		sum += sumDurations(v.Field(i))
+	}
+	b, _ := tp.FieldByName(name) // Already checked by ValidateType.
+	limit := v.FieldByIndex(b.Index)
+	if limit.Kind() == reflect.Pointer {
+		if limit.IsNil() {
+			return nil
+		}
+		limit = limit.Elem()
+	}
+	if l := time.Duration(limit.Int()); sum > l {
+		return w.report(path, fmt.Errorf("sum %s %w %s of %s",
+			sum, ErrBudgetExceeded, w.name(b), l))
+	}
+	return nil
+}
+
+// sumDurations returns the sum of duration v, which may be a pointer
+// or a slice or array of durations or pointers to durations.
+func sumDurations(v reflect.Value) (sum time.Duration) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return sumDurations(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			sum += sumDurations(v.Index(i))
+		}
+	default:
+		return time.Duration(v.Int())
+	}
+	return sum
+}
@@ -0,0 +1,121 @@
+package yamagiconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSumBudgetTag(t *testing.T) {
+	type Pipeline struct {
+		Stages       []time.Duration `yaml:"stages" sumbudget:"TotalTimeout"`
+		TotalTimeout time.Duration   `yaml:"total-timeout"`
+	}
+	type TestConfig struct {
+		Connect  time.Duration  `yaml:"connect" sumbudget:"Deadline" env:"CONNECT"`
+		Request  *time.Duration `yaml:"request" sumbudget:"Deadline"`
+		Deadline *time.Duration `yaml:"deadline"`
+		Pipeline Pipeline       `yaml:"pipeline"`
+	}
+
+	t.Run("within", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`connect: 5s
+request: 25s
+deadline: 30s
+pipeline:
+  stages: [10s, 20s, 30s]
+  total-timeout: 1m
+`)
+		require.NoError(t, err)
+		require.Equal(t, []time.Duration{
+			10 * time.Second, 20 * time.Second, 30 * time.Second,
+		}, c.Pipeline.Stages)
+
+		// A nil budget isn't checked.
+		_, err = LoadSrc[TestConfig](`connect: 1h
+request: null
+deadline: null
+pipeline:
+  stages: []
+  total-timeout: 0s
+`)
+		require.NoError(t, err)
+	})
+
+	t.Run("stages_exceed", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`connect: 5s
+request: null
+deadline: 30s
+pipeline:
+  stages: [10s, 20s, 31s]
+  total-timeout: 1m
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrBudgetExceeded)
+		require.Equal(t, `at 5:11: "stages": sum 1m1s exceeds budget `+
+			`"total-timeout" of 1m0s`, err.Error())
+	})
+
+	t.Run("group_exceeds", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`connect: 5s
+request: 26s
+deadline: 30s
+pipeline:
+  stages: []
+  total-timeout: 1m
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrBudgetExceeded)
+		require.Equal(t, `at 1:10: "connect": sum 31s exceeds budget `+
+			`"deadline" of 30s`, err.Error())
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("CONNECT", "10s")
+		_, err := LoadSrc[TestConfig](`connect: 5s
+request: 25s
+deadline: 30s
+pipeline:
+  stages: []
+  total-timeout: 1m
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrBudgetExceeded)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.Connect: invalid env var CONNECT: `+
+			`sum 35s exceeds budget "deadline" of 30s`, err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{
+			Pipeline: Pipeline{Stages: []time.Duration{time.Second}},
+		})
+		require.ErrorIs(t, err, yamagiconf.ErrBudgetExceeded)
+		require.Equal(t, `at TestConfig.Pipeline.Stages: sum 1s exceeds budget `+
+			`TotalTimeout of 0s`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidSumBudgetTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Field []time.Duration `yaml:"field" sumbudget:"Total"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidSumBudgetTag)
+	require.Equal(t, `at struct{...}.Field: invalid sumbudget struct tag: `+
+		`"Total": field not found`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field []time.Duration `yaml:"field" sumbudget:"Total"`
+		Total uint32          `yaml:"total"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidSumBudgetTag)
+	require.Equal(t, `at struct{...}.Field: invalid sumbudget struct tag: `+
+		`"Total": field is of type uint32, expected time.Duration`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field []uint32      `yaml:"field" sumbudget:"Total"`
+		Total time.Duration `yaml:"total"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidSumBudgetTag)
+	require.Equal(t, `at struct{...}.Field: invalid sumbudget struct tag: `+
+		`[]uint32 is not a time.Duration`, err.Error())
+}
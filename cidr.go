@@ -0,0 +1,137 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+	"strings"
+)
+
+var (
+	typeNetIP       = reflect.TypeFor[net.IP]()
+	typeNetAddr     = reflect.TypeFor[netip.Addr]()
+	typeNetAddrPort = reflect.TypeFor[netip.AddrPort]()
+	typeNetPrefix   = reflect.TypeFor[netip.Prefix]()
+)
+
+// checkNetipValue returns ErrInvalidIPAddress wrapping err if tp is
+// netip.Addr, netip.AddrPort or netip.Prefix and either err is non-nil
+// or s is empty since their zero values are invalid, otherwise returns err.
+func checkNetipValue(tp reflect.Type, s string, err error) error {
+	if tp != typeNetAddr && tp != typeNetAddrPort && tp != typeNetPrefix {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidIPAddress, err)
+	}
+	if s == "" {
+		return fmt.Errorf("%w: empty %s", ErrInvalidIPAddress, tp.String())
+	}
+	return nil
+}
+
+// parseCIDRTag parses a `cidr` struct tag holding
+// a comma-separated list of CIDR prefixes.
+func parseCIDRTag(tag string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, s := range strings.Split(tag, ",") {
+		p, err := netip.ParsePrefix(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not a valid CIDR", ErrTypeInvalidCIDRTag, s)
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
+
+// validateCIDRField returns an error if f has a `cidr` struct tag
+// that isn't a comma-separated list of CIDR prefixes or f isn't a string,
+// net.IP, netip.Addr, a pointer to those or a slice or array of those.
+func validateCIDRField(f reflect.StructField) error {
+	tag, ok := f.Tag.Lookup("cidr")
+	if !ok {
+		return nil
+	}
+	if _, err := parseCIDRTag(tag); err != nil {
+		return err
+	}
+	t := f.Type
+	if t != typeNetIP {
+		if k := t.Kind(); k == reflect.Slice || k == reflect.Array {
+			t = t.Elem()
+		}
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t != typeNetIP && t != typeNetAddr && t.Kind() != reflect.String {
+		return fmt.Errorf("%w: %s is not an IP address",
+			ErrTypeInvalidCIDRTag, f.Type.String())
+	}
+	return nil
+}
+
+// checkCIDRField reports the IP addresses of field f that aren't within
+// any of the prefixes of its `cidr` struct tag. Slice and array items
+// are checked individually. Nil pointers are not checked.
+func checkCIDRField(
+	w *fieldWalker, path string, _ reflect.Value, f reflect.StructField, fv reflect.Value,
+) error {
+	tag, ok := f.Tag.Lookup("cidr")
+	if !ok {
+		return nil
+	}
+	prefixes, _ := parseCIDRTag(tag) // Already checked by ValidateType.
+	if fv.Type() == typeNetIP {
+		return checkCIDR(path, fv, prefixes, w.report)
+	}
+	return checkItems(path, fv, func(path string, v reflect.Value) error {
+		return checkCIDR(path, v, prefixes, w.report)
+	})
+}
+
+// checkCIDR calls fn if IP address v isn't within any of prefixes
+// or is a string that isn't a valid IP address.
+func checkCIDR(
+	path string, v reflect.Value, prefixes []netip.Prefix,
+	fn func(path string, err error) error,
+) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	var addr netip.Addr
+	switch v.Type() {
+	case typeNetAddr:
+		addr = v.Interface().(netip.Addr)
+	case typeNetIP:
+		ip := v.Interface().(net.IP)
+		if ip == nil {
+			return nil
+		}
+		var ok bool
+		if addr, ok = netip.AddrFromSlice(ip); !ok {
+			return fn(path, fmt.Errorf("%w: %q", ErrInvalidIPAddress, ip.String()))
+		}
+	default:
+		var err error
+		if addr, err = netip.ParseAddr(v.String()); err != nil {
+			return fn(path, fmt.Errorf("%w: %q", ErrInvalidIPAddress, v.String()))
+		}
+	}
+	addr = addr.Unmap()
+	for _, p := range prefixes {
+		if p.Contains(addr) {
+			return nil
+		}
+	}
+	s := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		s[i] = p.String()
+	}
+	return fn(path, fmt.Errorf("%s is %w %s",
+		addr.String(), ErrNotWithinCIDR, strings.Join(s, ", ")))
+}
@@ -0,0 +1,246 @@
+package yamagiconf_test
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCIDRTag(t *testing.T) {
+	type TestConfig struct {
+		Bind     string       `yaml:"bind" cidr:"10.0.0.0/8" env:"BIND"`
+		Gateway  net.IP       `yaml:"gateway" cidr:"192.168.0.0/16,172.16.0.0/12"`
+		Upstream *netip.Addr  `yaml:"upstream" cidr:"2001:db8::/32"`
+		Peers    []netip.Addr `yaml:"peers" cidr:"fd00::/8, 10.0.0.0/8"`
+	}
+
+	t.Run("within", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`bind: 10.1.2.3
+gateway: 172.20.0.1
+upstream: 2001:db8::1
+peers: [fd12::1, 10.0.0.1]
+`)
+		require.NoError(t, err)
+		require.Equal(t, "10.1.2.3", c.Bind)
+		require.Equal(t, net.ParseIP("172.20.0.1"), c.Gateway)
+		require.Equal(t, PtrTo(netip.MustParseAddr("2001:db8::1")), c.Upstream)
+
+		_, err = LoadSrc[TestConfig](`bind: 10.0.0.1
+gateway: "::ffff:192.168.1.1"
+upstream: null
+peers: []
+`)
+		require.NoError(t, err)
+	})
+
+	for _, td := range []struct {
+		name, src, expect string
+	}{
+		{
+			name:   "string_out_of_range",
+			src:    "bind: 192.168.1.1\ngateway: 192.168.0.1\nupstream: null\npeers: []\n",
+			expect: `at 1:7: "bind": 192.168.1.1 is not within 10.0.0.0/8`,
+		},
+		{
+			name: "net_ip_out_of_range",
+			src:  "bind: 10.0.0.1\ngateway: 8.8.8.8\nupstream: null\npeers: []\n",
+			expect: `at 2:10: "gateway": 8.8.8.8 is not within ` +
+				`192.168.0.0/16, 172.16.0.0/12`,
+		},
+		{
+			name:   "ipv6_out_of_range",
+			src:    "bind: 10.0.0.1\ngateway: 192.168.0.1\nupstream: 2001:db9::1\npeers: []\n",
+			expect: `at 3:11: "upstream": 2001:db9::1 is not within 2001:db8::/32`,
+		},
+		{
+			name:   "ipv4_in_ipv6_range",
+			src:    "bind: 10.0.0.1\ngateway: 192.168.0.1\nupstream: 10.0.0.1\npeers: []\n",
+			expect: `at 3:11: "upstream": 10.0.0.1 is not within 2001:db8::/32`,
+		},
+		{
+			name: "slice_item",
+			src: "bind: 10.0.0.1\ngateway: 192.168.0.1\nupstream: null\n" +
+				"peers:\n  - fd00::1\n  - fe80::1\n",
+			expect: `at 6:5: "peers": fe80::1 is not within fd00::/8, 10.0.0.0/8`,
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			_, err := LoadSrc[TestConfig](td.src)
+			require.ErrorIs(t, err, yamagiconf.ErrNotWithinCIDR)
+			require.Equal(t, td.expect, err.Error())
+		})
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("bind: 10.0.0.300\n" +
+			"gateway: 192.168.0.1\nupstream: null\npeers: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrInvalidIPAddress)
+		require.Equal(t, `at 1:7: "bind": invalid IP address: "10.0.0.300"`, err.Error())
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("BIND", "127.0.0.1")
+		_, err := LoadSrc[TestConfig]("bind: 10.0.0.1\n" +
+			"gateway: 192.168.0.1\nupstream: null\npeers: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrNotWithinCIDR)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.Bind: invalid env var BIND: `+
+			`127.0.0.1 is not within 10.0.0.0/8`, err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{
+			Bind: "10.0.0.1", Gateway: net.ParseIP("10.0.0.1"),
+		})
+		require.ErrorIs(t, err, yamagiconf.ErrNotWithinCIDR)
+		require.Equal(t, `at TestConfig.Gateway: 10.0.0.1 is not within `+
+			`192.168.0.0/16, 172.16.0.0/12`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidCIDRTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Field string `yaml:"field" cidr:"10.0.0.0"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidCIDRTag)
+	require.Equal(t, `at struct{...}.Field: invalid cidr struct tag: `+
+		`"10.0.0.0" is not a valid CIDR`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field uint32 `yaml:"field" cidr:"10.0.0.0/8"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidCIDRTag)
+	require.Equal(t, "at struct{...}.Field: invalid cidr struct tag: "+
+		"uint32 is not an IP address", err.Error())
+}
+
+func TestNetipTypes(t *testing.T) {
+	type TestConfig struct {
+		Listen   netip.AddrPort       `yaml:"listen" env:"LISTEN"`
+		Peers    []netip.Addr         `yaml:"peers"`
+		Gateway  *netip.Addr          `yaml:"gateway"`
+		Subnet   netip.Prefix         `yaml:"subnet"`
+		Weights  map[netip.Addr]uint8 `yaml:"weights"`
+		Fallback netip.Addr           `yaml:"fallback"`
+	}
+	require.NoError(t, yamagiconf.ValidateType[TestConfig]())
+
+	t.Run("ok", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`listen: "[::1]:8080"
+peers:
+  - 10.0.0.1
+  - "fd00::1"
+gateway: null
+subnet: 10.0.0.0/8
+weights:
+  10.0.0.1: 2
+fallback: 127.0.0.1
+`)
+		require.NoError(t, err)
+		require.Equal(t, netip.MustParseAddrPort("[::1]:8080"), c.Listen)
+		require.Equal(t, []netip.Addr{
+			netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("fd00::1"),
+		}, c.Peers)
+		require.Nil(t, c.Gateway)
+		require.Equal(t, netip.MustParsePrefix("10.0.0.0/8"), c.Subnet)
+		require.Equal(t, map[netip.Addr]uint8{
+			netip.MustParseAddr("10.0.0.1"): 2,
+		}, c.Weights)
+
+		b, err := yamagiconf.Marshal(*c)
+		require.NoError(t, err)
+		loaded, err := LoadSrc[TestConfig](string(b))
+		require.NoError(t, err)
+		require.Equal(t, c, loaded)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("LISTEN", "0.0.0.0:9090")
+		c, err := LoadSrc[TestConfig](`listen: "[::1]:8080"
+peers: []
+gateway: 10.0.0.254
+subnet: 10.0.0.0/8
+weights: {}
+fallback: 127.0.0.1
+`)
+		require.NoError(t, err)
+		require.Equal(t, netip.MustParseAddrPort("0.0.0.0:9090"), c.Listen)
+		require.Equal(t, PtrTo(netip.MustParseAddr("10.0.0.254")), c.Gateway)
+	})
+
+	for _, td := range []struct {
+		name, src, expect string
+	}{
+		{
+			name: "addr_port",
+			src: `listen: 10.0.0.1
+peers: []
+gateway: null
+subnet: 10.0.0.0/8
+weights: {}
+fallback: 127.0.0.1
+`,
+			expect: `at 1:9: "listen" (TestConfig.Listen): ` +
+				`invalid IP address: not an ip:port`,
+		},
+		{
+			name: "slice_item",
+			src: `listen: 10.0.0.1:80
+peers: [10.0.0.1, 10.0.0]
+gateway: null
+subnet: 10.0.0.0/8
+weights: {}
+fallback: 127.0.0.1
+`,
+			expect: `at 2:19: "peers" (TestConfig.Peers[1]): ` +
+				`invalid IP address: ParseAddr("10.0.0"): IPv4 address too short`,
+		},
+		{
+			name: "map_key",
+			src: `listen: 10.0.0.1:80
+peers: []
+gateway: null
+subnet: 10.0.0.0/8
+weights:
+  localhost: 1
+fallback: 127.0.0.1
+`,
+			expect: `at 6:3: "weights" (TestConfig.Weights["localhost"]): ` +
+				`invalid IP address: ParseAddr("localhost"): unable to parse IP`,
+		},
+		{
+			name: "prefix",
+			src: `listen: 10.0.0.1:80
+peers: []
+gateway: null
+subnet: 10.0.0.0/33
+weights: {}
+fallback: 127.0.0.1
+`,
+			expect: `at 4:9: "subnet" (TestConfig.Subnet): ` +
+				`invalid IP address: netip.ParsePrefix("10.0.0.0/33"): ` +
+				`prefix length out of range`,
+		},
+		{
+			name: "empty",
+			src: `listen: 10.0.0.1:80
+peers: []
+gateway: null
+subnet: 10.0.0.0/8
+weights: {}
+fallback: ""
+`,
+			expect: `at 6:11: "fallback" (TestConfig.Fallback): ` +
+				`invalid IP address: empty netip.Addr`,
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			_, err := LoadSrc[TestConfig](td.src)
+			require.ErrorIs(t, err, yamagiconf.ErrInvalidIPAddress)
+			require.Equal(t, td.expect, err.Error())
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults returns a T with the values of all `default` struct tags applied
+// and zero values elsewhere, validated the same way as Validate does.
+// An error is returned if ValidateType fails for T or if the resulting
+// value doesn't pass validation, for example because a field marked as
+// required by a validator struct tag has no default.
+//
+// The `default` struct tag is supported on the same types as the `env`
+// struct tag and its value is parsed the same way env vars are.
+// Pointers to structs without a default are left nil.
+// Load applies `default` struct tags to fields missing in the document
+// the same way before validating.
+func Defaults[T any]() (T, error) {
+	var t T
+	if err := ValidateType[T](); err != nil {
+		return t, err
+	}
+	applyDefaults(reflect.ValueOf(&t).Elem())
+	if err := Validate(t); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// applyDefaults assigns the values of `default` struct tags
+// to the fields of struct v recursively.
+// Assumes that the type of v has already been validated.
+func applyDefaults(v reflect.Value) {
+	tp := v.Type()
+	for i := range tp.NumField() {
+		f := tp.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if d, ok := f.Tag.Lookup("default"); ok {
+			// The default value was checked by ValidateType already.
+			_ = setFromString(fv, d)
+			continue
+		}
+		if fv.Kind() == reflect.Struct &&
+			!implementsInterface[encoding.TextUnmarshaler](fv.Type()) &&
+			!implementsInterface[yaml.Unmarshaler](fv.Type()) {
+			applyDefaults(fv)
+		}
+	}
+}
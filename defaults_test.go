@@ -0,0 +1,214 @@
+package yamagiconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaults(t *testing.T) {
+	type Embedded struct {
+		Embedded string `yaml:"embedded" default:"emb"`
+	}
+	type Server struct {
+		Host    string        `yaml:"host" default:"localhost"`
+		Port    uint16        `yaml:"port" default:"8080"`
+		Timeout time.Duration `yaml:"timeout" default:"30s"`
+	}
+	type TestConfig struct {
+		Embedded  `yaml:",inline"`
+		Server    Server           `yaml:"server"`
+		Debug     bool             `yaml:"debug" default:"true"`
+		Ratio     float32          `yaml:"ratio" default:"0.5"`
+		Limit     *int32           `yaml:"limit" default:"-1"`
+		NoLimit   *int32           `yaml:"no-limit" default:"null"`
+		Text      TextUnmarshaler  `yaml:"text" default:"txt"`
+		TextPtr   *TextUnmarshaler `yaml:"text-ptr" default:"txtptr"`
+		NoDefault string           `yaml:"no-default"`
+		Optional  *Server          `yaml:"optional"`
+		Ignored   string           `yaml:"-" default:"ignored"`
+	}
+
+	c, err := yamagiconf.Defaults[TestConfig]()
+	require.NoError(t, err)
+	require.Equal(t, TestConfig{
+		Embedded: Embedded{Embedded: "emb"},
+		Server: Server{
+			Host:    "localhost",
+			Port:    8080,
+			Timeout: 30 * time.Second,
+		},
+		Debug:   true,
+		Ratio:   0.5,
+		Limit:   PtrTo(int32(-1)),
+		Text:    TextUnmarshaler{Str: "txt"},
+		TextPtr: &TextUnmarshaler{Str: "txtptr"},
+		Ignored: "ignored",
+	}, c)
+}
+
+func TestDefaultsErrValidation(t *testing.T) {
+	t.Run("required_without_default", func(t *testing.T) {
+		type TestConfig struct {
+			Name string `yaml:"name" validate:"required"`
+			Port uint16 `yaml:"port" default:"80"`
+		}
+		_, err := yamagiconf.Defaults[TestConfig]()
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Name: violates validation rule: "required"`,
+			err.Error())
+	})
+
+	t.Run("default_violates_rule", func(t *testing.T) {
+		type TestConfig struct {
+			Port uint16 `yaml:"port" default:"80" validate:"gt=1024"`
+		}
+		_, err := yamagiconf.Defaults[TestConfig]()
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Port: violates validation rule: "gt"`,
+			err.Error())
+	})
+
+	t.Run("validate_method", func(t *testing.T) {
+		type TestConfig struct {
+			Str ValidatedString `yaml:"str" default:"invalid"`
+		}
+		_, err := yamagiconf.Defaults[TestConfig]()
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+	})
+}
+
+func TestLoadDefaultTag(t *testing.T) {
+	type Server struct {
+		Host    string        `yaml:"host" default:"localhost" validate:"required"`
+		Port    uint16        `yaml:"port" default:"8080"`
+		Timeout time.Duration `yaml:"timeout" default:"30s"`
+	}
+	type TestConfig struct {
+		Server  Server           `yaml:"server"`
+		Debug   bool             `yaml:"debug" default:"true"`
+		Limit   *int32           `yaml:"limit" default:"-1"`
+		NoLimit *int32           `yaml:"no-limit" default:"null"`
+		Text    TextUnmarshaler  `yaml:"text" default:"txt"`
+		TextPtr *TextUnmarshaler `yaml:"text-ptr" default:"txtptr"`
+		Name    string           `yaml:"name"`
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("server: {}\nname: x\n")
+		require.NoError(t, err)
+		require.Equal(t, &TestConfig{
+			Server: Server{
+				Host:    "localhost",
+				Port:    8080,
+				Timeout: 30 * time.Second,
+			},
+			Debug:   true,
+			Limit:   PtrTo(int32(-1)),
+			Text:    TextUnmarshaler{Str: "txt"},
+			TextPtr: &TextUnmarshaler{Str: "txtptr"},
+			Name:    "x",
+		}, c)
+	})
+
+	t.Run("present", func(t *testing.T) {
+		// Values in the document are never overwritten, not even zero values.
+		c, err := LoadSrc[TestConfig](`server:
+  host: example.com
+  port: 0
+  timeout: 0s
+debug: false
+limit: null
+no-limit: 5
+text: ""
+text-ptr: null
+name: x
+`)
+		require.NoError(t, err)
+		require.Equal(t, &TestConfig{
+			Server:  Server{Host: "example.com"},
+			NoLimit: PtrTo(int32(5)),
+			Name:    "x",
+		}, c)
+	})
+
+	t.Run("env_overrides_default", func(t *testing.T) {
+		type TestConfig struct {
+			Port uint16 `yaml:"port" default:"8080" env:"PORT"`
+		}
+		t.Setenv("PORT", "9090")
+		c, err := LoadSrc[TestConfig]("{}\n")
+		require.NoError(t, err)
+		require.Equal(t, uint16(9090), c.Port)
+	})
+
+	t.Run("validated", func(t *testing.T) {
+		type TestConfig struct {
+			Port uint16          `yaml:"port" default:"80" validate:"gt=1024"`
+			Str  ValidatedString `yaml:"str" default:"invalid"`
+		}
+		_, err := LoadSrc[TestConfig]("str: valid\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:1: "port" violates validation rule: "gt"`,
+			err.Error())
+
+		_, err = LoadSrc[TestConfig]("port: 2048\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.Equal(t, `at 1:1: at TestConfig.Str: validation: is not 'valid'`,
+			err.Error())
+	})
+
+	t.Run("missing_without_default", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("server: {}\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at TestConfig.Name (as "name"): `+
+			`missing field in config file`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidDefaultTag(t *testing.T) {
+	t.Run("syntax", func(t *testing.T) {
+		type TestConfig struct {
+			Port uint16 `yaml:"port" default:"http"`
+		}
+		err := yamagiconf.ValidateType[TestConfig]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidDefaultTag)
+		require.Equal(t, `at TestConfig.Port: invalid default struct tag: `+
+			`expected uint16: strconv.ParseUint: parsing "http": invalid syntax`,
+			err.Error())
+	})
+
+	t.Run("bool", func(t *testing.T) {
+		type TestConfig struct {
+			Debug bool `yaml:"debug" default:"yes"`
+		}
+		err := yamagiconf.ValidateType[TestConfig]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidDefaultTag)
+		require.Equal(t, `at TestConfig.Debug: invalid default struct tag: `+
+			`expected bool: "yes"`, err.Error())
+	})
+
+	t.Run("unsupported_type", func(t *testing.T) {
+		type TestConfig struct {
+			List []string `yaml:"list" default:"a,b"`
+		}
+		err := yamagiconf.ValidateType[TestConfig]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidDefaultTag)
+		require.Equal(t, `at TestConfig.List: invalid default struct tag: `+
+			`unsupported type []string`, err.Error())
+	})
+
+	t.Run("unexported", func(t *testing.T) {
+		type TestConfig struct {
+			Port     uint16 `yaml:"port"`
+			internal string `default:"x"`
+		}
+		err := yamagiconf.ValidateType[TestConfig]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidDefaultTag)
+		require.Equal(t, `at TestConfig.internal: invalid default struct tag: `+
+			`unexported field`, err.Error())
+	})
+}
@@ -0,0 +1,66 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// LoadDir reads and validates every file with extension ".yaml" or ".yml"
+// in directory dir like LoadFileWithOptions and assigns them to *out keyed
+// by file name without extension, which is useful for drop-in config
+// directories such as /etc/app/conf.d. Subdirectories and other files are
+// ignored, an empty directory results in an empty map.
+// Errors of individual files name the file and files resolving to the same
+// key (like a.yaml and a.yml) are rejected with ErrDirDuplicateKey.
+// *out is only assigned if all files were loaded successfully.
+func LoadDir[V any](dir string, out *map[string]V, opts ...Option) error {
+	if out == nil {
+		return ErrConfigNil
+	}
+	if err := newOptions(opts).validateType(reflect.TypeFor[V]()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading directory %q: %w", dir, err)
+	}
+	m := make(map[string]V, len(entries))
+	files := make(map[string]string, len(entries)) // Key -> file name.
+	for _, e := range entries {
+		name := e.Name()
+		ext := filepath.Ext(name)
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		key := strings.TrimSuffix(name, ext)
+		if prev, ok := files[key]; ok {
+			return fmt.Errorf("in file %q: %w: %q already defined by %q",
+				path, ErrDirDuplicateKey, key, prev)
+		}
+		files[key] = name
+
+		// Each file gets its own options since they keep per-load state.
+		o := newOptions(opts)
+		o.typeValidated = true
+		o.baseDir = dir
+		if err := o.checkFileMode(path); err != nil {
+			return err
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading file %q: %w", path, err)
+		}
+		var v V
+		if err := load(o, src, &v); err != nil {
+			return fmt.Errorf("in file %q: %w", path, err)
+		}
+		m[key] = v
+	}
+	*out = m
+	return nil
+}
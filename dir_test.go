@@ -0,0 +1,97 @@
+package yamagiconf_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDir(t *testing.T) {
+	type Site struct {
+		Host string `yaml:"host" validate:"required"`
+		Port uint16 `yaml:"port"`
+	}
+
+	write := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			p := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o700))
+			require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+		}
+		return dir
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		dir := write(t, map[string]string{
+			"a.yaml":          "host: a.example\nport: 80\n",
+			"b.yml":           "host: b.example\nport: 443\n",
+			"README.md":       "ignored",
+			"sub/c.yaml":      "ignored",
+			"d.yaml.disabled": "ignored",
+		})
+		var m map[string]Site
+		err := yamagiconf.LoadDir(dir, &m)
+		require.NoError(t, err)
+		require.Equal(t, map[string]Site{
+			"a": {Host: "a.example", Port: 80},
+			"b": {Host: "b.example", Port: 443},
+		}, m)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var m map[string]Site
+		err := yamagiconf.LoadDir(write(t, nil), &m)
+		require.NoError(t, err)
+		require.Equal(t, map[string]Site{}, m)
+	})
+
+	t.Run("invalid_file", func(t *testing.T) {
+		dir := write(t, map[string]string{
+			"a.yaml": "host: a.example\nport: 80\n",
+			"b.yaml": "host: ''\nport: 443\n",
+		})
+		m := map[string]Site{"x": {}}
+		err := yamagiconf.LoadDir(dir, &m)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `in file "`+filepath.Join(dir, "b.yaml")+`": `+
+			`at 1:7: "host" violates validation rule: "required"`, err.Error())
+		require.Equal(t, map[string]Site{"x": {}}, m, "must not be assigned")
+	})
+
+	t.Run("duplicate_key", func(t *testing.T) {
+		dir := write(t, map[string]string{
+			"a.yaml": "host: a.example\nport: 80\n",
+			"a.yml":  "host: a.example\nport: 81\n",
+		})
+		var m map[string]Site
+		err := yamagiconf.LoadDir(dir, &m)
+		require.ErrorIs(t, err, yamagiconf.ErrDirDuplicateKey)
+		require.Equal(t, `in file "`+filepath.Join(dir, "a.yml")+`": `+
+			`duplicate key in directory: "a" already defined by "a.yaml"`,
+			err.Error())
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		var m map[string]Site
+		err := yamagiconf.LoadDir(filepath.Join(t.TempDir(), "missing"), &m)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("invalid_type", func(t *testing.T) {
+		var m map[string]struct {
+			Int int `yaml:"int"`
+		}
+		err := yamagiconf.LoadDir(t.TempDir(), &m)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeUnsupported)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		err := yamagiconf.LoadDir[Site](t.TempDir(), nil)
+		require.ErrorIs(t, err, yamagiconf.ErrConfigNil)
+	})
+}
@@ -0,0 +1,130 @@
+package yamagiconf
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GenerateMarkdownDocs returns a Markdown reference of config type T
+// listing every field with its YAML path, Go type, whether it's required
+// (has the validate rule "required"), its env vars, validation rules
+// and description provided by the `doc` struct tag.
+// Each nested struct is described in its own section titled by its YAML path,
+// items of slices and arrays are denoted by `[]` and map values by `.*`.
+// Returns the same errors as ValidateType if T is invalid.
+func GenerateMarkdownDocs[T any]() ([]byte, error) {
+	tp := reflect.TypeFor[T]()
+	if err := newOptions(nil).validateType(tp); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n", getConfigTypeName(tp))
+	writeMarkdownSection(&b, "", tp)
+	return b.Bytes(), nil
+}
+
+// markdownSection is a nested struct described in its own section.
+type markdownSection struct {
+	path string
+	tp   reflect.Type
+}
+
+// writeMarkdownSection writes the table of the fields of struct type tp
+// at YAML path followed by the sections of its nested structs.
+func writeMarkdownSection(b *bytes.Buffer, path string, tp reflect.Type) {
+	if path != "" {
+		fmt.Fprintf(b, "\n## %s\n", path)
+	}
+	b.WriteString("\n| Field | Type | Required | Env | Validate | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	var sections []markdownSection
+	writeMarkdownRows(b, path, tp, &sections)
+	for _, s := range sections {
+		writeMarkdownSection(b, s.path, s.tp)
+	}
+}
+
+// writeMarkdownRows writes a table row for each field of struct type tp
+// including the fields of inlined embedded structs and appends
+// the nested structs to sections.
+func writeMarkdownRows(
+	b *bytes.Buffer, path string, tp reflect.Type, sections *[]markdownSection,
+) {
+	for i := range tp.NumField() {
+		f := tp.Field(i)
+		yamlTag := getYAMLFieldName(f.Tag)
+		if !f.IsExported() || yamlTag == "-" {
+			continue
+		}
+		if f.Anonymous {
+			t := f.Type
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			writeMarkdownRows(b, path, t, sections)
+			continue
+		}
+		fieldPath := yamlTag
+		if path != "" {
+			fieldPath = path + "." + yamlTag
+		}
+		required := "no"
+		if slices.Contains(strings.Split(f.Tag.Get("validate"), ","), "required") {
+			required = "yes"
+		}
+		names, envRequired, _ := parseEnvTag(f.Tag.Get("env"))
+		env := strings.Join(names, ", ")
+		if envRequired {
+			env += " (required)"
+		}
+		fmt.Fprintf(b, "| `%s` | `%s` | %s | %s | %s | %s |\n",
+			fieldPath, f.Type.String(), required,
+			markdownCode(env), markdownCode(f.Tag.Get("validate")),
+			markdownEscape(f.Tag.Get("doc")))
+		if s, ok := nestedStruct(fieldPath, f.Type); ok {
+			*sections = append(*sections, s)
+		}
+	}
+}
+
+// nestedStruct returns the section of the struct type contained by tp,
+// if any, unwrapping pointers, slices, arrays and maps.
+func nestedStruct(path string, tp reflect.Type) (markdownSection, bool) {
+	for {
+		if implementsInterface[encoding.TextUnmarshaler](tp) ||
+			implementsInterface[yaml.Unmarshaler](tp) {
+			return markdownSection{}, false
+		}
+		switch tp.Kind() {
+		case reflect.Pointer:
+			tp = tp.Elem()
+		case reflect.Slice, reflect.Array:
+			path, tp = path+"[]", tp.Elem()
+		case reflect.Map:
+			path, tp = path+".*", tp.Elem()
+		case reflect.Struct:
+			return markdownSection{path: path, tp: tp}, true
+		default:
+			return markdownSection{}, false
+		}
+	}
+}
+
+// markdownCode returns s as inline code or an empty string if s is empty.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownEscape(s) + "`"
+}
+
+// markdownEscape escapes s for use in a table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,64 @@
+package yamagiconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+type DocsConfig struct {
+	DocsEmbedded `yaml:",inline"`
+	Server       DocsServer            `yaml:"server" doc:"HTTP server."`
+	Upstreams    map[string]DocsServer `yaml:"upstreams"`
+	Tags         []string              `yaml:"tags" validate:"dive,oneof=a|b"`
+	Ignored      string                `yaml:"-"`
+}
+
+type DocsEmbedded struct {
+	Name string `yaml:"name" validate:"required" doc:"Name of the instance."`
+}
+
+type DocsServer struct {
+	Host    string        `yaml:"host" env:"HOST,SERVER_HOST,required" validate:"required,hostname"`
+	Timeout time.Duration `yaml:"timeout" doc:"Request timeout, 0 disables it."`
+}
+
+func TestGenerateMarkdownDocs(t *testing.T) {
+	b, err := yamagiconf.GenerateMarkdownDocs[DocsConfig]()
+	require.NoError(t, err)
+	require.Equal(t, "# DocsConfig\n"+
+		"\n"+
+		"| Field | Type | Required | Env | Validate | Description |\n"+
+		"| --- | --- | --- | --- | --- | --- |\n"+
+		"| `name` | `string` | yes |  | `required` | Name of the instance. |\n"+
+		"| `server` | `yamagiconf_test.DocsServer` | no |  |  | HTTP server. |\n"+
+		"| `upstreams` | `map[string]yamagiconf_test.DocsServer` | no |  |  |  |\n"+
+		"| `tags` | `[]string` | no |  | `dive,oneof=a\\|b` |  |\n"+
+		"\n"+
+		"## server\n"+
+		"\n"+
+		"| Field | Type | Required | Env | Validate | Description |\n"+
+		"| --- | --- | --- | --- | --- | --- |\n"+
+		"| `server.host` | `string` | yes | `HOST, SERVER_HOST (required)` | "+
+		"`required,hostname` |  |\n"+
+		"| `server.timeout` | `time.Duration` | no |  |  | "+
+		"Request timeout, 0 disables it. |\n"+
+		"\n"+
+		"## upstreams.*\n"+
+		"\n"+
+		"| Field | Type | Required | Env | Validate | Description |\n"+
+		"| --- | --- | --- | --- | --- | --- |\n"+
+		"| `upstreams.*.host` | `string` | yes | `HOST, SERVER_HOST (required)` | "+
+		"`required,hostname` |  |\n"+
+		"| `upstreams.*.timeout` | `time.Duration` | no |  |  | "+
+		"Request timeout, 0 disables it. |\n", string(b))
+}
+
+func TestGenerateMarkdownDocsErrInvalidType(t *testing.T) {
+	_, err := yamagiconf.GenerateMarkdownDocs[struct {
+		Int int `yaml:"int"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeUnsupported)
+}
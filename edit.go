@@ -0,0 +1,218 @@
+package yamagiconf
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EditValue replaces the value at goPath in the YAML document src with
+// newValue and returns the updated document preserving comments.
+// goPath is the path of a field of T, such as "Server.Port" or "Config.Server.Port",
+// slice items are addressed by index ("List[0]") and map entries by key
+// ("Map[key]" or `Map["key"]`). The target must be a scalar value of a type that
+// is also supported by `env` struct tags and must not be an alias.
+// newValue is written as a string for string types and types implementing
+// encoding.TextUnmarshaler, and as a plain scalar otherwise.
+// Quoted values stay quoted.
+//
+// The resulting document is validated the same way LoadWithOptions does before
+// it's returned. Comments are preserved, but indentation is normalized to
+// the indentation of the first nested block found in src.
+func EditValue[T any](
+	src []byte, goPath string, newValue string, opts ...Option,
+) ([]byte, error) {
+	if err := newOptions(opts).validateType(reflect.TypeFor[T]()); err != nil {
+		return nil, err
+	}
+	if len(src) == 0 {
+		return nil, ErrYAMLEmptyFile
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrYAMLMalformed, err)
+	}
+	if len(doc.Content) < 1 {
+		return nil, ErrYAMLEmptyFile
+	}
+
+	tp := reflect.TypeFor[T]()
+	node, tp, err := findNodeByGoPath(tp, doc.Content[0], goPath)
+	if err != nil {
+		return nil, fmt.Errorf("at %s: %w", goPath, err)
+	}
+
+	base := tp
+	for base.Kind() == reflect.Pointer {
+		base = base.Elem()
+	}
+	switch {
+	case newValue == "null" && tp.Kind() == reflect.Pointer:
+		node.Tag, node.Style, node.Value = "!!null", 0, "null"
+	case base.Kind() == reflect.String ||
+		implementsInterface[encoding.TextUnmarshaler](base):
+		quoted := node.Style & (yaml.SingleQuotedStyle | yaml.DoubleQuotedStyle)
+		node.SetString(newValue)
+		if quoted != 0 && !strings.Contains(newValue, "\n") {
+			node.Style = quoted
+		}
+	default:
+		node.Tag, node.Style, node.Value = "", 0, newValue
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(detectIndent(doc.Content[0]))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("encoding yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding yaml: %w", err)
+	}
+
+	var config T
+	if err := LoadWithOptions(b.Bytes(), &config, opts...); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// findNodeByGoPath returns the scalar node and the type of
+// the value at goPath in node which is the root of a document of type tp.
+func findNodeByGoPath(
+	tp reflect.Type, node *yaml.Node, goPath string,
+) (*yaml.Node, reflect.Type, error) {
+	elements, err := parseGoPath(goPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(elements) > 0 && elements[0] == getConfigTypeName(tp) {
+		elements = elements[1:] // Remove type name prefix.
+	}
+	if len(elements) < 1 {
+		return nil, nil, fmt.Errorf("%w: path doesn't point to a field",
+			ErrEditInvalidPath)
+	}
+
+	for _, e := range elements {
+		if node.Alias != nil {
+			return nil, nil, fmt.Errorf("%w: %s is an alias", ErrEditInvalidPath, e)
+		}
+		for tp.Kind() == reflect.Pointer {
+			tp = tp.Elem()
+		}
+		switch {
+		case strings.HasPrefix(e, "[") && node.Kind == yaml.SequenceNode &&
+			(tp.Kind() == reflect.Slice || tp.Kind() == reflect.Array):
+			i, err := strconv.Atoi(e[1 : len(e)-1])
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return nil, nil, fmt.Errorf("%w: index %s out of range",
+					ErrEditInvalidPath, e)
+			}
+			tp, node = tp.Elem(), node.Content[i]
+		case strings.HasPrefix(e, "[") && node.Kind == yaml.MappingNode &&
+			tp.Kind() == reflect.Map:
+			key := e[1 : len(e)-1]
+			if k, err := strconv.Unquote(key); err == nil {
+				key = k
+			}
+			i := findMappingKey(node, key)
+			if i == -1 {
+				return nil, nil, fmt.Errorf("%w: key %q not found",
+					ErrEditInvalidPath, key)
+			}
+			tp, node = tp.Elem(), node.Content[i+1]
+		case !strings.HasPrefix(e, "[") && node.Kind == yaml.MappingNode &&
+			tp.Kind() == reflect.Struct:
+			f, ok := tp.FieldByName(e)
+			yamlTag := getYAMLFieldName(f.Tag)
+			if !ok || !f.IsExported() || yamlTag == "-" || yamlTag == "" {
+				return nil, nil, fmt.Errorf("%w: field %s not found",
+					ErrEditInvalidPath, e)
+			}
+			n := findContentNodeByTag(node, yamlTag)
+			if n == nil {
+				return nil, nil, fmt.Errorf("%w: field %s (%q) not found in document",
+					ErrEditInvalidPath, e, yamlTag)
+			}
+			tp, node = f.Type, n
+		default:
+			return nil, nil, fmt.Errorf("%w: unexpected element %s",
+				ErrEditInvalidPath, e)
+		}
+	}
+	if node.Alias != nil {
+		return nil, nil, fmt.Errorf("%w: value is an alias", ErrEditInvalidPath)
+	}
+	if node.Kind != yaml.ScalarNode || !isEnvSupportedType(tp) {
+		return nil, nil, fmt.Errorf("%w: value of type %s isn't a scalar",
+			ErrEditInvalidPath, tp.String())
+	}
+	return node, tp, nil
+}
+
+// parseGoPath splits a Go path like `Config.List[0].Map["key"]` into
+// its elements: "Config", "List", "[0]", "Map", `["key"]`.
+func parseGoPath(p string) ([]string, error) {
+	var elements []string
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			continue
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if len(p) > 1 && p[1] == '"' {
+				// Quoted key may contain brackets.
+				q, err := strconv.QuotedPrefix(p[1:])
+				if err != nil {
+					return nil, fmt.Errorf("%w: %w", ErrEditInvalidPath, err)
+				}
+				end = 1 + len(q)
+				if end >= len(p) || p[end] != ']' {
+					end = -1
+				}
+			}
+			if end == -1 {
+				return nil, fmt.Errorf("%w: unterminated bracket", ErrEditInvalidPath)
+			}
+			elements, p = append(elements, p[:end+1]), p[end+1:]
+			continue
+		}
+		end := strings.IndexAny(p, ".[")
+		if end == -1 {
+			end = len(p)
+		}
+		elements, p = append(elements, p[:end]), p[end:]
+	}
+	return elements, nil
+}
+
+// detectIndent returns the indentation width of the first nested block
+// mapping or sequence found in n, or 2 if there's none.
+func detectIndent(n *yaml.Node) int {
+	if n.Kind == yaml.MappingNode && n.Style&yaml.FlowStyle == 0 {
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 &&
+				(value.Kind == yaml.MappingNode || value.Kind == yaml.SequenceNode) {
+				if d := value.Content[0].Column - key.Column; d > 0 {
+					if value.Kind == yaml.SequenceNode {
+						// The first item column is after "- ".
+						d -= 2
+					}
+					if d > 0 {
+						return d
+					}
+				}
+				return detectIndent(value)
+			}
+		}
+	}
+	return 2
+}
@@ -0,0 +1,171 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditValue(t *testing.T) {
+	type Server struct {
+		Host string  `yaml:"host"`
+		Port uint16  `yaml:"port" validate:"gt=0"`
+		Name *string `yaml:"name"`
+	}
+	type TestConfig struct {
+		Server  Server            `yaml:"server"`
+		Tags    []string          `yaml:"tags"`
+		Labels  map[string]string `yaml:"labels"`
+		Enabled bool              `yaml:"enabled"`
+	}
+	const src = `# Server configuration.
+server:
+  # The host name.
+  host: 'example.com' # Public host.
+  port: 443
+  name: null
+tags:
+  - a
+  - b # second
+labels:
+  env: prod
+# Whether it's enabled.
+enabled: false
+`
+
+	t.Run("int", func(t *testing.T) {
+		out, err := yamagiconf.EditValue[TestConfig]([]byte(src), "Server.Port", "8080")
+		require.NoError(t, err)
+		require.Equal(t, `# Server configuration.
+server:
+  # The host name.
+  host: 'example.com' # Public host.
+  port: 8080
+  name: null
+tags:
+  - a
+  - b # second
+labels:
+  env: prod
+# Whether it's enabled.
+enabled: false
+`, string(out))
+	})
+
+	t.Run("quoted_string", func(t *testing.T) {
+		out, err := yamagiconf.EditValue[TestConfig](
+			[]byte(src), "TestConfig.Server.Host", "localhost")
+		require.NoError(t, err)
+		require.Contains(t, string(out), "  host: 'localhost' # Public host.\n")
+	})
+
+	t.Run("string_requiring_quotes", func(t *testing.T) {
+		out, err := yamagiconf.EditValue[TestConfig]([]byte(src), "Tags[1]", "true")
+		require.NoError(t, err)
+		require.Contains(t, string(out), "  - \"true\" # second\n")
+
+		var c TestConfig
+		require.NoError(t, yamagiconf.Load(out, &c))
+		require.Equal(t, []string{"a", "true"}, c.Tags)
+	})
+
+	t.Run("map_value", func(t *testing.T) {
+		out, err := yamagiconf.EditValue[TestConfig](
+			[]byte(src), `Labels["env"]`, "staging")
+		require.NoError(t, err)
+		require.Contains(t, string(out), "  env: staging\n")
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		out, err := yamagiconf.EditValue[TestConfig]([]byte(src), "Server.Name", "x")
+		require.NoError(t, err)
+		require.Contains(t, string(out), "  name: x\n")
+
+		out, err = yamagiconf.EditValue[TestConfig](out, "Server.Name", "null")
+		require.NoError(t, err)
+		require.Equal(t, src, string(out))
+	})
+
+	t.Run("bool", func(t *testing.T) {
+		out, err := yamagiconf.EditValue[TestConfig]([]byte(src), "Enabled", "true")
+		require.NoError(t, err)
+		require.Contains(t, string(out), "# Whether it's enabled.\nenabled: true\n")
+	})
+
+	t.Run("err_type", func(t *testing.T) {
+		_, err := yamagiconf.EditValue[TestConfig]([]byte(src), "Server.Port", "http")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+	})
+
+	t.Run("err_bool_literal", func(t *testing.T) {
+		_, err := yamagiconf.EditValue[TestConfig]([]byte(src), "Enabled", "yes")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadBoolLiteral)
+	})
+
+	t.Run("err_validation", func(t *testing.T) {
+		_, err := yamagiconf.EditValue[TestConfig]([]byte(src), "Server.Port", "0")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 5:9: "port" violates validation rule: "gt"`, err.Error())
+	})
+
+	t.Run("err_null_on_non_pointer", func(t *testing.T) {
+		_, err := yamagiconf.EditValue[TestConfig]([]byte(src), "Server.Port", "null")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLNullOnNonPointer)
+	})
+
+	for _, tt := range []struct {
+		name, path, expect string
+	}{
+		{"unknown_field", "Server.Unknown",
+			"at Server.Unknown: invalid edit path: field Unknown not found"},
+		{"not_scalar", "Server",
+			"at Server: invalid edit path: value of type " +
+				"yamagiconf_test.Server isn't a scalar"},
+		{"index_out_of_range", "Tags[2]",
+			"at Tags[2]: invalid edit path: index [2] out of range"},
+		{"unknown_key", "Labels[foo]",
+			`at Labels[foo]: invalid edit path: key "foo" not found`},
+		{"index_on_struct", "Server[0]",
+			"at Server[0]: invalid edit path: unexpected element [0]"},
+		{"empty", "TestConfig",
+			"at TestConfig: invalid edit path: path doesn't point to a field"},
+		{"unterminated", "Tags[0",
+			"at Tags[0: invalid edit path: unterminated bracket"},
+	} {
+		t.Run("err_path_"+tt.name, func(t *testing.T) {
+			_, err := yamagiconf.EditValue[TestConfig]([]byte(src), tt.path, "x")
+			require.ErrorIs(t, err, yamagiconf.ErrEditInvalidPath)
+			require.Equal(t, tt.expect, err.Error())
+		})
+	}
+
+	t.Run("err_alias", func(t *testing.T) {
+		_, err := yamagiconf.EditValue[TestConfig]([]byte(`
+server:
+  host: &h example.com
+  port: 443
+  name: *h
+tags: []
+labels: {}
+enabled: false
+`), "Server.Name", "x")
+		require.ErrorIs(t, err, yamagiconf.ErrEditInvalidPath)
+		require.Equal(t, "at Server.Name: invalid edit path: value is an alias",
+			err.Error())
+	})
+}
+
+func TestEditValueIndent(t *testing.T) {
+	type Server struct {
+		Port uint16 `yaml:"port"`
+	}
+	type TestConfig struct {
+		Server Server `yaml:"server"`
+	}
+	out, err := yamagiconf.EditValue[TestConfig](
+		[]byte("server:\n    port: 80\n"), "Server.Port", "81")
+	require.NoError(t, err)
+	require.Equal(t, "server:\n    port: 81\n", string(out))
+}
@@ -0,0 +1,94 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EncryptedPrefix is the prefix of encrypted values of fields with
+// the struct tag `encrypted:"true"`, see WithFieldDecryptor.
+const EncryptedPrefix = "enc:"
+
+// WithFieldDecryptor sets decrypt as the decryptor of string fields with
+// the struct tag `encrypted:"true"`. Values of such fields that begin with
+// EncryptedPrefix, such as `token: enc:AES256:...`, are passed to decrypt
+// without the prefix and the returned plaintext is assigned instead before
+// the value is validated. Values without the prefix are assigned as is
+// to allow mixing plaintext and encrypted values during migration.
+// Errors returned by decrypt and encrypted values found without a decryptor
+// are reported with ErrDecryption at the location of the value.
+// Encrypted fields are always redacted by MarshalRedacted.
+func WithFieldDecryptor(decrypt func(ciphertext string) (string, error)) Option {
+	return func(o *options) { o.decryptor = decrypt }
+}
+
+// validateEncryptedField returns an error if f has an `encrypted` struct tag
+// other than true or false, f isn't a string or pointer to string
+// or f also has a `resolve` struct tag.
+func validateEncryptedField(f reflect.StructField) error {
+	e, ok := f.Tag.Lookup("encrypted")
+	if !ok {
+		return nil
+	}
+	if e != "true" && e != "false" {
+		return fmt.Errorf("%w: %q, expected true or false",
+			ErrTypeInvalidEncryptedTag, e)
+	}
+	if _, ok := f.Tag.Lookup("resolve"); ok {
+		return fmt.Errorf("%w: can't be combined with resolve struct tag",
+			ErrTypeInvalidEncryptedTag)
+	}
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String ||
+		implementsInterface[encoding.TextUnmarshaler](t) ||
+		implementsInterface[yaml.Unmarshaler](t) {
+		return fmt.Errorf("%w: %s is not a string",
+			ErrTypeInvalidEncryptedTag, f.Type.String())
+	}
+	return nil
+}
+
+// decryptScalar decrypts the value node of struct field f
+// and returns the node of the plaintext.
+// Returns node if f isn't encrypted or the value isn't prefixed.
+func decryptScalar(
+	o *options, path, yamlTag string, f reflect.StructField, node *yaml.Node,
+) (*yaml.Node, error) {
+	if f.Tag.Get("encrypted") != "true" || o.resolved[node] {
+		// Nodes of aliased mappings are visited multiple times.
+		return node, nil
+	}
+	n := node
+	if n.Alias != nil {
+		n = n.Alias
+	}
+	ciphertext, ok := strings.CutPrefix(n.Value, EncryptedPrefix)
+	if n.Kind != yaml.ScalarNode || !ok {
+		return node, nil
+	}
+	if o.decryptor == nil {
+		return nil, o.valueError(node, yamlTag, path,
+			fmt.Errorf("%w: no decryptor", ErrDecryption))
+	}
+	plaintext, err := o.decryptor(ciphertext)
+	if err != nil {
+		return nil, o.valueError(node, yamlTag, path,
+			fmt.Errorf("%w: %w", ErrDecryption, err))
+	}
+	o.setSource(path, Provenance{Source: SourceSecret})
+	decrypted := newStringNode(plaintext)
+	// Report errors at the location of the original value.
+	decrypted.Line, decrypted.Column = node.Line, node.Column
+	if o.resolved == nil {
+		o.resolved = make(map[*yaml.Node]bool)
+	}
+	o.resolved[decrypted] = true
+	return decrypted, nil
+}
@@ -0,0 +1,121 @@
+package yamagiconf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+// rot13Decryptor is a stub decryptor expecting `ROT13:<ciphertext>`.
+func rot13Decryptor(ciphertext string) (string, error) {
+	c, ok := strings.CutPrefix(ciphertext, "ROT13:")
+	if !ok {
+		return "", errors.New("unsupported algorithm")
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		}
+		return r
+	}, c), nil
+}
+
+func TestWithFieldDecryptor(t *testing.T) {
+	type TestConfig struct {
+		Token    string  `yaml:"token" encrypted:"true" validate:"min=6"`
+		Password *string `yaml:"password" encrypted:"true"`
+		Comment  string  `yaml:"comment"`
+	}
+
+	load := func(src string, opts ...yamagiconf.Option) (TestConfig, error) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, opts...)
+		return c, err
+	}
+
+	t.Run("prefixed_and_plain", func(t *testing.T) {
+		c, err := load("token: enc:ROT13:frperg\n"+
+			"password: plaintext\n"+
+			"comment: enc:ROT13:untouched\n",
+			yamagiconf.WithFieldDecryptor(rot13Decryptor))
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Token:    "secret",
+			Password: PtrTo("plaintext"),
+			Comment:  "enc:ROT13:untouched",
+		}, c)
+	})
+
+	t.Run("pointer_and_null", func(t *testing.T) {
+		c, err := load("token: plain-token\npassword: enc:ROT13:uhagre2\n"+
+			"comment: ''\n", yamagiconf.WithFieldDecryptor(rot13Decryptor))
+		require.NoError(t, err)
+		require.Equal(t, PtrTo("hunter2"), c.Password)
+
+		c, err = load("token: plain-token\npassword: null\ncomment: ''\n",
+			yamagiconf.WithFieldDecryptor(rot13Decryptor))
+		require.NoError(t, err)
+		require.Nil(t, c.Password)
+	})
+
+	t.Run("validates_plaintext", func(t *testing.T) {
+		_, err := load("token: enc:ROT13:bx\npassword: null\ncomment: ''\n",
+			yamagiconf.WithFieldDecryptor(rot13Decryptor))
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:8: "token" violates validation rule: "min"`,
+			err.Error())
+	})
+
+	t.Run("decryption_failure", func(t *testing.T) {
+		_, err := load("token: plain-token\npassword: enc:AES256:abc\n"+
+			"comment: ''\n", yamagiconf.WithFieldDecryptor(rot13Decryptor))
+		require.ErrorIs(t, err, yamagiconf.ErrDecryption)
+		require.Equal(t, `at 2:11: "password" (TestConfig.Password): `+
+			`decryption: unsupported algorithm`, err.Error())
+	})
+
+	t.Run("no_decryptor", func(t *testing.T) {
+		_, err := load("token: enc:ROT13:frperg\npassword: null\ncomment: ''\n")
+		require.ErrorIs(t, err, yamagiconf.ErrDecryption)
+		require.Equal(t, `at 1:8: "token" (TestConfig.Token): `+
+			`decryption: no decryptor`, err.Error())
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		b, err := yamagiconf.MarshalRedacted(TestConfig{
+			Token: "secret", Comment: "public",
+		}, yamagiconf.WithRedactPattern(nil))
+		require.NoError(t, err)
+		require.Equal(t, "token: '[REDACTED]'\npassword: null\ncomment: public\n",
+			string(b))
+	})
+}
+
+func TestValidateTypeErrInvalidEncryptedTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Field string `yaml:"field" encrypted:"yes"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEncryptedTag)
+	require.Equal(t, `at struct{...}.Field: invalid encrypted struct tag: `+
+		`"yes", expected true or false`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field []byte `yaml:"field" encrypted:"true"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEncryptedTag)
+	require.Equal(t, "at struct{...}.Field: invalid encrypted struct tag: "+
+		"[]uint8 is not a string", err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field string `yaml:"field" encrypted:"true" resolve:"x"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEncryptedTag)
+	require.Equal(t, "at struct{...}.Field: invalid encrypted struct tag: "+
+		"can't be combined with resolve struct tag", err.Error())
+}
@@ -0,0 +1,75 @@
+package yamagiconf
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WithEnumMapping makes integer type T accept the names in mapping instead of
+// numbers, for example `color: green` given {"red": Red, "green": Green}.
+// Names that aren't in mapping are rejected with ErrInvalidEnumValue,
+// numbers aren't accepted. The same applies to map keys and to values
+// provided by env vars. Marshal writes the name of the value instead of the
+// number and fails with ErrInvalidEnumValue if the value has no name.
+// If multiple names map to the same value Marshal writes the
+// lexicographically smallest one.
+func WithEnumMapping[T ~int8 | ~int16 | ~int32 | ~int64](mapping map[string]T) Option {
+	e := &enumMapping{
+		values: make(map[string]int64, len(mapping)),
+		names:  make(map[int64]string, len(mapping)),
+	}
+	names := make([]string, 0, len(mapping))
+	for name, v := range mapping {
+		e.values[name] = int64(v)
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(e.values[a], e.values[b]), strings.Compare(a, b))
+	})
+	for _, name := range names {
+		if prev, ok := e.names[e.values[name]]; !ok || name < prev {
+			e.names[e.values[name]] = name
+		}
+	}
+	e.allowed = strings.Join(names, ", ")
+	tp := reflect.TypeFor[T]()
+	return func(o *options) {
+		if o.enums == nil {
+			o.enums = make(map[reflect.Type]*enumMapping)
+		}
+		o.enums[tp] = e
+	}
+}
+
+type enumMapping struct {
+	values  map[string]int64
+	names   map[int64]string
+	allowed string // Names sorted by value.
+}
+
+// value returns the value of name or an error wrapping ErrInvalidEnumValue.
+func (e *enumMapping) value(name string) (int64, error) {
+	v, ok := e.values[name]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q, allowed: %s", ErrInvalidEnumValue, name, e.allowed)
+	}
+	return v, nil
+}
+
+// resolveNode replaces the name in scalar node with its integer value
+// so the decoder can decode it.
+// node must not be shared, see coercible.
+func (e *enumMapping) resolveNode(node *yaml.Node) error {
+	v, err := e.value(node.Value)
+	if err != nil {
+		return err
+	}
+	node.Value, node.Tag, node.Style = strconv.FormatInt(v, 10), "!!int", 0
+	return nil
+}
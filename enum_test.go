@@ -0,0 +1,200 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Color int32
+
+const (
+	ColorRed Color = iota
+	ColorGreen
+	ColorBlue
+)
+
+var colorNames = map[string]Color{
+	"red":   ColorRed,
+	"green": ColorGreen,
+	"blue":  ColorBlue,
+}
+
+func TestWithEnumMapping(t *testing.T) {
+	type TestConfig struct {
+		Color    Color            `yaml:"color" env:"COLOR"`
+		Ptr      *Color           `yaml:"ptr"`
+		Fallback Color            `yaml:"fallback"`
+		Palette  []Color          `yaml:"palette"`
+		ByColor  map[Color]int8   `yaml:"by-color"`
+		Names    map[string]Color `yaml:"names"`
+	}
+	const src = `color: green
+ptr: 'blue'
+fallback: &b blue
+palette: [red, *b]
+by-color:
+  blue: 2
+  red: 0
+names:
+  x: red
+`
+	opt := yamagiconf.WithEnumMapping(colorNames)
+
+	var c TestConfig
+	err := yamagiconf.LoadWithOptions(src, &c, opt)
+	require.NoError(t, err)
+	require.Equal(t, TestConfig{
+		Color:    ColorGreen,
+		Ptr:      PtrTo(ColorBlue),
+		Palette:  []Color{ColorRed, ColorBlue},
+		ByColor:  map[Color]int8{ColorBlue: 2, ColorRed: 0},
+		Fallback: ColorBlue,
+		Names:    map[string]Color{"x": ColorRed},
+	}, c)
+
+	b, err := yamagiconf.Marshal(c, opt)
+	require.NoError(t, err)
+	require.Equal(t, `color: green
+ptr: blue
+fallback: blue
+palette:
+  - red
+  - blue
+by-color:
+  red: 0
+  blue: 2
+names:
+  x: red
+`, string(b))
+}
+
+func TestWithEnumMappingAlias(t *testing.T) {
+	type TestConfig struct {
+		Color   Color   `yaml:"color"`
+		Name    string  `yaml:"name"`
+		Palette []Color `yaml:"palette"`
+	}
+	opt := yamagiconf.WithEnumMapping(colorNames)
+
+	t.Run("anchored_enum", func(t *testing.T) {
+		// The anchored name must remain unchanged for aliases of other types.
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(
+			"color: &x green\nname: *x\npalette: [*x]\n", &c, opt)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Color: ColorGreen, Name: "green", Palette: []Color{ColorGreen},
+		}, c)
+	})
+
+	t.Run("anchored_string", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(
+			"name: &x blue\ncolor: *x\npalette: [red, *x]\n", &c, opt)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Color: ColorBlue, Name: "blue", Palette: []Color{ColorRed, ColorBlue},
+		}, c)
+	})
+}
+
+func TestWithEnumMappingEnv(t *testing.T) {
+	type TestConfig struct {
+		Color Color  `yaml:"color" env:"COLOR"`
+		Ptr   *Color `yaml:"ptr" env:"COLOR_PTR"`
+	}
+	opt := yamagiconf.WithEnumMapping(colorNames)
+
+	t.Setenv("COLOR", "blue")
+	t.Setenv("COLOR_PTR", "green")
+	var c TestConfig
+	err := yamagiconf.LoadWithOptions("color: red\nptr: null\n", &c, opt)
+	require.NoError(t, err)
+	require.Equal(t, TestConfig{Color: ColorBlue, Ptr: PtrTo(ColorGreen)}, c)
+
+	t.Setenv("COLOR", "2")
+	err = yamagiconf.LoadWithOptions("color: red\nptr: null\n", &c, opt)
+	require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+	require.ErrorIs(t, err, yamagiconf.ErrInvalidEnumValue)
+	require.Equal(t, `at 1:8: "color" (TestConfig.Color): invalid env var COLOR: `+
+		`expected yamagiconf_test.Color: invalid enum value: "2", `+
+		`allowed: red, green, blue`, err.Error())
+}
+
+func TestWithEnumMappingErrInvalidEnumValue(t *testing.T) {
+	type TestConfig struct {
+		Color   Color          `yaml:"color"`
+		ByColor map[Color]int8 `yaml:"by-color"`
+	}
+	opt := yamagiconf.WithEnumMapping(colorNames)
+
+	for _, td := range []struct {
+		name, src, expect string
+	}{
+		{
+			name: "unknown_name",
+			src:  "color: purple\nby-color: {}\n",
+			expect: `at 1:8: "color" (TestConfig.Color): invalid enum value: ` +
+				`"purple", allowed: red, green, blue`,
+		},
+		{
+			name: "number",
+			src:  "color: 1\nby-color: {}\n",
+			expect: `at 1:8: "color" (TestConfig.Color): invalid enum value: ` +
+				`"1", allowed: red, green, blue`,
+		},
+		{
+			name: "case_sensitive",
+			src:  "color: Red\nby-color: {}\n",
+			expect: `at 1:8: "color" (TestConfig.Color): invalid enum value: ` +
+				`"Red", allowed: red, green, blue`,
+		},
+		{
+			name: "map_key",
+			src:  "color: red\nby-color:\n  pink: 1\n",
+			expect: `at 3:3: "by-color" (TestConfig.ByColor["pink"]): ` +
+				`invalid enum value: "pink", allowed: red, green, blue`,
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			var c TestConfig
+			err := yamagiconf.LoadWithOptions(td.src, &c, opt)
+			require.ErrorIs(t, err, yamagiconf.ErrInvalidEnumValue)
+			require.Equal(t, td.expect, err.Error())
+		})
+	}
+}
+
+func TestWithEnumMappingMarshalErrInvalidEnumValue(t *testing.T) {
+	type TestConfig struct {
+		Color Color `yaml:"color"`
+	}
+	_, err := yamagiconf.Marshal(TestConfig{Color: 42},
+		yamagiconf.WithEnumMapping(colorNames))
+	require.ErrorIs(t, err, yamagiconf.ErrInvalidEnumValue)
+	require.Equal(t, `at TestConfig.Color: invalid enum value: 42 has no name`,
+		err.Error())
+
+	// Without the option the number is written.
+	b, err := yamagiconf.Marshal(TestConfig{Color: 42})
+	require.NoError(t, err)
+	require.Equal(t, "color: 42\n", string(b))
+}
+
+func TestWithEnumMappingAmbiguous(t *testing.T) {
+	type TestConfig struct {
+		Color Color `yaml:"color"`
+	}
+	opt := yamagiconf.WithEnumMapping(map[string]Color{
+		"red": ColorRed, "crimson": ColorRed,
+	})
+	var c TestConfig
+	require.NoError(t, yamagiconf.LoadWithOptions("color: red\n", &c, opt))
+	require.Equal(t, ColorRed, c.Color)
+	b, err := yamagiconf.Marshal(c, opt)
+	require.NoError(t, err)
+	require.Equal(t, "color: crimson\n", string(b))
+}
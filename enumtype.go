@@ -0,0 +1,117 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Enum can be implemented by named string and integer types that may only
+// take a fixed set of values, such as `type LogLevel string`, which keeps
+// the allowed values next to the type instead of repeating them in
+// `validate:"oneof=..."` struct tags. Integer types return the decimal
+// representation of their values. Values of the document and env vars
+// that aren't returned by Enum are rejected with ErrEnumViolation.
+// JSONSchema and ExampleYAML use the values as well.
+// Enum is invoked on the zero value and must always return the same values.
+type Enum interface{ Enum() []string }
+
+// enumValues returns the values returned by Enum if tp implements it.
+// Pointers are not considered, their elements are.
+func enumValues(tp reflect.Type) (values []string, ok bool) {
+	if tp.Kind() == reflect.Pointer || !implementsInterface[Enum](tp) {
+		return nil, false
+	}
+	return reflect.New(tp).Interface().(Enum).Enum(), true
+}
+
+// validateEnumType returns ErrTypeInvalidEnum if tp implements Enum
+// but isn't a string or integer type or returns no or unparsable values.
+func validateEnumType(tp reflect.Type) error {
+	values, ok := enumValues(tp)
+	if !ok {
+		return nil
+	}
+	switch tp.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return fmt.Errorf("%w: %s is not a string or integer type",
+			ErrTypeInvalidEnum, tp.String())
+	}
+	if len(values) < 1 {
+		return fmt.Errorf("%w: %s has no values", ErrTypeInvalidEnum, tp.String())
+	}
+	for _, s := range values {
+		if err := setFromString(reflect.New(tp).Elem(), s); err != nil {
+			return fmt.Errorf("%w: value %q of %s: %w",
+				ErrTypeInvalidEnum, s, tp.String(), err)
+		}
+	}
+	return nil
+}
+
+// checkEnumValue returns an error wrapping ErrEnumViolation if the type
+// of v implements Enum and v isn't one of its values.
+func checkEnumValue(v reflect.Value) error {
+	values, ok := enumValues(v.Type())
+	if !ok {
+		return nil
+	}
+	for _, s := range values {
+		// The values were checked by ValidateType already.
+		if e := reflect.New(v.Type()).Elem(); setFromString(e, s) == nil && e.Equal(v) {
+			return nil
+		}
+	}
+	var s string
+	switch {
+	case v.CanInt():
+		s = strconv.FormatInt(v.Int(), 10)
+	case v.CanUint():
+		s = strconv.FormatUint(v.Uint(), 10)
+	default:
+		s = v.String()
+	}
+	return fmt.Errorf("%w: %q, allowed: %s",
+		ErrEnumViolation, s, strings.Join(values, ", "))
+}
+
+// checkEnumNode returns an error wrapping ErrEnumViolation if tp
+// implements Enum and the value of scalar node isn't one of its values.
+func checkEnumNode(tp reflect.Type, node *yaml.Node) error {
+	if _, ok := enumValues(tp); !ok {
+		return nil
+	}
+	n := node
+	if n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind != yaml.ScalarNode || n.Tag == "!!null" {
+		return nil
+	}
+	v := reflect.New(tp)
+	if tp.Kind() == reflect.String {
+		v.Elem().SetString(n.Value)
+	} else if err := n.Decode(v.Interface()); err != nil {
+		return nil // Reported by the decoder.
+	}
+	return checkEnumValue(v.Elem())
+}
+
+// enumExample returns the first value returned by Enum of tp.
+// Returns false if tp doesn't implement Enum.
+func enumExample(tp reflect.Type) (reflect.Value, bool) {
+	values, ok := enumValues(tp)
+	if !ok || len(values) < 1 {
+		return reflect.Value{}, false
+	}
+	v := reflect.New(tp).Elem()
+	// The value was checked by ValidateType already.
+	_ = setFromString(v, values[0])
+	return v, true
+}
@@ -0,0 +1,196 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+type LogLevel string
+
+func (LogLevel) Enum() []string { return []string{"info", "debug", "error"} }
+
+type Priority uint8
+
+func (*Priority) Enum() []string { return []string{"1", "2", "3"} }
+
+type TestConfigEnum struct {
+	Level     LogLevel          `yaml:"level" env:"LEVEL"`
+	Priority  *Priority         `yaml:"priority"`
+	Levels    []LogLevel        `yaml:"levels"`
+	ByLevel   map[LogLevel]bool `yaml:"by-level"`
+	Unchecked string            `yaml:"unchecked"`
+}
+
+func TestEnum(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		c, err := LoadSrc[TestConfigEnum](`level: debug
+priority: 2
+levels: [info, error]
+by-level:
+  debug: true
+unchecked: x
+`)
+		require.NoError(t, err)
+		require.Equal(t, &TestConfigEnum{
+			Level:     "debug",
+			Priority:  PtrTo(Priority(2)),
+			Levels:    []LogLevel{"info", "error"},
+			ByLevel:   map[LogLevel]bool{"debug": true},
+			Unchecked: "x",
+		}, c)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("LEVEL", "error")
+		c, err := LoadSrc[TestConfigEnum](`level: debug
+priority: null
+levels: []
+by-level: {}
+unchecked: x
+`)
+		require.NoError(t, err)
+		require.Equal(t, LogLevel("error"), c.Level)
+	})
+
+	t.Run("err", func(t *testing.T) {
+		_, err := LoadSrc[TestConfigEnum](`level: trace
+priority: null
+levels: []
+by-level: {}
+unchecked: x
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrEnumViolation)
+		require.Equal(t, `at 1:8: "level" (TestConfigEnum.Level): `+
+			`value not in enum: "trace", allowed: info, debug, error`, err.Error())
+	})
+
+	t.Run("err_int", func(t *testing.T) {
+		_, err := LoadSrc[TestConfigEnum](`level: info
+priority: 4
+levels: []
+by-level: {}
+unchecked: x
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrEnumViolation)
+		require.Equal(t, `at 2:11: "priority" (TestConfigEnum.Priority): `+
+			`value not in enum: "4", allowed: 1, 2, 3`, err.Error())
+	})
+
+	t.Run("err_slice_item", func(t *testing.T) {
+		_, err := LoadSrc[TestConfigEnum](`level: info
+priority: null
+levels: [info, Info]
+by-level: {}
+unchecked: x
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrEnumViolation)
+		require.Equal(t, `at 3:16: "levels" (TestConfigEnum.Levels[1]): `+
+			`value not in enum: "Info", allowed: info, debug, error`, err.Error())
+	})
+
+	t.Run("err_map_key", func(t *testing.T) {
+		_, err := LoadSrc[TestConfigEnum](`level: info
+priority: null
+levels: []
+by-level:
+  warn: true
+unchecked: x
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrEnumViolation)
+		require.Equal(t, `at 5:3: "by-level" (TestConfigEnum.ByLevel["warn"]): `+
+			`value not in enum: "warn", allowed: info, debug, error`, err.Error())
+	})
+
+	t.Run("err_env", func(t *testing.T) {
+		t.Setenv("LEVEL", "trace")
+		_, err := LoadSrc[TestConfigEnum](`level: info
+priority: null
+levels: []
+by-level: {}
+unchecked: x
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.ErrorIs(t, err, yamagiconf.ErrEnumViolation)
+		require.Equal(t, `at 1:8: "level" (TestConfigEnum.Level): `+
+			`invalid env var LEVEL: expected yamagiconf_test.LogLevel: `+
+			`value not in enum: "trace", allowed: info, debug, error`, err.Error())
+	})
+}
+
+func TestEnumGenerators(t *testing.T) {
+	b, err := yamagiconf.JSONSchema[TestConfigEnum]()
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "TestConfigEnum",
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"level": {"type": "string", "enum": ["info", "debug", "error"]},
+			"priority": {"anyOf": [
+				{"type": "integer", "enum": [1, 2, 3]},
+				{"type": "null"}
+			]},
+			"levels": {
+				"type": "array",
+				"items": {"type": "string", "enum": ["info", "debug", "error"]}
+			},
+			"by-level": {"type": "object", "additionalProperties": {"type": "boolean"}},
+			"unchecked": {"type": "string"}
+		}
+	}`, string(b))
+
+	type TestConfig struct {
+		Level    LogLevel `yaml:"level"`
+		Priority Priority `yaml:"priority"`
+	}
+	b, err = yamagiconf.ExampleYAML[TestConfig]()
+	require.NoError(t, err)
+	require.Equal(t, "level: info\npriority: 1\n", string(b))
+	var c TestConfig
+	require.NoError(t, yamagiconf.Load(b, &c))
+}
+
+type EnumFloat float64
+
+func (EnumFloat) Enum() []string { return []string{"1.5"} }
+
+type EnumEmpty string
+
+func (EnumEmpty) Enum() []string { return nil }
+
+type EnumInvalidInt int8
+
+func (EnumInvalidInt) Enum() []string { return []string{"1", "x"} }
+
+func TestValidateTypeErrInvalidEnum(t *testing.T) {
+	t.Run("float", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			F *EnumFloat `yaml:"f"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnum)
+		require.Equal(t, `at struct{...}.F: invalid Enum implementation: `+
+			`yamagiconf_test.EnumFloat is not a string or integer type`, err.Error())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			E []EnumEmpty `yaml:"e"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnum)
+		require.Equal(t, `at struct{...}.E: invalid Enum implementation: `+
+			`yamagiconf_test.EnumEmpty has no values`, err.Error())
+	})
+
+	t.Run("invalid_int", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			I EnumInvalidInt `yaml:"i"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnum)
+		require.Equal(t, `at struct{...}.I: invalid Enum implementation: `+
+			`value "x" of yamagiconf_test.EnumInvalidInt: `+
+			`strconv.ParseInt: parsing "x": invalid syntax`, err.Error())
+	})
+}
@@ -0,0 +1,106 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvVarInfo describes an env var consumed by a config type.
+type EnvVarInfo struct {
+	// Name is the name of the env var.
+	Name string
+
+	// GoPath is the path of the field in the config type,
+	// such as `Config.Server.Host`.
+	GoPath string
+
+	// YAMLPath is the path of the field in the YAML document,
+	// such as `server.host`. Empty for fields ignored by YAML (`yaml:"-"`).
+	YAMLPath string
+
+	// Required is true if the field has the validate rule "required".
+	Required bool
+}
+
+// EnvVars returns all env vars specified by `env` struct tags
+// in config type T in the order of the fields.
+// A field with multiple env vars in its `env` struct tag produces
+// an entry for each of them. Fields inside slices and arrays are denoted by
+// `[]` and inside maps by `[*]` in Go paths and by `.*` in YAML paths.
+// Returns the same errors as ValidateType if T is invalid.
+func EnvVars[T any]() ([]EnvVarInfo, error) {
+	tp := reflect.TypeFor[T]()
+	if err := newOptions(nil).validateType(tp); err != nil {
+		return nil, err
+	}
+	var vars []EnvVarInfo
+	appendEnvVars(&vars, getConfigTypeName(tp), "", tp)
+	return vars, nil
+}
+
+// appendEnvVars appends the env vars of the fields of struct type tp
+// and all struct types contained by them to vars.
+func appendEnvVars(vars *[]EnvVarInfo, goPath, yamlPath string, tp reflect.Type) {
+	for i := range tp.NumField() {
+		f := tp.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		goPath := goPath + "." + f.Name
+		yamlTag := getYAMLFieldName(f.Tag)
+		fieldYAMLPath := ""
+		if yamlTag != "-" {
+			fieldYAMLPath = yamlTag
+			if yamlPath != "" {
+				fieldYAMLPath = yamlPath + "." + yamlTag
+			}
+		}
+		names, _, _ := parseEnvTag(f.Tag.Get("env"))
+		required := slices.Contains(strings.Split(f.Tag.Get("validate"), ","), "required")
+		for _, name := range names {
+			*vars = append(*vars, EnvVarInfo{
+				Name:     name,
+				GoPath:   goPath,
+				YAMLPath: fieldYAMLPath,
+				Required: required,
+			})
+		}
+		if yamlTag == "-" {
+			continue
+		}
+		if f.Anonymous {
+			fieldYAMLPath = yamlPath
+		}
+		appendContainedEnvVars(vars, goPath, fieldYAMLPath, f.Type)
+	}
+}
+
+// appendContainedEnvVars appends the env vars of the struct type contained
+// by tp, if any, unwrapping pointers, slices, arrays and maps.
+func appendContainedEnvVars(
+	vars *[]EnvVarInfo, goPath, yamlPath string, tp reflect.Type,
+) {
+	for {
+		if implementsInterface[encoding.TextUnmarshaler](tp) ||
+			implementsInterface[yaml.Unmarshaler](tp) {
+			return
+		}
+		switch tp.Kind() {
+		case reflect.Pointer:
+			tp = tp.Elem()
+		case reflect.Slice, reflect.Array:
+			goPath, yamlPath, tp = goPath+"[]", yamlPath+"[]", tp.Elem()
+		case reflect.Map:
+			goPath, yamlPath, tp = goPath+"[*]", yamlPath+".*", tp.Elem()
+		case reflect.Struct:
+			appendEnvVars(vars, goPath, yamlPath, tp)
+			return
+		default:
+			return
+		}
+	}
+}
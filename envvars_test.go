@@ -0,0 +1,89 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvVars(t *testing.T) {
+	vars, err := yamagiconf.EnvVars[DocsConfig]()
+	require.NoError(t, err)
+	require.Equal(t, []yamagiconf.EnvVarInfo{
+		{
+			Name: "HOST", GoPath: "DocsConfig.Server.Host",
+			YAMLPath: "server.host", Required: true,
+		},
+		{
+			Name: "SERVER_HOST", GoPath: "DocsConfig.Server.Host",
+			YAMLPath: "server.host", Required: true,
+		},
+		{
+			Name: "HOST", GoPath: "DocsConfig.Upstreams[*].Host",
+			YAMLPath: "upstreams.*.host", Required: true,
+		},
+		{
+			Name: "SERVER_HOST", GoPath: "DocsConfig.Upstreams[*].Host",
+			YAMLPath: "upstreams.*.host", Required: true,
+		},
+	}, vars)
+}
+
+func TestEnvVarsPaths(t *testing.T) {
+	type Embedded struct {
+		Region string `yaml:"region" env:"REGION"`
+	}
+	type Item struct {
+		Token *string `yaml:"token" env:"ITEM_TOKEN"`
+	}
+	type TestConfig struct {
+		Embedded `yaml:",inline"`
+		Debug    bool              `yaml:"-" env:"DEBUG" envDefault:"false"`
+		Port     uint16            `yaml:"port" env:"PORT,required" validate:"required"`
+		Labels   map[string]string `yaml:"labels" env:"LABEL"`
+		Items    []*Item           `yaml:"items"`
+		Nested   *struct {
+			Items [2]Item `yaml:"items"`
+		} `yaml:"nested"`
+	}
+
+	vars, err := yamagiconf.EnvVars[TestConfig]()
+	require.NoError(t, err)
+	require.Equal(t, []yamagiconf.EnvVarInfo{
+		{Name: "REGION", GoPath: "TestConfig.Embedded.Region", YAMLPath: "region"},
+		{Name: "DEBUG", GoPath: "TestConfig.Debug", YAMLPath: ""},
+		{
+			Name: "PORT", GoPath: "TestConfig.Port",
+			YAMLPath: "port", Required: true,
+		},
+		{Name: "LABEL", GoPath: "TestConfig.Labels", YAMLPath: "labels"},
+		{
+			Name: "ITEM_TOKEN", GoPath: "TestConfig.Items[].Token",
+			YAMLPath: "items[].token",
+		},
+		{
+			Name: "ITEM_TOKEN", GoPath: "TestConfig.Nested.Items[].Token",
+			YAMLPath: "nested.items[].token",
+		},
+	}, vars)
+}
+
+func TestEnvVarsNone(t *testing.T) {
+	type TestConfig struct {
+		Name string `yaml:"name"`
+	}
+	vars, err := yamagiconf.EnvVars[TestConfig]()
+	require.NoError(t, err)
+	require.Empty(t, vars)
+}
+
+func TestEnvVarsErrInvalidType(t *testing.T) {
+	_, err := yamagiconf.EnvVars[string]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeIllegalRoot)
+
+	_, err = yamagiconf.EnvVars[struct {
+		Port uint16 `yaml:"port" env:"port"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnvTag)
+}
@@ -0,0 +1,225 @@
+package yamagiconf
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Error is an error that occurred at a specific location.
+type Error struct {
+	// GoPath is the path of the Go value, such as "Config.Server.Port".
+	// GoPath may be empty for errors located in the YAML source.
+	GoPath string
+
+	// YAMLPath is the path of the outermost YAML node at Line and Column,
+	// such as "server.port" or "servers[0].host".
+	// YAMLPath is empty if the error isn't located in the source
+	// or is located at the root of the document.
+	YAMLPath string
+
+	// Line and Column are the 1-based location in the YAML source
+	// or 0 if the error isn't located in the source.
+	Line, Column int
+
+	// Offset is the 0-based byte offset of Line and Column
+	// in the YAML source, which is useful for tools working
+	// with byte ranges. Only valid if Line > 0.
+	// Offset is -1 if the source isn't available, which is the case
+	// for sequence items streamed by LoadSequence.
+	Offset int
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("at %d:%d: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("at %s: %v", e.GoPath, e.Err)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// MultiError is a list of errors.
+type MultiError struct{ Errors []error }
+
+// Error returns the messages of all errors separated by line breaks.
+func (e MultiError) Error() string {
+	var b strings.Builder
+	for i, err := range e.Errors {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap allows errors.Is and errors.As to match any of the errors.
+func (e MultiError) Unwrap() []error { return e.Errors }
+
+// JUnitXML renders the errors as a JUnit XML report with a failed test case
+// per error for CI systems to display config problems alongside test results.
+// The test case is named after the GoPath of the Error if the error is
+// or wraps an Error, otherwise it's named "config". The failure message
+// is the entire error message including the location.
+func (e MultiError) JUnitXML() []byte {
+	type failure struct {
+		Message string `xml:"message,attr"`
+		Text    string `xml:",chardata"`
+	}
+	type testCase struct {
+		Name      string  `xml:"name,attr"`
+		ClassName string  `xml:"classname,attr"`
+		Failure   failure `xml:"failure"`
+	}
+	type testSuite struct {
+		XMLName   xml.Name   `xml:"testsuite"`
+		Name      string     `xml:"name,attr"`
+		Tests     int        `xml:"tests,attr"`
+		Failures  int        `xml:"failures,attr"`
+		TestCases []testCase `xml:"testcase"`
+	}
+	s := testSuite{
+		Name:      "yamagiconf",
+		Tests:     len(e.Errors),
+		Failures:  len(e.Errors),
+		TestCases: make([]testCase, len(e.Errors)),
+	}
+	for i, err := range e.Errors {
+		c := testCase{Name: "config", ClassName: "yamagiconf"}
+		c.Failure.Message, c.Failure.Text = err.Error(), err.Error()
+		var located *Error
+		if errors.As(err, &located) && located.GoPath != "" {
+			c.Name = located.GoPath
+		}
+		s.TestCases[i] = c
+	}
+	// Marshaling can't fail since all fields are strings and ints.
+	b, _ := xml.MarshalIndent(s, "", "  ")
+	return append([]byte(xml.Header), append(b, '\n')...)
+}
+
+// sourceIndex is the source of a load and its parsed root node
+// used to locate errors in the source, see errorAt.
+type sourceIndex struct {
+	src        string
+	lineStarts []int // Byte offsets of the lines in src.
+	root       *yaml.Node
+	path       string // YAML path of root in the document.
+
+	// overlay is true if root was merged over another document, see
+	// WithJSONEnvOverride. Locations outside of root aren't in src then.
+	overlay bool
+}
+
+// newSourceIndex indexes the lines of src once per load
+// so that the byte offsets of errors are found without scanning src.
+func newSourceIndex[S string | []byte](src S, root *yaml.Node) *sourceIndex {
+	s := &sourceIndex{src: string(src), lineStarts: []int{0}, root: root}
+	for i := 0; i < len(s.src); i++ {
+		if s.src[i] == '\n' {
+			s.lineStarts = append(s.lineStarts, i+1)
+		}
+	}
+	return s
+}
+
+// offset returns the byte offset of the 1-based line and column.
+// Columns are counted in characters like the YAML parser does.
+func (s *sourceIndex) offset(line, column int) (offset int, ok bool) {
+	if s.lineStarts == nil || line < 1 || line > len(s.lineStarts) {
+		return 0, false
+	}
+	offset = s.lineStarts[line-1]
+	for c := 1; c < column; c++ {
+		if offset >= len(s.src) || s.src[offset] == '\n' {
+			return 0, false
+		}
+		_, size := utf8.DecodeRuneInString(s.src[offset:])
+		offset += size
+	}
+	return offset, true
+}
+
+// errorAt returns err located at the 1-based line and column in s
+// for the value at goPath. s may be nil if the source isn't available.
+// The message of err must not contain the location.
+func (s *sourceIndex) errorAt(line, column int, goPath string, err error) *Error {
+	e := &Error{GoPath: goPath, Line: line, Column: column, Offset: -1, Err: err}
+	if s == nil {
+		return e
+	}
+	if s.root != nil {
+		var ok bool
+		if e.YAMLPath, ok = yamlPathAt(s.root, s.path, line, column); !ok && s.overlay {
+			return e
+		}
+	}
+	if offset, ok := s.offset(line, column); ok {
+		e.Offset = offset
+	}
+	return e
+}
+
+// errorAt returns err located at the 1-based line and column
+// in the source being loaded for the value at goPath.
+func (o *options) errorAt(line, column int, goPath string, err error) *Error {
+	return o.source.errorAt(line, column, goPath, err)
+}
+
+// valueError returns err located at node for the value at path,
+// mentioning yamlTag unless it's empty.
+func (o *options) valueError(node *yaml.Node, yamlTag, path string, err error) error {
+	if yamlTag != "" {
+		return o.errorAt(node.Line, node.Column, path,
+			fmt.Errorf("%q (%s): %w", yamlTag, path, err))
+	}
+	return o.errorAt(node.Line, node.Column, path, fmt.Errorf("%s: %w", path, err))
+}
+
+// yamlPathAt returns the path of the outermost node in n
+// at the 1-based line and column. Aliases aren't followed.
+func yamlPathAt(n *yaml.Node, path string, line, column int) (string, bool) {
+	if n == nil {
+		return "", false // Released by LoadSequence.
+	}
+	if n.Kind != yaml.DocumentNode && path != "" &&
+		n.Line == line && n.Column == column {
+		return path, true
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			if p, ok := yamlPathAt(c, path, line, column); ok {
+				return p, true
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			p := n.Content[i].Value
+			if path != "" {
+				p = path + "." + p
+			}
+			for _, c := range n.Content[i : i+2] {
+				if p, ok := yamlPathAt(c, p, line, column); ok {
+					return p, true
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if p, ok := yamlPathAt(c, p, line, column); ok {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,214 @@
+package yamagiconf_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTypeAll(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		type TestConfig struct {
+			Foo string `yaml:"foo"`
+		}
+		require.NoError(t, yamagiconf.ValidateTypeAll[TestConfig]())
+	})
+
+	t.Run("illegal_root", func(t *testing.T) {
+		err := yamagiconf.ValidateTypeAll[string]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeIllegalRoot)
+	})
+
+	type Recursive struct {
+		Name string     `yaml:"name"`
+		Next *Recursive `yaml:"next"`
+	}
+	type Nested struct {
+		MissingTag string
+		Int        int             `yaml:"int"`
+		Env        string          `yaml:"env" env:"lower"`
+		Map        map[uint]string `yaml:"map"`
+	}
+	type TestConfig struct {
+		Nested    Nested    `yaml:"nested"`
+		Recursive Recursive `yaml:"recursive"`
+		Dup       string    `yaml:"dup"`
+		Dup2      string    `yaml:"dup"`
+		Chan      chan int  `yaml:"chan"`
+		Fine      string    `yaml:"fine"`
+	}
+
+	err := yamagiconf.ValidateTypeAll[TestConfig]()
+	require.Error(t, err)
+
+	var multi *yamagiconf.MultiError
+	require.True(t, errors.As(err, &multi))
+	require.Len(t, multi.Errors, 7)
+
+	paths := make([]string, len(multi.Errors))
+	for i, err := range multi.Errors {
+		var e *yamagiconf.Error
+		require.True(t, errors.As(err, &e))
+		paths[i] = e.GoPath
+	}
+	require.Equal(t, []string{
+		"TestConfig.Nested.MissingTag",
+		"TestConfig.Nested.Int",
+		"TestConfig.Nested.Env",
+		"TestConfig.Nested.Map[key]",
+		"TestConfig.Recursive.Next",
+		"TestConfig.Dup2",
+		"TestConfig.Chan",
+	}, paths)
+
+	require.ErrorIs(t, err, yamagiconf.ErrTypeMissingYAMLTag)
+	require.ErrorIs(t, err, yamagiconf.ErrTypeUnsupported)
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnvTag)
+	require.ErrorIs(t, err, yamagiconf.ErrTypeRecursive)
+	require.ErrorIs(t, err, yamagiconf.ErrYAMLTagRedefined)
+
+	require.Equal(t, `at TestConfig.Nested.MissingTag: missing yaml struct tag
+at TestConfig.Nested.Int: unsupported type: int, use integer type with specified width, such as int8, int16, int32 or int64 instead of int
+at TestConfig.Nested.Env: `+yamagiconf.ErrTypeInvalidEnvTag.Error()+`
+at TestConfig.Nested.Map[key]: unsupported type: uint, use unsigned integer type with specified width, such as uint8, uint16, uint32 or uint64 instead of uint
+at TestConfig.Recursive.Next: recursive type
+at TestConfig.Dup2: yaml tag "dup" previously defined on field TestConfig.Dup: a yaml struct tag must be unique
+at TestConfig.Chan: unsupported type: chan int`, err.Error())
+
+	// ValidateType reports only the first violation.
+	err = yamagiconf.ValidateType[TestConfig]()
+	var e *yamagiconf.Error
+	require.True(t, errors.As(err, &e))
+	require.Equal(t, "TestConfig.Nested.MissingTag", e.GoPath)
+	require.Equal(t, multi.Errors[0].Error(), err.Error())
+}
+
+func TestMultiErrorJUnitXML(t *testing.T) {
+	type TestConfig struct {
+		Foo string `yaml:"foo"`
+		Int int    `yaml:"int"`
+		Bad string `yaml:"bad" env:"<lower>"`
+	}
+	err := yamagiconf.ValidateTypeAll[TestConfig]()
+	var multi *yamagiconf.MultiError
+	require.True(t, errors.As(err, &multi))
+	multi.Errors = append(multi.Errors, &yamagiconf.Error{
+		GoPath: "TestConfig.Foo", Line: 2, Column: 6, Offset: 10,
+		Err: errors.New("invalid value"),
+	}, errors.New("unlocated & plain"))
+
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="yamagiconf" tests="4" failures="4">
+  <testcase name="TestConfig.Int" classname="yamagiconf">
+    <failure message="at TestConfig.Int: unsupported type: int, use integer type with specified width, such as int8, int16, int32 or int64 instead of int">at TestConfig.Int: unsupported type: int, use integer type with specified width, such as int8, int16, int32 or int64 instead of int</failure>
+  </testcase>
+  <testcase name="TestConfig.Bad" classname="yamagiconf">
+    <failure message="at TestConfig.Bad: invalid env struct tag: must match the POSIX env var regexp: ^[A-Z_][A-Z0-9_]*$">at TestConfig.Bad: invalid env struct tag: must match the POSIX env var regexp: ^[A-Z_][A-Z0-9_]*$</failure>
+  </testcase>
+  <testcase name="TestConfig.Foo" classname="yamagiconf">
+    <failure message="at 2:6: invalid value">at 2:6: invalid value</failure>
+  </testcase>
+  <testcase name="config" classname="yamagiconf">
+    <failure message="unlocated &amp; plain">unlocated &amp; plain</failure>
+  </testcase>
+</testsuite>
+`, string(multi.JUnitXML()))
+}
+
+func TestErrorOffset(t *testing.T) {
+	type Server struct {
+		Host ValidatedString `yaml:"host"`
+		Port uint16          `yaml:"port" validate:"gt=0"`
+	}
+	type TestConfig struct {
+		Name   string `yaml:"name"`
+		Server Server `yaml:"server"`
+	}
+
+	t.Run("validator_tag", func(t *testing.T) {
+		src := "name: \"名前\"\nserver:\n  host: valid\n  port: 0\n"
+		_, err := LoadSrc[TestConfig](src)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 4:9: "port" violates validation rule: "gt"`, err.Error())
+
+		var e *yamagiconf.Error
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, 4, e.Line)
+		require.Equal(t, 9, e.Column)
+		require.Equal(t, len("name: \"名前\"\nserver:\n  host: valid\n  port: "), e.Offset)
+		require.Equal(t, "0", src[e.Offset:e.Offset+1])
+		require.Equal(t, "TestConfig.Server.Port", e.GoPath)
+		require.Equal(t, "server.port", e.YAMLPath)
+	})
+
+	t.Run("multibyte_column", func(t *testing.T) {
+		src := "name: x\nserver: {host: \"ä\", hst: valid, port: 1}\n"
+		_, err := LoadSrc[TestConfig](src)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 2:21: malformed YAML: field "hst" `+
+			`not found in type yamagiconf_test.Server`, err.Error())
+
+		var e *yamagiconf.Error
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, 21, e.Column)
+		require.Equal(t, "hst", src[e.Offset:e.Offset+len("hst")])
+		require.Equal(t, "server.hst", e.YAMLPath)
+	})
+
+	t.Run("validate_method", func(t *testing.T) {
+		src := "name: x\nserver:\n  host: invalid\n  port: 1\n"
+		_, err := LoadSrc[TestConfig](src)
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.Equal(t, `at 3:9: at TestConfig.Server.Host: `+
+			`validation: is not 'valid'`, err.Error())
+
+		var e *yamagiconf.Error
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, "TestConfig.Server.Host", e.GoPath)
+		require.Equal(t, "server.host", e.YAMLPath)
+		require.Equal(t, "invalid", src[e.Offset:e.Offset+len("invalid")])
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		type TestConfig struct {
+			Servers []Server `yaml:"servers" validate:"dive"`
+		}
+		src := "servers:\n  - host: valid\n    port: 1\n" +
+			"  - host: valid\n    port: 0\n"
+		_, err := LoadSrc[TestConfig](src)
+		require.Equal(t, `at 5:11: "port" violates validation rule: "gt"`, err.Error())
+
+		var e *yamagiconf.Error
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, 5, e.Line)
+		require.Equal(t, 11, e.Column)
+		require.Equal(t, "TestConfig.Servers[1].Port", e.GoPath)
+		require.Equal(t, "servers[1].port", e.YAMLPath)
+	})
+
+	t.Run("load_sequence", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader(
+			"- host: valid\n  port: 1\n- host: valid\n  port: 0\n",
+		), func(int, Server) error { return nil })
+		require.Equal(t, `at 4:9: "port" violates validation rule: "gt"`, err.Error())
+
+		var e *yamagiconf.Error
+		require.True(t, errors.As(err, &e))
+		require.Equal(t, 4, e.Line)
+		require.Equal(t, 9, e.Column)
+		require.Equal(t, -1, e.Offset, "the source isn't retained")
+		require.Equal(t, "Server.Port", e.GoPath)
+		require.Equal(t, "[1].port", e.YAMLPath)
+	})
+
+	t.Run("unlocated", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("name: x\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		var e *yamagiconf.Error
+		require.False(t, errors.As(err, &e))
+	})
+}
@@ -0,0 +1,201 @@
+package yamagiconf
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExampleYAML returns an example document of config type T listing every
+// field, which is useful for committing a `config.example.yaml` and keeping
+// it in sync with the type in a test.
+// Fields are commented with the description provided by the `doc` struct tag
+// and their validate struct tag rules. Fields have the values of their
+// `default` struct tags or zero values. Pointers to structs are written
+// as structs and other pointers as `null`. Slices and maps of structs
+// contain a single example item, with maps of structs keyed by "example"
+// or the zero value of their key type, other slices and maps are empty.
+// Inlined embedded structs are flattened and ignored fields are omitted.
+// Integer types registered with WithEnumMapping are written as
+// the name of their smallest value and types implementing Enum
+// as their first value.
+// Keep in mind that the example may not pass validation.
+// Returns the same errors as ValidateType if T is invalid.
+func ExampleYAML[T any](opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	tp := reflect.TypeFor[T]()
+	if err := o.validateType(tp); err != nil {
+		return nil, err
+	}
+	n, err := exampleNode(o, getConfigTypeName(tp), tp)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(n); err != nil {
+		return nil, fmt.Errorf("encoding yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding yaml: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// exampleNode returns the YAML node of the example value of type tp.
+func exampleNode(o *options, path string, tp reflect.Type) (*yaml.Node, error) {
+	if e := o.enums[tp]; e != nil && len(e.names) > 0 {
+		smallest := slices.Min(slices.Collect(maps.Keys(e.names)))
+		return newStringNode(e.names[smallest]), nil
+	}
+	if v, ok := enumExample(tp); ok {
+		return marshalNode(o, path, v)
+	}
+	if implementsInterface[encoding.TextUnmarshaler](tp) ||
+		implementsInterface[yaml.Unmarshaler](tp) {
+		n, err := marshalNode(o, path, reflect.Zero(tp))
+		if err != nil {
+			// The zero value isn't necessarily marshalable, such as a nil URL.
+			return newStringNode(""), nil
+		}
+		return n, nil
+	}
+	switch tp.Kind() {
+	case reflect.Pointer:
+		if isPlainStruct(tp.Elem()) {
+			return exampleNode(o, path, tp.Elem())
+		}
+		return newNullNode(), nil
+	case reflect.Interface:
+		return newNullNode(), nil
+	case reflect.Struct:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if err := exampleStructFields(o, path, tp, n); err != nil {
+			return nil, err
+		}
+		if len(n.Content) < 1 {
+			n.Style = yaml.FlowStyle
+		}
+		return n, nil
+	case reflect.Array:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if tp.Len() < 1 {
+			n.Style = yaml.FlowStyle
+		}
+		for i := range tp.Len() {
+			item, err := exampleNode(o, fmt.Sprintf("%s[%d]", path, i), tp.Elem())
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, item)
+		}
+		return n, nil
+	case reflect.Slice:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if !containsStruct(tp.Elem()) {
+			n.Style = yaml.FlowStyle
+			return n, nil
+		}
+		item, err := exampleNode(o, path+"[0]", tp.Elem())
+		if err != nil {
+			return nil, err
+		}
+		n.Content = append(n.Content, item)
+		return n, nil
+	case reflect.Map:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if !containsStruct(tp.Elem()) {
+			n.Style = yaml.FlowStyle
+			return n, nil
+		}
+		key := newStringNode("example")
+		if tp.Key().Kind() != reflect.String || o.enums[tp.Key()] != nil {
+			var err error
+			if key, err = exampleNode(o, path, tp.Key()); err != nil {
+				return nil, err
+			}
+		}
+		value, err := exampleNode(o, fmt.Sprintf("%s[%s]", path, key.Value), tp.Elem())
+		if err != nil {
+			return nil, err
+		}
+		n.Content = append(n.Content, key, value)
+		return n, nil
+	}
+	return marshalNode(o, path, reflect.Zero(tp))
+}
+
+// exampleStructFields appends the commented key and example value nodes
+// of all fields of struct type tp to mapping node n,
+// inlined embedded structs are flattened.
+func exampleStructFields(
+	o *options, path string, tp reflect.Type, n *yaml.Node,
+) error {
+	for i := range tp.NumField() {
+		f := tp.Field(i)
+		yamlTag := getYAMLFieldName(f.Tag)
+		if !f.IsExported() || yamlTag == "-" {
+			continue
+		}
+		path := path + "." + f.Name
+		if f.Anonymous {
+			t := f.Type
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			if err := exampleStructFields(o, path, t, n); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := exampleFieldNode(o, path, f)
+		if err != nil {
+			return err
+		}
+		key := newStringNode(yamlTag)
+		key.HeadComment = exampleComment(f)
+		n.Content = append(n.Content, key, value)
+	}
+	return nil
+}
+
+// exampleFieldNode returns the YAML node of the value of the `default`
+// struct tag of field f or the example value of its type.
+func exampleFieldNode(o *options, path string, f reflect.StructField) (*yaml.Node, error) {
+	d, ok := f.Tag.Lookup("default")
+	if !ok {
+		return exampleNode(o, path, f.Type)
+	}
+	v := reflect.New(f.Type).Elem()
+	// The default value was checked by ValidateType already.
+	_ = setFromString(v, d)
+	return marshalNode(o, path, v)
+}
+
+// exampleComment returns the comment of field f consisting of
+// its description and validate rules.
+func exampleComment(f reflect.StructField) string {
+	var lines []string
+	if doc := f.Tag.Get("doc"); doc != "" {
+		lines = append(lines, doc)
+	}
+	if rules := f.Tag.Get("validate"); rules != "" {
+		lines = append(lines, "validate: "+rules)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// containsStruct returns true if tp is a plain struct or a pointer to one.
+func containsStruct(tp reflect.Type) bool {
+	for tp.Kind() == reflect.Pointer {
+		tp = tp.Elem()
+	}
+	return isPlainStruct(tp)
+}
@@ -0,0 +1,88 @@
+package yamagiconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExampleYAML(t *testing.T) {
+	b, err := yamagiconf.ExampleYAML[DocsConfig]()
+	require.NoError(t, err)
+	require.Equal(t, `# Name of the instance.
+# validate: required
+name: ""
+# HTTP server.
+server:
+  # validate: required,hostname
+  host: ""
+  # Request timeout, 0 disables it.
+  timeout: 0s
+upstreams:
+  example:
+    # validate: required,hostname
+    host: ""
+    # Request timeout, 0 disables it.
+    timeout: 0s
+# validate: dive,oneof=a|b
+tags: []
+`, string(b))
+}
+
+func TestExampleYAMLValues(t *testing.T) {
+	type Level int8
+	type Item struct {
+		Name string `yaml:"name"`
+	}
+	type TestConfig struct {
+		Workers  uint8               `yaml:"workers" default:"4"`
+		Ratio    float64             `yaml:"ratio"`
+		Enabled  bool                `yaml:"enabled"`
+		Level    Level               `yaml:"level"`
+		Started  time.Time           `yaml:"started"`
+		URL      yamagiconf.URL      `yaml:"url"`
+		Optional *string             `yaml:"optional"`
+		Nested   *Item               `yaml:"nested"`
+		Pair     [2]int32            `yaml:"pair"`
+		Items    []*Item             `yaml:"items"`
+		ByID     map[uint16]Item     `yaml:"by-id"`
+		Labels   map[string]string   `yaml:"labels"`
+		Ignored  string              `yaml:"-"`
+		Grouped  map[string][]string `yaml:"grouped"`
+	}
+
+	opt := yamagiconf.WithEnumMapping(map[string]Level{"info": 1, "debug": 0})
+	b, err := yamagiconf.ExampleYAML[TestConfig](opt)
+	require.NoError(t, err)
+	require.Equal(t, `workers: 4
+ratio: 0
+enabled: false
+level: debug
+started: "0001-01-01T00:00:00Z"
+url: ""
+optional: null
+nested:
+  name: ""
+pair:
+  - 0
+  - 0
+items:
+  - name: ""
+by-id:
+  0:
+    name: ""
+labels: {}
+grouped: {}
+`, string(b))
+
+	var c TestConfig
+	require.NoError(t, yamagiconf.LoadWithOptions(b, &c, opt))
+	require.Equal(t, uint8(4), c.Workers)
+}
+
+func TestExampleYAMLErrInvalidType(t *testing.T) {
+	_, err := yamagiconf.ExampleYAML[string]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeIllegalRoot)
+}
@@ -0,0 +1,84 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WithExpandEnv makes Load replace references to env vars of the form
+// `${NAME}` in string values with the values of the env vars, for example
+// `dsn: "postgres://${DB_HOST}:5432/app"`. `$$` is replaced by a literal `$`,
+// any other `$` is kept as is. References to env vars that aren't set
+// are reported as ErrEnvMissingVar and malformed references as
+// ErrEnvInvalidReference. Env vars are looked up the same way as for
+// `env` struct tags (see WithEnvSource).
+// Only values and map keys of string types are expanded, types implementing
+// encoding.TextUnmarshaler and values produced by resolvers and decryptors
+// aren't. Values are expanded before validation.
+func WithExpandEnv() Option {
+	return func(o *options) { o.expandEnv = true }
+}
+
+// expandEnvNode replaces the env var references in the value of
+// string node of type tp if WithExpandEnv is used.
+func (o *options) expandEnvNode(tp reflect.Type, node *yaml.Node) error {
+	if !o.expandEnv || node.Kind != yaml.ScalarNode || node.Tag != "!!str" ||
+		tp.Kind() != reflect.String || o.resolved[node] || o.expanded[node] ||
+		implementsInterface[encoding.TextUnmarshaler](tp) {
+		return nil
+	}
+	v, err := o.expandEnvRefs(node.Value)
+	if err != nil {
+		return err
+	}
+	if o.expanded == nil {
+		o.expanded = make(map[*yaml.Node]bool)
+	}
+	// Nodes of aliased mappings are visited multiple times.
+	o.expanded[node] = true
+	node.Value = v
+	return nil
+}
+
+// expandEnvRefs replaces `${NAME}` in s with the value of env var NAME
+// and `$$` with `$`.
+func (o *options) expandEnvRefs(s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i == -1 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			s = s[i+2:]
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end == -1 {
+				return "", fmt.Errorf("%w: unterminated %q",
+					ErrEnvInvalidReference, s[i:])
+			}
+			name := s[i+2 : i+2+end]
+			if !regexEnvVarPOSIX.MatchString(name) {
+				return "", fmt.Errorf("%w: %q must match the POSIX env var regexp: %s",
+					ErrEnvInvalidReference, s[i:i+3+end], regexEnvVarPOSIXPattern)
+			}
+			v, ok := o.lookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("%w %s", ErrEnvMissingVar, name)
+			}
+			b.WriteString(v)
+			s = s[i+3+end:]
+		default:
+			b.WriteByte('$')
+			s = s[i+1:]
+		}
+	}
+}
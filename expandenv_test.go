@@ -0,0 +1,121 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithExpandEnv(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host" validate:"hostname"`
+	}
+	type TestConfig struct {
+		DSN     string            `yaml:"dsn"`
+		Price   *string           `yaml:"price"`
+		Servers []Server          `yaml:"servers" validate:"dive"`
+		Labels  map[string]string `yaml:"labels"`
+		Port    uint16            `yaml:"port"`
+		Text    TextUnmarshaler   `yaml:"text"`
+	}
+	load := func(src string) (*TestConfig, error) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithExpandEnv())
+		return &c, err
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		t.Setenv("DB_HOST", "db.local")
+		t.Setenv("HOST", "example.com")
+		t.Setenv("ENV", "prod")
+		c, err := load(`dsn: "postgres://${DB_HOST}:5432/app"
+price: $$5 or $5 or ${HOST}$
+servers:
+  - host: ${HOST}
+  - host: &h api.${HOST}
+  - host: *h
+labels:
+  ${ENV}: env=${ENV}
+port: 8080
+text: ${HOST}
+`)
+		require.NoError(t, err)
+		require.Equal(t, &TestConfig{
+			DSN:   "postgres://db.local:5432/app",
+			Price: PtrTo("$5 or $5 or example.com$"),
+			Servers: []Server{
+				{Host: "example.com"},
+				{Host: "api.example.com"},
+				{Host: "api.example.com"},
+			},
+			Labels: map[string]string{"prod": "env=prod"},
+			Port:   8080,
+			Text:   TextUnmarshaler{Str: "${HOST}"},
+		}, c)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("DB_HOST", "db.local")
+		c, err := LoadSrc[TestConfig](`dsn: ${DB_HOST}
+price: null
+servers: []
+labels: {}
+port: 8080
+text: x
+`)
+		require.NoError(t, err)
+		require.Equal(t, "${DB_HOST}", c.DSN)
+	})
+
+	t.Run("validated", func(t *testing.T) {
+		t.Setenv("HOST", "not a hostname")
+		_, err := load(`dsn: x
+price: null
+servers:
+  - host: ${HOST}
+labels: {}
+port: 8080
+text: x
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 4:11: "host" violates validation rule: "hostname"`,
+			err.Error())
+	})
+
+	t.Run("err_missing", func(t *testing.T) {
+		_, err := load("dsn: postgres://${DB_HOST}/app\n")
+		require.ErrorIs(t, err, yamagiconf.ErrEnvMissingVar)
+		require.Equal(t, `at 1:6: "dsn" (TestConfig.DSN): missing env var DB_HOST`,
+			err.Error())
+	})
+
+	t.Run("err_unterminated", func(t *testing.T) {
+		_, err := load("dsn: postgres://${DB_HOST/app\n")
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidReference)
+		require.Equal(t, `at 1:6: "dsn" (TestConfig.DSN): `+
+			`invalid env var reference: unterminated "${DB_HOST/app"`, err.Error())
+	})
+
+	t.Run("err_invalid_name", func(t *testing.T) {
+		_, err := load("dsn: postgres://${db-host}/app\n")
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidReference)
+		require.Equal(t, `at 1:6: "dsn" (TestConfig.DSN): `+
+			`invalid env var reference: "${db-host}" must match `+
+			`the POSIX env var regexp: ^[A-Z_][A-Z0-9_]*$`, err.Error())
+	})
+
+	t.Run("env_source", func(t *testing.T) {
+		type TestConfig struct {
+			DSN string `yaml:"dsn"`
+		}
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("dsn: ${DB_HOST}\n", &c,
+			yamagiconf.WithExpandEnv(),
+			yamagiconf.WithEnvSource(func(key string) (string, bool) {
+				return "from-source", key == "DB_HOST"
+			}))
+		require.NoError(t, err)
+		require.Equal(t, "from-source", c.DSN)
+	})
+}
@@ -0,0 +1,96 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// fieldChecks check the values of fields against their `pattern`, `format`,
+// `multipleof`, `maxbytes`, `maxrunes`, `sorted`, `cidr`, `fileexists`,
+// `before`, `after` and `sumbudget` struct tags.
+var fieldChecks = [...]fieldCheck{
+	checkPatternField,
+	checkFormatField,
+	checkMultipleOfField,
+	checkMaxLenField,
+	checkSortedField,
+	checkCIDRField,
+	checkFileExistsField,
+	checkOrderField,
+	checkSumBudgetField,
+}
+
+// fieldCheck checks the value fv of field f of struct v at Go path against
+// the struct tags of f and reports violations to w. Returns the error
+// returned by w.report if it's non-nil.
+// Assumes that the config type has already been validated.
+type fieldCheck func(
+	w *fieldWalker, path string, v reflect.Value, f reflect.StructField, fv reflect.Value,
+) error
+
+// fieldWalker traverses config values applying fieldChecks to every field
+// except those within sections disabled by a `validate_when` struct tag.
+type fieldWalker struct {
+	baseDir string // Relative `fileexists` paths are resolved against.
+
+	// name returns how field f is referred to in violations.
+	name func(f reflect.StructField) string
+
+	// report is called for every violation of the value at Go path.
+	// The traversal stops if it returns an error.
+	report func(path string, err error) error
+}
+
+// walk applies fieldChecks to all fields within v at Go path.
+func (w *fieldWalker) walk(path string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return w.walk(path, v.Elem())
+		}
+	case reflect.Struct:
+		tp := v.Type()
+		for i := range tp.NumField() {
+			f := tp.Field(i)
+			if !f.IsExported() || validateWhenDisabled(v, f) {
+				continue
+			}
+			path, fv := path+"."+f.Name, v.Field(i)
+			for _, check := range fieldChecks {
+				if err := check(w, path, v, f, fv); err != nil {
+					return err
+				}
+			}
+			if err := w.walk(path, fv); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if err := w.walk(fmt.Sprintf("%s[%d]", path, i), v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range mapKeysSorted(v) {
+			if err := w.walk(fmt.Sprintf("%s[%v]", path, key), v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkItems calls check for v or, if v is a slice or array,
+// for every item of v at Go path individually.
+func checkItems(path string, v reflect.Value, check func(path string, v reflect.Value) error) error {
+	if k := v.Kind(); k != reflect.Slice && k != reflect.Array {
+		return check(path, v)
+	}
+	for i := range v.Len() {
+		if err := check(fmt.Sprintf("%s[%d]", path, i), v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
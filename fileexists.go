@@ -0,0 +1,87 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validateFileExistsField returns an error if f has a `fileexists` struct tag
+// other than "true" or "readable" or f isn't a string or pointer to string.
+func validateFileExistsField(f reflect.StructField) error {
+	v, ok := f.Tag.Lookup("fileexists")
+	if !ok {
+		return nil
+	}
+	if v != "true" && v != "readable" {
+		return fmt.Errorf("%w: %q, expected true or readable",
+			ErrTypeInvalidFileExistsTag, v)
+	}
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String ||
+		implementsInterface[encoding.TextUnmarshaler](t) ||
+		implementsInterface[yaml.Unmarshaler](t) {
+		return fmt.Errorf("%w: %s is not a string",
+			ErrTypeInvalidFileExistsTag, f.Type.String())
+	}
+	return nil
+}
+
+// checkFileExistsField reports string field f if its value isn't the path
+// of an existing file, or a readable file for `fileexists:"readable"`.
+// Relative paths are resolved against w.baseDir.
+// Nil pointers are not checked.
+func checkFileExistsField(
+	w *fieldWalker, path string, _ reflect.Value, f reflect.StructField, fv reflect.Value,
+) error {
+	mode, ok := f.Tag.Lookup("fileexists")
+	if !ok {
+		return nil
+	}
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	if err := checkFileExists(w.baseDir, fv.String(), mode); err != nil {
+		return w.report(path, err)
+	}
+	return nil
+}
+
+// checkFileExists returns ErrReferencedFileMissing if there's no file
+// at filePath and ErrReferencedFileUnreadable if mode is "readable"
+// and the file can't be opened for reading.
+func checkFileExists(baseDir, filePath, mode string) error {
+	if filePath == "" {
+		return fmt.Errorf("%w: empty path", ErrReferencedFileMissing)
+	}
+	p := filePath
+	if !filepath.IsAbs(p) && baseDir != "" {
+		p = filepath.Join(baseDir, p)
+	}
+	if _, err := os.Stat(p); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %q", ErrReferencedFileMissing, filePath)
+		}
+		return fmt.Errorf("%w: %q: %w", ErrReferencedFileMissing, filePath, err)
+	}
+	if mode != "readable" {
+		return nil
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("%w: %q", ErrReferencedFileUnreadable, filePath)
+	}
+	return f.Close()
+}
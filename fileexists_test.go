@@ -0,0 +1,102 @@
+package yamagiconf_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileExistsTag(t *testing.T) {
+	type TestConfig struct {
+		Cert string  `yaml:"cert" fileexists:"true"`
+		Key  *string `yaml:"key" fileexists:"readable"`
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certPath, []byte("cert"), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, []byte("key"), 0o600))
+
+	t.Run("existing", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("cert: " + certPath + "\nkey: " + keyPath + "\n")
+		require.NoError(t, err)
+		require.Equal(t, certPath, c.Cert)
+
+		_, err = LoadSrc[TestConfig]("cert: " + certPath + "\nkey: null\n")
+		require.NoError(t, err)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		missing := filepath.Join(dir, "missing.crt")
+		_, err := LoadSrc[TestConfig]("cert: " + missing + "\nkey: null\n")
+		require.ErrorIs(t, err, yamagiconf.ErrReferencedFileMissing)
+		require.Equal(t, `at 1:7: "cert": referenced file missing: "`+
+			missing+`"`, err.Error())
+
+		_, err = LoadSrc[TestConfig]("cert: ''\nkey: null\n")
+		require.ErrorIs(t, err, yamagiconf.ErrReferencedFileMissing)
+		require.Equal(t, `at 1:7: "cert": referenced file missing: empty path`,
+			err.Error())
+	})
+
+	t.Run("unreadable", func(t *testing.T) {
+		if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+			t.Skip("file permissions are not enforced")
+		}
+		unreadable := filepath.Join(dir, "unreadable.key")
+		require.NoError(t, os.WriteFile(unreadable, []byte("key"), 0o000))
+		_, err := LoadSrc[TestConfig]("cert: " + certPath + "\nkey: " + unreadable + "\n")
+		require.ErrorIs(t, err, yamagiconf.ErrReferencedFileUnreadable)
+		require.Equal(t, `at 2:6: "key": referenced file not readable: "`+
+			unreadable+`"`, err.Error())
+
+		// Existence alone doesn't require read permission.
+		_, err = LoadSrc[TestConfig]("cert: " + unreadable + "\nkey: null\n")
+		require.NoError(t, err)
+	})
+
+	t.Run("relative to file", func(t *testing.T) {
+		configPath := filepath.Join(dir, "config.yaml")
+		require.NoError(t, os.WriteFile(configPath,
+			[]byte("cert: tls.crt\nkey: ./tls.key\n"), 0o600))
+		var c TestConfig
+		require.NoError(t, yamagiconf.LoadFile(configPath, &c))
+		require.Equal(t, "tls.crt", c.Cert)
+
+		require.NoError(t, os.WriteFile(configPath,
+			[]byte("cert: certs/tls.crt\nkey: null\n"), 0o600))
+		err := yamagiconf.LoadFile(configPath, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrReferencedFileMissing)
+		require.Equal(t, `at 1:7: "cert": referenced file missing: "certs/tls.crt"`,
+			err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{Cert: filepath.Join(dir, "nope")})
+		require.ErrorIs(t, err, yamagiconf.ErrReferencedFileMissing)
+	})
+}
+
+func TestFileExistsTagInvalid(t *testing.T) {
+	type TestConfigInvalidValue struct {
+		Cert string `yaml:"cert" fileexists:"yes"`
+	}
+	err := yamagiconf.ValidateType[TestConfigInvalidValue]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidFileExistsTag)
+	require.Equal(t, `at TestConfigInvalidValue.Cert: `+
+		`invalid fileexists struct tag: "yes", expected true or readable`,
+		err.Error())
+
+	type TestConfigInvalidType struct {
+		Port uint16 `yaml:"port" fileexists:"true"`
+	}
+	err = yamagiconf.ValidateType[TestConfigInvalidType]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidFileExistsTag)
+	require.Equal(t, `at TestConfigInvalidType.Port: `+
+		`invalid fileexists struct tag: uint16 is not a string`, err.Error())
+}
@@ -0,0 +1,26 @@
+package yamagiconf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Fingerprint returns the hex-encoded SHA-256 digest of config serialized
+// by Marshal in canonical form, which is useful for detecting whether
+// a reloaded config actually changed. Fields are serialized in declaration
+// order, map entries in ascending order of their keys and floats in their
+// shortest representation regardless of WithMapSortOrder and WithFloatFormat.
+// Configs loaded from documents that differ only in formatting, comments,
+// the order of map entries or the use of anchors therefore have the same
+// fingerprint. Options such as WithEnumMapping and WithPolymorphic apply.
+func Fingerprint[T any](config T, opts ...Option) (string, error) {
+	b, err := Marshal(config, append(opts, func(o *options) {
+		o.mapSortOrder = MapSortAsc
+		o.floatFmt, o.floatPrec = 'g', -1
+	})...)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:]), nil
+}
@@ -0,0 +1,94 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	type Server struct {
+		Host string  `yaml:"host"`
+		Port uint16  `yaml:"port"`
+		Load float64 `yaml:"load"`
+	}
+	type TestConfig struct {
+		Name    string            `yaml:"name"`
+		Servers []Server          `yaml:"servers"`
+		Labels  map[string]string `yaml:"labels"`
+		Color   Color             `yaml:"color"`
+	}
+	opt := yamagiconf.WithEnumMapping(colorNames)
+
+	fingerprint := func(t *testing.T, src string, opts ...yamagiconf.Option) string {
+		t.Helper()
+		var c TestConfig
+		require.NoError(t, yamagiconf.LoadWithOptions(src, &c, opt))
+		f, err := yamagiconf.Fingerprint(c, append(opts, opt)...)
+		require.NoError(t, err)
+		require.Len(t, f, 64)
+		return f
+	}
+
+	base := fingerprint(t, `name: app
+servers:
+  - host: a
+    port: 80
+    load: 0.5
+labels:
+  env: prod
+  stage: prod
+  tier: web
+color: green
+`)
+
+	// Formatting only.
+	require.Equal(t, base, fingerprint(t, `# The app.
+name: "app"
+servers: [{host: 'a', port: 0x50, load: 5e-1}]
+labels: {tier: web, stage: prod, env: prod} # Different order.
+color: green
+`))
+
+	// Anchors and aliases.
+	require.Equal(t, base, fingerprint(t, `name: app
+servers:
+  - host: a
+    port: 80
+    load: 0.50
+labels:
+  env: &p prod
+  stage: *p
+  tier: web
+color: green
+`))
+
+	// Output options don't affect the fingerprint.
+	require.Equal(t, base, fingerprint(t, `name: app
+servers:
+  - host: a
+    port: 80
+    load: 0.5
+labels:
+  env: prod
+  stage: prod
+  tier: web
+color: green
+`, yamagiconf.WithMapSortOrder(yamagiconf.MapSortDesc),
+		yamagiconf.WithFloatFormat('f', 3)))
+
+	// Semantic changes.
+	for _, src := range []string{
+		"name: app\nservers: [{host: a, port: 81, load: 0.5}]\n" +
+			"labels: {env: prod, stage: prod, tier: web}\ncolor: green\n",
+		"name: app\nservers: [{host: a, port: 80, load: 0.5}]\n" +
+			"labels: {env: prod, stage: prod, tier: api}\ncolor: green\n",
+		"name: app\nservers: [{host: a, port: 80, load: 0.5}]\n" +
+			"labels: {env: prod, stage: prod, tier: web}\ncolor: red\n",
+		"name: app\nservers: []\n" +
+			"labels: {env: prod, stage: prod, tier: web}\ncolor: green\n",
+	} {
+		require.NotEqual(t, base, fingerprint(t, src), src)
+	}
+}
@@ -0,0 +1,148 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"encoding/pem"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// formats maps the names of supported `format` struct tag values
+// to functions checking whether a string is of the format.
+// The argument following the colon in tags like `format:"pem:CERTIFICATE"`
+// is passed as arg and is empty if there is none.
+var formats = map[string]func(s, arg string) error{
+	"uuid":  withoutArg(checkUUID),
+	"uuid4": withoutArg(checkUUID4),
+	"pem":   checkPEM,
+}
+
+// formatsWithArg lists the formats accepting an argument.
+var formatsWithArg = map[string]bool{"pem": true}
+
+func withoutArg(fn func(s string) error) func(s, arg string) error {
+	return func(s, _ string) error { return fn(s) }
+}
+
+// validateFormatField returns an error if f has a `format` struct tag
+// with an unknown format or f isn't a string or pointer to string.
+func validateFormatField(f reflect.StructField) error {
+	tag, ok := f.Tag.Lookup("format")
+	if !ok {
+		return nil
+	}
+	name, arg, hasArg := strings.Cut(tag, ":")
+	if _, ok := formats[name]; !ok {
+		names := make([]string, 0, len(formats))
+		for n := range formats {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return fmt.Errorf("%w: unknown format %q, supported: %s",
+			ErrTypeInvalidFormatTag, name, strings.Join(names, ", "))
+	}
+	if hasArg && !formatsWithArg[name] {
+		return fmt.Errorf("%w: format %q doesn't accept an argument",
+			ErrTypeInvalidFormatTag, name)
+	}
+	if hasArg && arg == "" {
+		return fmt.Errorf("%w: empty argument for format %q",
+			ErrTypeInvalidFormatTag, name)
+	}
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String ||
+		implementsInterface[encoding.TextUnmarshaler](t) ||
+		implementsInterface[yaml.Unmarshaler](t) {
+		return fmt.Errorf("%w: %s is not a string",
+			ErrTypeInvalidFormatTag, f.Type.String())
+	}
+	return nil
+}
+
+// checkFormatField reports string field f if its value isn't of the format
+// of its `format` struct tag. Nil pointers are not checked.
+func checkFormatField(
+	w *fieldWalker, path string, _ reflect.Value, f reflect.StructField, fv reflect.Value,
+) error {
+	tag, ok := f.Tag.Lookup("format")
+	if !ok {
+		return nil
+	}
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	name, arg, _ := strings.Cut(tag, ":")
+	if err := formats[name](fv.String(), arg); err != nil {
+		return w.report(path, err)
+	}
+	return nil
+}
+
+// checkUUID returns ErrInvalidUUID if s isn't a UUID
+// in the canonical 8-4-4-4-12 hexadecimal form of any version.
+func checkUUID(s string) error {
+	if len(s) != 36 {
+		return ErrInvalidUUID
+	}
+	for i := range len(s) {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return ErrInvalidUUID
+			}
+		default:
+			if !isHexDigit(s[i]) {
+				return ErrInvalidUUID
+			}
+		}
+	}
+	return nil
+}
+
+// checkUUID4 is similar to checkUUID but requires version 4
+// of the RFC 9562 variant.
+func checkUUID4(s string) error {
+	if err := checkUUID(s); err != nil {
+		return err
+	}
+	if s[14] != '4' {
+		return fmt.Errorf("%w: version %c, expected 4", ErrInvalidUUID, s[14])
+	}
+	switch s[19] {
+	case '8', '9', 'a', 'b', 'A', 'B':
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported variant", ErrInvalidUUID)
+}
+
+func isHexDigit(b byte) bool {
+	return '0' <= b && b <= '9' || 'a' <= b && b <= 'f' || 'A' <= b && b <= 'F'
+}
+
+// checkPEM returns ErrInvalidPEM if s isn't a single PEM block
+// optionally surrounded by whitespace, or if blockType is non-empty
+// and the type of the block isn't blockType.
+func checkPEM(s, blockType string) error {
+	block, rest := pem.Decode([]byte(s))
+	if block == nil {
+		return ErrInvalidPEM
+	}
+	if len(strings.TrimSpace(string(rest))) > 0 {
+		return fmt.Errorf("%w: unexpected data after block", ErrInvalidPEM)
+	}
+	if blockType != "" && block.Type != blockType {
+		return fmt.Errorf("%w: block type %q, expected %q",
+			ErrInvalidPEM, block.Type, blockType)
+	}
+	return nil
+}
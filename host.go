@@ -0,0 +1,114 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultHostSectionKey is the default key of WithHostSectionKey.
+const DefaultHostSectionKey = "hosts"
+
+// WithHostSectionKey sets the key of the mapping of host sections
+// LoadForHost looks for in the document (DefaultHostSectionKey by default).
+func WithHostSectionKey(key string) Option {
+	return func(o *options) { o.hostSectionKey = key }
+}
+
+// WithHostname makes LoadForHost select the host section by hostname
+// instead of the name returned by os.Hostname.
+func WithHostname(hostname string) Option {
+	return func(o *options) { o.hostname = hostname }
+}
+
+// LoadForHost is similar to LoadWithOptions but specializes the document for
+// the host it's loaded on. The top-level mapping of host sections
+// (see WithHostSectionKey) is removed from the document and the section
+// whose key matches the hostname (see WithHostname) is deep-merged over
+// the rest of the document before validation. The section may therefore
+// be partial. Keys are matched exactly first and then as glob patterns
+// (see path.Match) like "web-*" in the order they appear in the document.
+// If no section matches the document is loaded as is. The document isn't
+// required to contain host sections but all sections must be mappings.
+// The config type must not declare a field with the key of the host sections.
+func LoadForHost[T any](src []byte, config *T, opts ...Option) error {
+	o := newOptions(opts)
+	if config == nil {
+		return ErrConfigNil
+	}
+	if len(src) == 0 {
+		return ErrYAMLEmptyFile
+	}
+	if err := o.validateLoadType(reflect.TypeFor[T]()); err != nil {
+		return err
+	}
+	hostname := o.hostname
+	if hostname == "" {
+		var err error
+		if hostname, err = os.Hostname(); err != nil {
+			return fmt.Errorf("getting hostname: %w", err)
+		}
+	}
+	node, err := parseDocument(src, o.allowMultiDoc)
+	if err != nil {
+		return err
+	}
+	o.source = newSourceIndex(src, node)
+	if node, err = o.mergeHostSection(node, hostname); err != nil {
+		return err
+	}
+	o.source.root = node
+	return loadNode(o, config, node)
+}
+
+// mergeHostSection removes the host sections from root mapping node
+// and returns it with the section matching hostname merged over it.
+func (o *options) mergeHostSection(node *yaml.Node, hostname string) (*yaml.Node, error) {
+	key := o.hostSectionKey
+	if key == "" {
+		key = DefaultHostSectionKey
+	}
+	if node.Kind != yaml.MappingNode {
+		return node, nil // Reported by validation.
+	}
+	i := findMappingKey(node, key)
+	if i == -1 {
+		return node, nil
+	}
+	sections := node.Content[i+1]
+	if sections.Kind != yaml.MappingNode {
+		return nil, o.errorAt(sections.Line, sections.Column, "",
+			fmt.Errorf("%q: %w: expected a mapping", key, ErrYAMLMalformed))
+	}
+
+	var exact, glob *yaml.Node
+	for i := 0; i < len(sections.Content); i += 2 {
+		k, v := sections.Content[i], sections.Content[i+1]
+		if v.Kind != yaml.MappingNode {
+			return nil, o.errorAt(v.Line, v.Column, "",
+				fmt.Errorf("%q: %w: expected a mapping", k.Value, ErrYAMLMalformed))
+		}
+		match, err := path.Match(k.Value, hostname)
+		if err != nil {
+			return nil, o.errorAt(k.Line, k.Column, "",
+				fmt.Errorf("invalid host pattern %q: %w", k.Value, err))
+		}
+		switch {
+		case k.Value == hostname && exact == nil:
+			exact = v
+		case match && glob == nil:
+			glob = v
+		}
+	}
+	node.Content = append(node.Content[:i:i], node.Content[i+2:]...)
+	if exact != nil {
+		return mergeNodes(node, exact), nil
+	}
+	if glob != nil {
+		return mergeNodes(node, glob), nil
+	}
+	return node, nil
+}
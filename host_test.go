@@ -0,0 +1,149 @@
+package yamagiconf_test
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadForHost(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host"`
+		Port uint16 `yaml:"port"`
+	}
+	type TestConfig struct {
+		Server  Server `yaml:"server"`
+		Workers uint8  `yaml:"workers"`
+	}
+	const src = `server:
+  host: localhost
+  port: 8080
+workers: 2
+hosts:
+  web-*:
+    workers: 8
+  web-1:
+    server:
+      host: web-1.internal
+  db-?:
+    server:
+      port: 5432
+`
+
+	for _, td := range []struct {
+		name, hostname string
+		expect         TestConfig
+	}{
+		{
+			name:     "exact",
+			hostname: "web-1",
+			expect:   TestConfig{Server: Server{Host: "web-1.internal", Port: 8080}, Workers: 2},
+		},
+		{
+			name:     "glob",
+			hostname: "web-2",
+			expect:   TestConfig{Server: Server{Host: "localhost", Port: 8080}, Workers: 8},
+		},
+		{
+			name:     "glob_single_char",
+			hostname: "db-3",
+			expect:   TestConfig{Server: Server{Host: "localhost", Port: 5432}, Workers: 2},
+		},
+		{
+			name:     "no_match",
+			hostname: "cache-1",
+			expect:   TestConfig{Server: Server{Host: "localhost", Port: 8080}, Workers: 2},
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			var c TestConfig
+			err := yamagiconf.LoadForHost([]byte(src), &c,
+				yamagiconf.WithHostname(td.hostname))
+			require.NoError(t, err)
+			require.Equal(t, td.expect, c)
+		})
+	}
+
+	t.Run("os_hostname", func(t *testing.T) {
+		hostname, err := os.Hostname()
+		require.NoError(t, err)
+		var c TestConfig
+		err = yamagiconf.LoadForHost([]byte(`server:
+  host: localhost
+  port: 8080
+workers: 2
+hosts:
+  "`+hostname+`":
+    workers: 4
+`), &c)
+		require.NoError(t, err)
+		require.Equal(t, uint8(4), c.Workers)
+	})
+
+	t.Run("custom_key", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadForHost([]byte(`server:
+  host: localhost
+  port: 8080
+workers: 2
+machines:
+  web-1:
+    workers: 3
+`), &c, yamagiconf.WithHostname("web-1"), yamagiconf.WithHostSectionKey("machines"))
+		require.NoError(t, err)
+		require.Equal(t, uint8(3), c.Workers)
+	})
+
+	t.Run("no_sections", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadForHost([]byte("server:\n  host: x\n  port: 1\nworkers: 2\n"),
+			&c, yamagiconf.WithHostname("web-1"))
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Server: Server{Host: "x", Port: 1}, Workers: 2}, c)
+	})
+
+	t.Run("invalid_merged_value", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadForHost([]byte(src+"  web-9:\n    workers: null\n"), &c,
+			yamagiconf.WithHostname("web-9"))
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLNullOnNonPointer)
+		require.Equal(t, `at 15:14: "workers" (TestConfig.Workers): `+
+			`cannot assign null to non-pointer type`, err.Error())
+	})
+}
+
+func TestLoadForHostErr(t *testing.T) {
+	type TestConfig struct {
+		Workers uint8 `yaml:"workers"`
+	}
+
+	t.Run("sections_not_mapping", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadForHost([]byte("workers: 2\nhosts: [web-1]\n"), &c,
+			yamagiconf.WithHostname("web-1"))
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 2:8: "hosts": malformed YAML: expected a mapping`,
+			err.Error())
+	})
+
+	t.Run("section_not_mapping", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadForHost([]byte("workers: 2\nhosts:\n  db-1: 4\n"), &c,
+			yamagiconf.WithHostname("web-1"))
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 3:9: "db-1": malformed YAML: expected a mapping`,
+			err.Error())
+	})
+
+	t.Run("invalid_pattern", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadForHost([]byte("workers: 2\nhosts:\n  '[web':\n    workers: 1\n"),
+			&c, yamagiconf.WithHostname("web-1"))
+		require.ErrorIs(t, err, path.ErrBadPattern)
+		require.Equal(t, `at 3:3: invalid host pattern "[web": `+
+			`syntax error in pattern`, err.Error())
+	})
+}
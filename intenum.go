@@ -0,0 +1,111 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WithIntEnum makes integer type T accept only validValues, for example
+// the declared constants of `type Level int8`. Values that aren't in
+// validValues are rejected with ErrIntEnumValue, including values provided
+// by env vars and map keys. Unlike WithEnumMapping the values are
+// written and read as numbers and Marshal is unaffected.
+func WithIntEnum[T ~int8 | ~int16 | ~int32 | ~int64](validValues ...T) Option {
+	e := &intEnum{values: make(map[int64]struct{}, len(validValues))}
+	sorted := make([]int64, 0, len(validValues))
+	for _, v := range validValues {
+		if _, ok := e.values[int64(v)]; !ok {
+			e.values[int64(v)] = struct{}{}
+			sorted = append(sorted, int64(v))
+		}
+	}
+	slices.Sort(sorted)
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, v := range sorted {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatInt(v, 10))
+	}
+	b.WriteByte(']')
+	e.allowed = b.String()
+	tp := reflect.TypeFor[T]()
+	return func(o *options) {
+		if o.intEnums == nil {
+			o.intEnums = make(map[reflect.Type]*intEnum)
+		}
+		o.intEnums[tp] = e
+	}
+}
+
+type intEnum struct {
+	values  map[int64]struct{}
+	allowed string // Values sorted in ascending order, like "[0 1 2]".
+}
+
+// check returns an error wrapping ErrIntEnumValue if v isn't a valid value.
+func (e *intEnum) check(v int64) error {
+	if _, ok := e.values[v]; !ok {
+		return fmt.Errorf("%w %d, allowed: %s", ErrIntEnumValue, v, e.allowed)
+	}
+	return nil
+}
+
+// checkIntEnums traverses v and calls fn for every value at Go path
+// of a type registered with WithIntEnum that isn't a valid value.
+// Stops and returns the error returned by fn if it's non-nil.
+// Nil pointers are not checked.
+func (o *options) checkIntEnums(
+	path string, v reflect.Value, fn func(path string, err error) error,
+) error {
+	if len(o.intEnums) < 1 {
+		return nil
+	}
+	if e := o.intEnums[v.Type()]; e != nil {
+		if err := e.check(v.Int()); err != nil {
+			return fn(path, err)
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			return o.checkIntEnums(path, v.Elem(), fn)
+		}
+	case reflect.Struct:
+		tp := v.Type()
+		for i := range tp.NumField() {
+			if !tp.Field(i).IsExported() {
+				continue
+			}
+			path := path + "." + tp.Field(i).Name
+			if err := o.checkIntEnums(path, v.Field(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			path := fmt.Sprintf("%s[%d]", path, i)
+			if err := o.checkIntEnums(path, v.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range mapKeysSorted(v) {
+			path := fmt.Sprintf("%s[%v]", path, key)
+			if e := o.intEnums[key.Type()]; e != nil {
+				if err := e.check(key.Int()); err != nil {
+					return fn(path, err)
+				}
+			}
+			if err := o.checkIntEnums(path, v.MapIndex(key), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Level int8
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func TestWithIntEnum(t *testing.T) {
+	type TestConfig struct {
+		Level   Level            `yaml:"level" env:"LEVEL"`
+		Ptr     *Level           `yaml:"ptr"`
+		Levels  []Level          `yaml:"levels"`
+		ByLevel map[Level]int8   `yaml:"by-level"`
+		Names   map[string]Level `yaml:"names"`
+	}
+	opt := yamagiconf.WithIntEnum(LevelDebug, LevelInfo, LevelWarn, LevelError)
+
+	t.Run("valid", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`level: 1
+ptr: 3
+levels: [0, 2]
+by-level:
+  3: 1
+names:
+  x: 2
+`, &c, opt)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Level:   LevelInfo,
+			Ptr:     PtrTo(LevelError),
+			Levels:  []Level{LevelDebug, LevelWarn},
+			ByLevel: map[Level]int8{LevelError: 1},
+			Names:   map[string]Level{"x": LevelWarn},
+		}, c)
+
+		b, err := yamagiconf.Marshal(c, opt)
+		require.NoError(t, err)
+		require.Contains(t, string(b), "level: 1\n")
+	})
+
+	for _, td := range []struct {
+		name, src, expect string
+	}{
+		{
+			name:   "field",
+			src:    "level: 5\nptr: null\nlevels: []\nby-level: {}\nnames: {}\n",
+			expect: `at 1:8: "level": invalid value 5, allowed: [0 1 2 3]`,
+		},
+		{
+			name:   "pointer",
+			src:    "level: 0\nptr: -1\nlevels: []\nby-level: {}\nnames: {}\n",
+			expect: `at 2:6: "ptr": invalid value -1, allowed: [0 1 2 3]`,
+		},
+		{
+			name:   "slice_item",
+			src:    "level: 0\nptr: null\nlevels: [1, 4]\nby-level: {}\nnames: {}\n",
+			expect: `at 3:13: "levels": invalid value 4, allowed: [0 1 2 3]`,
+		},
+		{
+			name:   "map_value",
+			src:    "level: 0\nptr: null\nlevels: []\nby-level: {}\nnames: {x: 9}\n",
+			expect: `at 5:12: "names": invalid value 9, allowed: [0 1 2 3]`,
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			var c TestConfig
+			err := yamagiconf.LoadWithOptions(td.src, &c, opt)
+			require.ErrorIs(t, err, yamagiconf.ErrIntEnumValue)
+			require.Equal(t, td.expect, err.Error())
+		})
+	}
+
+	t.Run("env", func(t *testing.T) {
+		const src = "level: 0\nptr: null\nlevels: []\nby-level: {}\nnames: {}\n"
+		t.Setenv("LEVEL", "2")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, opt)
+		require.NoError(t, err)
+		require.Equal(t, LevelWarn, c.Level)
+
+		t.Setenv("LEVEL", "7")
+		err = yamagiconf.LoadWithOptions(src, &c, opt)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.ErrorIs(t, err, yamagiconf.ErrIntEnumValue)
+		require.Equal(t, `at TestConfig.Level: invalid env var LEVEL: `+
+			`invalid value 7, allowed: [0 1 2 3]`, err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{Levels: []Level{6}}, opt)
+		require.ErrorIs(t, err, yamagiconf.ErrIntEnumValue)
+		require.Equal(t, `at TestConfig.Levels[0]: `+
+			`invalid value 6, allowed: [0 1 2 3]`, err.Error())
+	})
+}
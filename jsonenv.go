@@ -0,0 +1,88 @@
+package yamagiconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WithJSONEnvOverride makes Load deep-merge the JSON object in env var
+// envVarName over the document after it was loaded, for platforms that
+// inject configuration as a single JSON blob. JSON keys are matched against
+// yaml struct tags, objects are merged key by key and any other value
+// replaces the value in the document entirely. The merged config is then
+// validated again just like the document. Invalid JSON, values that aren't
+// objects and values that don't pass validation are reported as
+// ErrEnvInvalidVar with locations referring to the JSON value.
+// Nothing is merged if the env var isn't set.
+func WithJSONEnvOverride(envVarName string) Option {
+	return func(o *options) { o.jsonEnvOverride = envVarName }
+}
+
+// parseJSONEnvOverride returns the JSON object in the env var set by
+// WithJSONEnvOverride with its mapping node as root or nil if it isn't set.
+func (o *options) parseJSONEnvOverride() (*sourceIndex, error) {
+	env, ok := o.lookupEnv(o.jsonEnvOverride)
+	if !ok {
+		return nil, nil
+	}
+	if !json.Valid([]byte(env)) {
+		return nil, fmt.Errorf("%w %s: invalid JSON", ErrEnvInvalidVar, o.jsonEnvOverride)
+	}
+	env = strings.TrimSpace(env)
+	n, err := parseDocument(env, false)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrEnvInvalidVar, o.jsonEnvOverride, err)
+	}
+	if n.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w %s: must be a JSON object",
+			ErrEnvInvalidVar, o.jsonEnvOverride)
+	}
+	s := newSourceIndex(env, n)
+	s.overlay = true
+	return s, nil
+}
+
+// loadJSONEnvOverride merges the root of override over document node and
+// loads the result into config. node must not have been validated before
+// since validation modifies it. Errors are located in the JSON object.
+func loadJSONEnvOverride[T any](o *options, config *T, node *yaml.Node, override *sourceIndex) error {
+	envVar, source := o.jsonEnvOverride, o.source
+	o.jsonEnvOverride = "" // Don't merge again.
+	o.source = override
+	defer func() { o.jsonEnvOverride, o.source = envVar, source }()
+
+	var merged T
+	if err := loadNode(o, &merged, mergeNodes(node, override.root)); err != nil {
+		return fmt.Errorf("%w %s: %w", ErrEnvInvalidVar, envVar, err)
+	}
+	*config = merged
+	return nil
+}
+
+// cloneNode returns a deep copy of n preserving aliases.
+func cloneNode(n *yaml.Node) *yaml.Node {
+	return cloneNodeMemo(n, make(map[*yaml.Node]*yaml.Node))
+}
+
+func cloneNodeMemo(n *yaml.Node, memo map[*yaml.Node]*yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	if c, ok := memo[n]; ok {
+		return c
+	}
+	c := new(yaml.Node)
+	*c = *n
+	memo[n] = c
+	c.Alias = cloneNodeMemo(n.Alias, memo)
+	if n.Content != nil {
+		c.Content = make([]*yaml.Node, len(n.Content))
+		for i, n := range n.Content {
+			c.Content[i] = cloneNodeMemo(n, memo)
+		}
+	}
+	return c
+}
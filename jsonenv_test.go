@@ -0,0 +1,121 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithJSONEnvOverride(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host"`
+		Port uint16 `yaml:"port" validate:"gt=0"`
+	}
+	type TestConfig struct {
+		Name     string            `yaml:"name"`
+		Server   Server            `yaml:"server"`
+		Tags     []string          `yaml:"tags"`
+		Labels   map[string]string `yaml:"labels"`
+		Fallback *Server           `yaml:"fallback"`
+	}
+	const src = `name: &name app
+server:
+  host: localhost
+  port: 8080
+tags: [a, b]
+labels:
+  team: *name
+  env: dev
+fallback: null
+`
+	load := func(t *testing.T) (TestConfig, error) {
+		t.Helper()
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithJSONEnvOverride("APP_CONFIG_JSON"))
+		return c, err
+	}
+
+	t.Run("not_set", func(t *testing.T) {
+		c, err := load(t)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Name:   "app",
+			Server: Server{Host: "localhost", Port: 8080},
+			Tags:   []string{"a", "b"},
+			Labels: map[string]string{"team": "app", "env": "dev"},
+		}, c)
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		t.Setenv("APP_CONFIG_JSON", `{
+			"server": {"port": 9090},
+			"tags": ["c"],
+			"labels": {"env": "prod"},
+			"fallback": {"host": "backup", "port": 80}
+		}`)
+		c, err := load(t)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Name:     "app",
+			Server:   Server{Host: "localhost", Port: 9090},
+			Tags:     []string{"c"},
+			Labels:   map[string]string{"team": "app", "env": "prod"},
+			Fallback: &Server{Host: "backup", Port: 80},
+		}, c)
+	})
+
+	t.Run("type_mismatch", func(t *testing.T) {
+		t.Setenv("APP_CONFIG_JSON", `{"server": {"port": "http"}}`)
+		_, err := load(t)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, "invalid env var APP_CONFIG_JSON: malformed YAML: "+
+			"yaml: unmarshal errors:\n  line 1: cannot unmarshal !!str `http` "+
+			"into uint16", err.Error())
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		t.Setenv("APP_CONFIG_JSON", `{"server": {"port": 0}}`)
+		_, err := load(t)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `invalid env var APP_CONFIG_JSON: at 1:21: "port" `+
+			`violates validation rule: "gt"`, err.Error())
+	})
+
+	t.Run("validation_location", func(t *testing.T) {
+		t.Setenv("APP_CONFIG_JSON", "{\n  \"tags\": [\"c\"],\n  \"server\": {\"port\": 0}\n}")
+		_, err := load(t)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		var e *yamagiconf.Error
+		require.ErrorAs(t, err, &e)
+		require.Equal(t, yamagiconf.Error{
+			GoPath: "TestConfig.Server.Port", YAMLPath: "server.port",
+			Line: 3, Column: 22, Offset: 40, Err: e.Err,
+		}, *e)
+	})
+
+	t.Run("unknown_field", func(t *testing.T) {
+		t.Setenv("APP_CONFIG_JSON", `{"server": {"timeout": "5s"}}`)
+		_, err := load(t)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+	})
+
+	t.Run("invalid_json", func(t *testing.T) {
+		t.Setenv("APP_CONFIG_JSON", `{server: {port: 9090}}`)
+		_, err := load(t)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, "invalid env var APP_CONFIG_JSON: invalid JSON", err.Error())
+	})
+
+	t.Run("not_object", func(t *testing.T) {
+		t.Setenv("APP_CONFIG_JSON", `[1, 2]`)
+		_, err := load(t)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, "invalid env var APP_CONFIG_JSON: must be a JSON object",
+			err.Error())
+	})
+}
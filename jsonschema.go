@@ -0,0 +1,172 @@
+package yamagiconf
+
+import (
+	"cmp"
+	"encoding"
+	"encoding/json"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) of config type T for
+// validating documents outside of Go, such as in editors and web UIs.
+// Fields become properties named by their yaml struct tags, fields with
+// the validate rule "required" are required and unknown fields are rejected
+// unless WithAllowUnknownFields is used. Descriptions are taken from
+// `doc` struct tags. Integers are limited to the range of their type,
+// time.Duration and types implementing encoding.TextUnmarshaler are strings,
+// maps are objects with additionalProperties and pointers also accept null.
+// Integer types registered with WithEnumMapping or WithIntEnum become
+// enums of their names or values respectively, types implementing Enum
+// become enums of their values.
+// Other validate rules and Validate methods aren't reflected in the schema.
+// Returns the same errors as ValidateType if T is invalid.
+func JSONSchema[T any](opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	tp := reflect.TypeFor[T]()
+	if err := o.validateType(tp); err != nil {
+		return nil, err
+	}
+	s := jsonSchemaOf(o, tp)
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["title"] = getConfigTypeName(tp)
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// jsonSchema is a JSON Schema object. An empty schema accepts any value.
+type jsonSchema = map[string]any
+
+// jsonSchemaOf returns the schema of values of type tp.
+func jsonSchemaOf(o *options, tp reflect.Type) jsonSchema {
+	if e := o.enums[tp]; e != nil {
+		names := slices.SortedFunc(maps.Keys(e.values), func(a, b string) int {
+			return cmp.Or(cmp.Compare(e.values[a], e.values[b]), strings.Compare(a, b))
+		})
+		return jsonSchema{"type": "string", "enum": names}
+	}
+	if e := o.intEnums[tp]; e != nil {
+		return jsonSchema{"type": "integer", "enum": slices.Sorted(maps.Keys(e.values))}
+	}
+	if values, ok := enumValues(tp); ok {
+		if tp.Kind() == reflect.String {
+			return jsonSchema{"type": "string", "enum": values}
+		}
+		numbers := make([]any, len(values))
+		for i, s := range values {
+			v := reflect.New(tp).Elem()
+			// The values were checked by ValidateType already.
+			_ = setFromString(v, s)
+			numbers[i] = v.Interface()
+		}
+		return jsonSchema{"type": "integer", "enum": numbers}
+	}
+	switch {
+	case tp.Kind() == reflect.Pointer:
+		return jsonSchemaNullable(jsonSchemaOf(o, tp.Elem()))
+	case tp == typeTimeDuration ||
+		implementsInterface[encoding.TextUnmarshaler](tp):
+		return jsonSchema{"type": "string"}
+	case implementsInterface[yaml.Unmarshaler](tp):
+		return jsonSchema{}
+	}
+	switch tp.Kind() {
+	case reflect.Bool:
+		return jsonSchema{"type": "boolean"}
+	case reflect.String:
+		return jsonSchema{"type": "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return jsonSchema{
+			"type":    "integer",
+			"minimum": int64(-1) << (tp.Bits() - 1),
+			"maximum": int64(math.MaxInt64) >> (64 - tp.Bits()),
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return jsonSchema{
+			"type":    "integer",
+			"minimum": 0,
+			"maximum": uint64(math.MaxUint64) >> (64 - tp.Bits()),
+		}
+	case reflect.Float32, reflect.Float64:
+		return jsonSchema{"type": "number"}
+	case reflect.Slice:
+		return jsonSchema{"type": "array", "items": jsonSchemaOf(o, tp.Elem())}
+	case reflect.Array:
+		return jsonSchema{
+			"type":     "array",
+			"items":    jsonSchemaOf(o, tp.Elem()),
+			"minItems": tp.Len(),
+			"maxItems": tp.Len(),
+		}
+	case reflect.Map:
+		return jsonSchema{
+			"type":                 "object",
+			"additionalProperties": jsonSchemaOf(o, tp.Elem()),
+		}
+	case reflect.Struct:
+		s := jsonSchema{"type": "object"}
+		properties, required := jsonSchema{}, []string{}
+		jsonSchemaFields(o, tp, properties, &required)
+		s["properties"] = properties
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		if !o.ignoreUnknownFields {
+			s["additionalProperties"] = false
+		}
+		return s
+	}
+	// Interfaces of polymorphic fields.
+	return jsonSchema{}
+}
+
+// jsonSchemaFields adds the schemas of the fields of struct type tp
+// including the fields of inlined embedded structs to properties and
+// appends the names of required fields to required.
+func jsonSchemaFields(
+	o *options, tp reflect.Type, properties jsonSchema, required *[]string,
+) {
+	for i := range tp.NumField() {
+		f := tp.Field(i)
+		yamlTag := getYAMLFieldName(f.Tag)
+		if !f.IsExported() || yamlTag == "-" {
+			continue
+		}
+		if f.Anonymous {
+			t := f.Type
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			jsonSchemaFields(o, t, properties, required)
+			continue
+		}
+		s := jsonSchemaOf(o, f.Type)
+		if doc := f.Tag.Get("doc"); doc != "" {
+			s["description"] = doc
+		}
+		properties[yamlTag] = s
+		if slices.Contains(strings.Split(f.Tag.Get("validate"), ","), "required") {
+			*required = append(*required, yamlTag)
+		}
+	}
+}
+
+// jsonSchemaNullable returns s additionally accepting null.
+func jsonSchemaNullable(s jsonSchema) jsonSchema {
+	if len(s) == 0 {
+		return s // Already accepts null.
+	}
+	if t, ok := s["type"].(string); ok && s["enum"] == nil {
+		s["type"] = []string{t, "null"}
+		return s
+	}
+	return jsonSchema{"anyOf": []jsonSchema{s, {"type": "null"}}}
+}
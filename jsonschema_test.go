@@ -0,0 +1,168 @@
+package yamagiconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	type Level int8
+	type Status int16
+	type Limits struct {
+		Int8   int8   `yaml:"int8"`
+		Uint16 uint16 `yaml:"uint16"`
+		Int64  int64  `yaml:"int64"`
+		Uint64 uint64 `yaml:"uint64"`
+	}
+	type TestConfig struct {
+		Limits   `yaml:",inline"`
+		Bool     bool           `yaml:"bool"`
+		Float    float64        `yaml:"float"`
+		Duration *time.Duration `yaml:"duration"`
+		Time     time.Time      `yaml:"time" validate:"required"`
+		Pair     [2]string      `yaml:"pair"`
+		Level    *Level         `yaml:"level"`
+		Status   Status         `yaml:"status"`
+		Nested   *struct {
+			X bool `yaml:"x"`
+		} `yaml:"nested"`
+		Ignored string `yaml:"-"`
+	}
+
+	b, err := yamagiconf.JSONSchema[TestConfig](
+		yamagiconf.WithEnumMapping(map[string]Level{"debug": 0, "info": 1}),
+		yamagiconf.WithIntEnum[Status](200, 100),
+	)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "TestConfig",
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["time"],
+		"properties": {
+			"int8": {"type": "integer", "minimum": -128, "maximum": 127},
+			"uint16": {"type": "integer", "minimum": 0, "maximum": 65535},
+			"int64": {
+				"type": "integer",
+				"minimum": -9223372036854775808,
+				"maximum": 9223372036854775807
+			},
+			"uint64": {
+				"type": "integer", "minimum": 0, "maximum": 18446744073709551615
+			},
+			"bool": {"type": "boolean"},
+			"float": {"type": "number"},
+			"duration": {"type": ["string", "null"]},
+			"time": {"type": "string"},
+			"pair": {
+				"type": "array", "items": {"type": "string"},
+				"minItems": 2, "maxItems": 2
+			},
+			"level": {"anyOf": [
+				{"type": "string", "enum": ["debug", "info"]},
+				{"type": "null"}
+			]},
+			"status": {"type": "integer", "enum": [100, 200]},
+			"nested": {
+				"type": ["object", "null"],
+				"additionalProperties": false,
+				"properties": {"x": {"type": "boolean"}}
+			}
+		}
+	}`, string(b))
+}
+
+func TestJSONSchemaStable(t *testing.T) {
+	b, err := yamagiconf.JSONSchema[DocsConfig]()
+	require.NoError(t, err)
+	require.Equal(t, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "additionalProperties": false,
+  "properties": {
+    "name": {
+      "description": "Name of the instance.",
+      "type": "string"
+    },
+    "server": {
+      "additionalProperties": false,
+      "description": "HTTP server.",
+      "properties": {
+        "host": {
+          "type": "string"
+        },
+        "timeout": {
+          "description": "Request timeout, 0 disables it.",
+          "type": "string"
+        }
+      },
+      "required": [
+        "host"
+      ],
+      "type": "object"
+    },
+    "tags": {
+      "items": {
+        "type": "string"
+      },
+      "type": "array"
+    },
+    "upstreams": {
+      "additionalProperties": {
+        "additionalProperties": false,
+        "properties": {
+          "host": {
+            "type": "string"
+          },
+          "timeout": {
+            "description": "Request timeout, 0 disables it.",
+            "type": "string"
+          }
+        },
+        "required": [
+          "host"
+        ],
+        "type": "object"
+      },
+      "type": "object"
+    }
+  },
+  "required": [
+    "name"
+  ],
+  "title": "DocsConfig",
+  "type": "object"
+}
+`, string(b))
+
+	b2, err := yamagiconf.JSONSchema[DocsConfig]()
+	require.NoError(t, err)
+	require.Equal(t, string(b), string(b2))
+}
+
+func TestJSONSchemaAllowUnknownFields(t *testing.T) {
+	type TestConfig struct {
+		Name string `yaml:"name"`
+	}
+	b, err := yamagiconf.JSONSchema[TestConfig](yamagiconf.WithAllowUnknownFields())
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "TestConfig",
+		"type": "object",
+		"properties": {"name": {"type": "string"}}
+	}`, string(b))
+}
+
+func TestJSONSchemaErrInvalidType(t *testing.T) {
+	_, err := yamagiconf.JSONSchema[string]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeIllegalRoot)
+
+	_, err = yamagiconf.JSONSchema[struct {
+		Int int `yaml:"int"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeUnsupported)
+}
@@ -0,0 +1,110 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// validateKeyNormalizeField returns an error if f has a `keynormalize` struct
+// tag with unknown normalizations (see the `normalize` struct tag) or f isn't
+// a map with string keys.
+func validateKeyNormalizeField(f reflect.StructField) error {
+	n, ok := f.Tag.Lookup("keynormalize")
+	if !ok {
+		return nil
+	}
+	for _, name := range strings.Split(n, ",") {
+		if _, ok := normalizers[name]; !ok {
+			return fmt.Errorf("%w: unknown normalization %q",
+				ErrTypeInvalidKeyNormalizeTag, name)
+		}
+	}
+	if t := f.Type; t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+		return fmt.Errorf("%w: %s is not a map with string keys",
+			ErrTypeInvalidKeyNormalizeTag, f.Type.String())
+	}
+	return nil
+}
+
+// normalizeMapKeys traverses v and replaces the maps of all fields with
+// a `keynormalize` struct tag with maps with normalized keys.
+// If normalization maps two keys to the same key fn is called for the keys
+// and Go paths of both entries, in sorted order of the original keys, and
+// the error it returns is returned. Assumes that the config type has
+// already been validated.
+func normalizeMapKeys(
+	path string, v reflect.Value, fn func(path, key, pathPrev, keyPrev string) error,
+) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return normalizeMapKeys(path, v.Elem(), fn)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			// Interface values aren't addressable, normalize a copy.
+			val := reflect.New(v.Elem().Type()).Elem()
+			val.Set(v.Elem())
+			if err := normalizeMapKeys(path, val, fn); err != nil {
+				return err
+			}
+			v.Set(val)
+		}
+	case reflect.Struct:
+		tp := v.Type()
+		for i := range tp.NumField() {
+			f := tp.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			path, fv := path+"."+f.Name, v.Field(i)
+			if err := normalizeMapKeys(path, fv, fn); err != nil {
+				return err
+			}
+			n, ok := f.Tag.Lookup("keynormalize")
+			if !ok {
+				continue
+			}
+			if fv.IsNil() {
+				continue
+			}
+			m := reflect.MakeMapWithSize(fv.Type(), fv.Len())
+			origins := make(map[string]reflect.Value, fv.Len())
+			for _, key := range mapKeysSorted(fv) {
+				s := key.String()
+				for _, name := range strings.Split(n, ",") {
+					s = normalizers[name](s)
+				}
+				normalized := reflect.New(fv.Type().Key()).Elem()
+				normalized.SetString(s)
+				if prev, ok := origins[s]; ok {
+					return fn(fmt.Sprintf("%s[%v]", path, key), key.String(),
+						fmt.Sprintf("%s[%v]", path, prev), prev.String())
+				}
+				origins[s] = key
+				m.SetMapIndex(normalized, fv.MapIndex(key))
+			}
+			fv.Set(m)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			path := fmt.Sprintf("%s[%d]", path, i)
+			if err := normalizeMapKeys(path, v.Index(i), fn); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range mapKeysSorted(v) {
+			// Map values aren't addressable, normalize a copy.
+			path := fmt.Sprintf("%s[%v]", path, key)
+			val := reflect.New(v.Type().Elem()).Elem()
+			val.Set(v.MapIndex(key))
+			if err := normalizeMapKeys(path, val, fn); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, val)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyNormalizeTag(t *testing.T) {
+	type Route struct {
+		Headers map[string]string `yaml:"headers" keynormalize:"lower"`
+	}
+	type TestConfig struct {
+		Labels  map[string]uint8  `yaml:"labels" keynormalize:"trim,lower"`
+		Aliases map[string]string `yaml:"aliases" keynormalize:"lower"`
+		Routes  []Route           `yaml:"routes"`
+		Raw     map[string]uint8  `yaml:"raw"`
+	}
+
+	t.Run("normalized", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`labels:
+  Env: 1
+  " Tier ": 2
+aliases:
+  WWW: web
+routes:
+  - headers:
+      X-Request-ID: id
+raw:
+  Env: 1
+`)
+		require.NoError(t, err)
+		require.Equal(t, map[string]uint8{"env": 1, "tier": 2}, c.Labels)
+		require.Equal(t, map[string]string{"www": "web"}, c.Aliases)
+		require.Equal(t, map[string]string{"x-request-id": "id"}, c.Routes[0].Headers)
+		require.Equal(t, map[string]uint8{"Env": 1}, c.Raw)
+
+		c, err = LoadSrc[TestConfig]("labels: {}\naliases: null\nroutes: []\nraw: {}\n")
+		require.NoError(t, err)
+		require.Equal(t, map[string]uint8{}, c.Labels)
+		require.Nil(t, c.Aliases)
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`labels:
+  env: 1
+  ENV: 2
+aliases: null
+routes: []
+raw: {}
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateMapKey)
+		require.Equal(t, `at 3:8: "labels": duplicate map key: `+
+			`"ENV" normalizes to the same key as "env" at 2:8`, err.Error())
+	})
+
+	t.Run("collision_trim", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`labels:
+  " a": 1
+  "a ": 2
+aliases: null
+routes: []
+raw: {}
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateMapKey)
+		require.Equal(t, `at 3:9: "labels": duplicate map key: `+
+			`"a " normalizes to the same key as " a" at 2:9`, err.Error())
+	})
+
+	t.Run("collision_nested", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`labels: {}
+aliases: null
+routes:
+  - headers:
+      Accept: a
+      accept: b
+raw: {}
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateMapKey)
+		require.Equal(t, `at 6:15: "headers": duplicate map key: `+
+			`"accept" normalizes to the same key as "Accept" at 5:15`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidKeyNormalizeTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Field map[string]string `yaml:"field" keynormalize:"upper"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidKeyNormalizeTag)
+	require.Equal(t, `at struct{...}.Field: invalid keynormalize struct tag: `+
+		`unknown normalization "upper"`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field string `yaml:"field" keynormalize:"lower"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidKeyNormalizeTag)
+	require.Equal(t, `at struct{...}.Field: invalid keynormalize struct tag: `+
+		`string is not a map with string keys`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field map[uint8]string `yaml:"field" keynormalize:"lower"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidKeyNormalizeTag)
+	require.Equal(t, `at struct{...}.Field: invalid keynormalize struct tag: `+
+		`map[uint8]string is not a map with string keys`, err.Error())
+}
@@ -0,0 +1,134 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+)
+
+// LazyString is a string value that's resolved from a reference on first
+// access instead of at load, which is useful for expensive to resolve values
+// (like secrets fetched from a secret manager) that may never be used.
+// In the YAML document the field holds the reference, which by default is
+// the name of the env var to read the value from
+// (see WithLazyResolver for custom resolvers).
+// Load only checks that the reference isn't empty.
+//
+// LazyString is safe for concurrent use. The value is resolved once,
+// copies of a loaded LazyString share the resolved value or error.
+type LazyString struct {
+	ref   string
+	state *lazyState
+}
+
+type lazyState struct {
+	once    sync.Once
+	resolve func(ref string) (string, error)
+	value   string
+	err     error
+}
+
+var (
+	_ encoding.TextUnmarshaler = (*LazyString)(nil)
+	_ encoding.TextMarshaler   = LazyString{}
+)
+
+// NewLazyString returns a LazyString referring to ref resolved by resolve.
+// If resolve is nil ref is resolved as the name of an env var.
+func NewLazyString(ref string, resolve func(ref string) (string, error)) LazyString {
+	return LazyString{ref: ref, state: &lazyState{resolve: resolve}}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *LazyString) UnmarshalText(text []byte) error {
+	if len(text) < 1 {
+		return errors.New("empty reference")
+	}
+	*s = NewLazyString(string(text), nil)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+// It returns the reference, not the resolved value.
+func (s LazyString) MarshalText() ([]byte, error) { return []byte(s.ref), nil }
+
+// Ref returns the reference.
+func (s LazyString) Ref() string { return s.ref }
+
+// Get resolves the reference on the first call and returns the value.
+// Any error of the resolution is returned by this and all subsequent calls.
+func (s LazyString) Get() (string, error) {
+	if s.state == nil {
+		// Zero value, there's nothing to share the result with.
+		return resolveEnvRef(s.ref)
+	}
+	s.state.once.Do(func() {
+		resolve := s.state.resolve
+		if resolve == nil {
+			resolve = resolveEnvRef
+		}
+		s.state.value, s.state.err = resolve(s.ref)
+	})
+	return s.state.value, s.state.err
+}
+
+// resolveEnvRef resolves ref as the name of an env var.
+func resolveEnvRef(ref string) (string, error) {
+	return envRefResolver(os.LookupEnv)(ref)
+}
+
+// envRefResolver returns a resolver resolving references as the names of
+// env vars looked up using lookup.
+func envRefResolver(lookup func(key string) (string, bool)) func(ref string) (string, error) {
+	return func(ref string) (string, error) {
+		v, ok := lookup(ref)
+		if !ok {
+			return "", fmt.Errorf("%w %s", ErrEnvMissingVar, ref)
+		}
+		return v, nil
+	}
+}
+
+// WithLazyResolver sets the function LazyString values use to resolve their
+// reference on first access instead of reading the env var named
+// by the reference.
+func WithLazyResolver(resolve func(ref string) (string, error)) Option {
+	return func(o *options) { o.lazyResolver = resolve }
+}
+
+var typeLazyString = reflect.TypeFor[LazyString]()
+
+// setLazyResolvers traverses v and sets resolve as the resolver of
+// all LazyString values that weren't resolved yet.
+func setLazyResolvers(v reflect.Value, resolve func(ref string) (string, error)) {
+	if v.Type() == typeLazyString {
+		if s := v.Interface().(LazyString); s.state != nil {
+			s.state.resolve = resolve
+		}
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			setLazyResolvers(v.Elem(), resolve)
+		}
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				setLazyResolvers(v.Field(i), resolve)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			setLazyResolvers(v.Index(i), resolve)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			setLazyResolvers(iter.Value(), resolve)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package yamagiconf_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLazyString(t *testing.T) {
+	type TestConfig struct {
+		Password yamagiconf.LazyString            `yaml:"password"`
+		Tokens   map[string]yamagiconf.LazyString `yaml:"tokens"`
+		Optional *yamagiconf.LazyString           `yaml:"optional"`
+	}
+	const src = "password: DB_PASSWORD\ntokens:\n  a: TOKEN_A\noptional: null\n"
+
+	t.Run("env", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		require.Equal(t, "DB_PASSWORD", c.Password.Ref())
+
+		// Resolved on first access only.
+		t.Setenv("DB_PASSWORD", "s3cr3t")
+		v, err := c.Password.Get()
+		require.NoError(t, err)
+		require.Equal(t, "s3cr3t", v)
+
+		_, err = c.Tokens["a"].Get()
+		require.ErrorIs(t, err, yamagiconf.ErrEnvMissingVar)
+		require.Equal(t, "missing env var TOKEN_A", err.Error())
+	})
+
+	t.Run("resolve_once", func(t *testing.T) {
+		var calls []string
+		var lock sync.Mutex
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithLazyResolver(func(ref string) (string, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				calls = append(calls, ref)
+				return "value of " + ref, nil
+			}))
+		require.NoError(t, err)
+		require.Empty(t, calls)
+
+		copied := c.Password
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				v, err := copied.Get()
+				require.NoError(t, err)
+				require.Equal(t, "value of DB_PASSWORD", v)
+			}()
+		}
+		wg.Wait()
+		v, err := c.Password.Get()
+		require.NoError(t, err)
+		require.Equal(t, "value of DB_PASSWORD", v)
+		require.Equal(t, []string{"DB_PASSWORD"}, calls)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		errResolve := errors.New("secret manager unavailable")
+		calls := 0
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithLazyResolver(func(ref string) (string, error) {
+				calls++
+				return "", errResolve
+			}))
+		require.NoError(t, err)
+		for range 2 {
+			_, err = c.Tokens["a"].Get()
+			require.ErrorIs(t, err, errResolve)
+		}
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("empty_ref", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("password: ''\ntokens: {}\noptional: null\n")
+		require.Error(t, err)
+		require.Equal(t, `at 1:11: "password" (TestConfig.Password): `+
+			`empty reference`, err.Error())
+	})
+
+	t.Run("marshal", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		b, err := yamagiconf.Marshal(*c)
+		require.NoError(t, err)
+		require.Equal(t, src, string(b))
+	})
+}
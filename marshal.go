@@ -0,0 +1,434 @@
+package yamagiconf
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Marshal serializes config to YAML using the same subset of YAML
+// that Load accepts. Marshal first validates type T using ValidateType.
+//
+// Struct fields are written in declaration order, inlined embedded structs
+// are flattened and fields with the yaml struct tag option "omitempty" are
+// omitted if they're zero following the same rules as gopkg.in/yaml.v3,
+// nil pointers, slices and maps of fields without "omitempty" are written as `null`.
+// The output contains no YAML tags, anchors or aliases and strings that
+// would otherwise be read as other types, such as "null" or "true", are quoted.
+// Loading the output into a zero value of T produces a config equal to config
+// except for fields ignored by YAML (`yaml:"-"`), which are left to env vars
+// and defaults, and except for values whose MarshalText or MarshalYAML
+// isn't reversed by the respective unmarshaler. Load leaves missing fields
+// marked "omitempty" zero.
+//
+// Values implementing encoding.TextMarshaler are written as strings and
+// values implementing yaml.Marshaler are written as returned by MarshalYAML.
+// Floats are formatted according to WithFloatFormat, map entries
+// are sorted by key according to WithMapSortOrder and the names of
+// types registered with WithEnumMapping are written instead of numbers.
+func Marshal[T any](config T, opts ...Option) ([]byte, error) {
+	o := newOptions(opts)
+	if err := o.validateType(reflect.TypeFor[T]()); err != nil {
+		return nil, err
+	}
+	tp := reflect.TypeOf(config)
+	n, err := marshalNode(o, getConfigTypeName(tp), reflect.ValueOf(config))
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(n); err != nil {
+		return nil, fmt.Errorf("encoding yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encoding yaml: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// RedactedValue replaces the values of redacted fields in the output of
+// MarshalRedacted.
+const RedactedValue = "[REDACTED]"
+
+// defaultRedactPattern is returned by DefaultRedactPattern.
+// Words are delimited by changes of case like in Go field names (APIKey)
+// or by '-', '_' or '.' like in yaml struct tags and env var names.
+var defaultRedactPattern = regexp.MustCompile(
+	`(^|[-_.])(passwords?|secrets?|tokens?|keys?)$|` +
+		`(^|[-_.])(PASSWORDS?|SECRETS?|TOKENS?|KEYS?)$|` +
+		`(Passwords?|Secrets?|Tokens?|Keys?)$`,
+)
+
+// DefaultRedactPattern returns the default pattern of WithRedactPattern
+// which matches names whose last word is password, secret, token or key,
+// such as Password, APIKey, api-key or AUTH_SECRET but not Monkey or KeyPath.
+func DefaultRedactPattern() *regexp.Regexp { return defaultRedactPattern }
+
+// MarshalRedacted is similar to Marshal but replaces the values of sensitive
+// fields with RedactedValue, which is useful for logging the effective config.
+// A field is considered sensitive if it has the struct tag `redact:"true"`
+// or `encrypted:"true"` or if either its Go field name, its yaml struct tag or its env var name
+// match the pattern set by WithRedactPattern (DefaultRedactPattern by default).
+// Fields matching the pattern can be excluded using `redact:"false"`.
+// Nil values are not redacted and written as `null`.
+//
+// Keep in mind that the output of MarshalRedacted is not meant to be loaded
+// since the redacted values may not be valid for the type of the field.
+func MarshalRedacted[T any](config T, opts ...Option) ([]byte, error) {
+	return Marshal(config, append(opts, func(o *options) { o.redact = true })...)
+}
+
+// MarshalWithProvenance is similar to MarshalRedacted but annotates fields
+// with line comments like `# from env DB_HOST` describing where their values
+// came from according to the Sources of report returned by LoadWithReport.
+// Fields that aren't structs and have no recorded source are annotated
+// with `# from yaml`. The sources of redacted fields are noted too.
+// A nil report annotates all fields as coming from YAML.
+func MarshalWithProvenance[T any](
+	config T, report *LoadReport, opts ...Option,
+) ([]byte, error) {
+	return Marshal(config, append(opts, func(o *options) {
+		o.redact = true
+		o.provenance = map[string]Provenance{}
+		if report != nil && report.Sources != nil {
+			o.provenance = report.Sources
+		}
+	})...)
+}
+
+// MarshalNonDefault is similar to Marshal but omits all fields whose values
+// equal their defaults, which are the values of their `default` struct tags
+// or the zero value for fields without one. Nested structs with only
+// default values are omitted entirely, which makes the output a minimal
+// document capturing only intentional deviations from the defaults.
+// The values of sequences, maps and pointers are written in full
+// if they differ from their defaults.
+//
+// Keep in mind that Load requires all fields without `default` struct tags
+// to be present, so the output of MarshalNonDefault is not meant to be loaded.
+func MarshalNonDefault[T any](config T, opts ...Option) ([]byte, error) {
+	return Marshal(config, append(opts, func(o *options) { o.omitDefaults = true })...)
+}
+
+// annotateProvenance sets a line comment describing where the value of
+// the field at Go path came from on value node n or on key node
+// if n is a block collection.
+func (o *options) annotateProvenance(path string, f reflect.StructField, key, n *yaml.Node) {
+	if o.provenance == nil {
+		return
+	}
+	p, ok := o.provenance[path]
+	if !ok {
+		t := f.Type
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct {
+			return // The fields are annotated individually.
+		}
+		p = Provenance{Source: SourceYAML}
+	}
+	if n.Kind == yaml.ScalarNode || n.Style&yaml.FlowStyle != 0 {
+		n.LineComment = p.String()
+		return
+	}
+	key.LineComment = p.String()
+}
+
+// isRedacted returns true if struct field f is sensitive and its value
+// should be redacted.
+func (o *options) isRedacted(f reflect.StructField) bool {
+	switch f.Tag.Get("redact") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if f.Tag.Get("encrypted") == "true" {
+		return true
+	}
+	if o.redactPattern == nil {
+		return false
+	}
+	if o.redactPattern.MatchString(f.Name) {
+		return true
+	}
+	if t := getYAMLFieldName(f.Tag); t != "" && o.redactPattern.MatchString(t) {
+		return true
+	}
+	if e := f.Tag.Get("env"); e != "" && o.redactPattern.MatchString(e) {
+		return true
+	}
+	return false
+}
+
+// marshalNode returns the YAML node representing v.
+// Assumes the type of v was validated using ValidateType.
+func marshalNode(o *options, path string, v reflect.Value) (*yaml.Node, error) {
+	tp := v.Type()
+	switch tp.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		if v.IsNil() {
+			return newNullNode(), nil
+		}
+	}
+
+	if m := asIface[yaml.Marshaler](v, false); m != nil {
+		x, err := m.MarshalYAML()
+		if err != nil {
+			return nil, fmt.Errorf("at %s: %w", path, err)
+		}
+		n := new(yaml.Node)
+		if err := n.Encode(x); err != nil {
+			return nil, fmt.Errorf("at %s: %w", path, err)
+		}
+		return n, nil
+	}
+	if m := asIface[encoding.TextMarshaler](v, false); m != nil {
+		t, err := m.MarshalText()
+		if err != nil {
+			return nil, fmt.Errorf("at %s: %w", path, err)
+		}
+		return newStringNode(string(t)), nil
+	}
+	if implementsInterface[encoding.TextUnmarshaler](tp) {
+		return nil, fmt.Errorf("at %s: %w: %s",
+			path, ErrTypeNoTextMarshaler, tp.String())
+	}
+
+	if tp == typeTimeDuration {
+		return newStringNode(time.Duration(v.Int()).String()), nil
+	}
+	if e := o.enums[tp]; e != nil {
+		name, ok := e.names[v.Int()]
+		if !ok {
+			return nil, fmt.Errorf("at %s: %w: %d has no name",
+				path, ErrInvalidEnumValue, v.Int())
+		}
+		return newStringNode(name), nil
+	}
+
+	switch tp.Kind() {
+	case reflect.Interface:
+		if p := o.polymorphic[tp]; p != nil {
+			return marshalPolymorphic(o, p, path, v.Elem())
+		}
+		return marshalNode(o, path, v.Elem())
+	case reflect.Pointer:
+		return marshalNode(o, path, v.Elem())
+	case reflect.Bool:
+		return newScalarNode("!!bool", strconv.FormatBool(v.Bool())), nil
+	case reflect.String:
+		return newStringNode(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return newScalarNode("!!int", strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return newScalarNode("!!int", strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		// The tag is left for the encoder to resolve since depending on
+		// the format whole numbers may be written like integers.
+		f := formatFloat(v.Float(), o.floatFmt, o.floatPrec, tp.Bits())
+		return newScalarNode("", f), nil
+	case reflect.Struct:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if err := marshalStructFields(o, path, v, n); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case reflect.Slice, reflect.Array:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if v.Len() < 1 {
+			n.Style = yaml.FlowStyle
+		}
+		for i := range v.Len() {
+			path := fmt.Sprintf("%s[%d]", path, i)
+			item, err := marshalNode(o, path, v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, item)
+		}
+		return n, nil
+	case reflect.Map:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		if v.Len() < 1 {
+			n.Style = yaml.FlowStyle
+		}
+		keys := mapKeysSorted(v)
+		if o.mapSortOrder == MapSortDesc {
+			slices.Reverse(keys)
+		}
+		for _, k := range keys {
+			path := fmt.Sprintf("%s[%v]", path, k)
+			key, err := marshalNode(o, path, k)
+			if err != nil {
+				return nil, err
+			}
+			value, err := marshalNode(o, path, v.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, key, value)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("at %s: %w: %s", path, ErrTypeUnsupported, tp.String())
+}
+
+// marshalStructFields appends the key and value nodes of all fields of
+// struct v to mapping node n, inlined embedded structs are flattened.
+func marshalStructFields(
+	o *options, path string, v reflect.Value, n *yaml.Node,
+) error {
+	tp := v.Type()
+	for i := range tp.NumField() {
+		f := tp.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		yamlTag := getYAMLFieldName(f.Tag)
+		if yamlTag == "-" {
+			continue // Ignored field.
+		}
+		path := path + "." + f.Name
+		fv := v.Field(i)
+		if f.Anonymous {
+			for fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() != reflect.Struct {
+				continue // Nil embedded struct pointer.
+			}
+			if err := marshalStructFields(o, path, fv, n); err != nil {
+				return err
+			}
+			continue
+		}
+		if yamlTagHasOption(f.Tag, "omitempty") && isZeroValue(fv) {
+			continue
+		}
+		fo := o
+		if o.omitDefaults && !isPlainStruct(fv.Type()) {
+			if isDefaultValue(f, fv) {
+				continue
+			}
+			// Values that differ from their defaults are written in full.
+			c := *o
+			c.omitDefaults = false
+			fo = &c
+		}
+		key := newStringNode(yamlTag)
+		if o.redact && o.isRedacted(f) && !isNil(fv) {
+			value := newStringNode(RedactedValue)
+			o.annotateProvenance(path, f, key, value)
+			n.Content = append(n.Content, key, value)
+			continue
+		}
+		value, err := marshalNode(fo, path, fv)
+		if err != nil {
+			return err
+		}
+		if fo.omitDefaults && len(value.Content) < 1 {
+			continue // Nested struct with only default values.
+		}
+		o.annotateProvenance(path, f, key, value)
+		n.Content = append(n.Content, key, value)
+	}
+	return nil
+}
+
+// isPlainStruct returns true if tp is a struct type that's marshaled
+// field by field.
+func isPlainStruct(tp reflect.Type) bool {
+	return tp.Kind() == reflect.Struct &&
+		!implementsInterface[encoding.TextUnmarshaler](tp) &&
+		!implementsInterface[yaml.Unmarshaler](tp)
+}
+
+// isDefaultValue returns true if v equals the value of the `default` struct tag
+// of field f, or the zero value if f has no `default` struct tag.
+func isDefaultValue(f reflect.StructField, v reflect.Value) bool {
+	d, ok := f.Tag.Lookup("default")
+	if !ok {
+		return v.IsZero()
+	}
+	dv := reflect.New(f.Type).Elem()
+	// The default value was checked by ValidateType already.
+	_ = setFromString(dv, d)
+	return reflect.DeepEqual(v.Interface(), dv.Interface())
+}
+
+// isZeroValue reports whether v is considered zero for
+// the yaml struct tag option "omitempty" following gopkg.in/yaml.v3.
+func isZeroValue(v reflect.Value) bool {
+	if z, ok := v.Interface().(interface{ IsZero() bool }); ok {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return true
+		}
+		return z.IsZero()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() && !isZeroValue(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return v.IsZero()
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+func formatFloat(f float64, format byte, prec, bitSize int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	case math.IsNaN(f):
+		return ".nan"
+	}
+	return strconv.FormatFloat(f, format, prec, bitSize)
+}
+
+func newNullNode() *yaml.Node { return newScalarNode("!!null", "null") }
+
+func newScalarNode(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+// newStringNode returns a string node for s which is quoted if
+// the plain scalar s would be rejected by Load.
+func newStringNode(s string) *yaml.Node {
+	n := new(yaml.Node)
+	n.SetString(s)
+	if n.Style == 0 && (s == "<<" || s == "~" || strings.EqualFold(s, "null")) {
+		// Merge keys and variants of null the encoder doesn't quote.
+		n.Style = yaml.DoubleQuotedStyle
+	}
+	return n
+}
@@ -0,0 +1,87 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validateMaxLenField returns an error if f has a `maxbytes` or `maxrunes`
+// struct tag that isn't a positive integer or f isn't a string, a pointer to
+// a string or a slice or array of those.
+func validateMaxLenField(f reflect.StructField) error {
+	hasTag := false
+	for _, tag := range [...]string{"maxbytes", "maxrunes"} {
+		m, ok := f.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		hasTag = true
+		if n, err := strconv.ParseInt(m, 10, 64); err != nil || n < 1 {
+			return fmt.Errorf("%w: %s %q is not a positive integer",
+				ErrTypeInvalidMaxLenTag, tag, m)
+		}
+	}
+	if !hasTag {
+		return nil
+	}
+	t := f.Type
+	if k := t.Kind(); k == reflect.Slice || k == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String ||
+		implementsInterface[encoding.TextUnmarshaler](t) ||
+		implementsInterface[yaml.Unmarshaler](t) {
+		return fmt.Errorf("%w: %s is not a string",
+			ErrTypeInvalidMaxLenTag, f.Type.String())
+	}
+	return nil
+}
+
+// checkMaxLenField reports the strings of field f that exceed its
+// `maxbytes` or `maxrunes` struct tag. Slice and array items
+// are checked individually.
+func checkMaxLenField(
+	w *fieldWalker, path string, _ reflect.Value, f reflect.StructField, fv reflect.Value,
+) error {
+	maxBytes, okBytes := f.Tag.Lookup("maxbytes")
+	maxRunes, okRunes := f.Tag.Lookup("maxrunes")
+	if !okBytes && !okRunes {
+		return nil
+	}
+	// Already checked by ValidateType.
+	nBytes, _ := strconv.ParseInt(maxBytes, 10, 64)
+	nRunes, _ := strconv.ParseInt(maxRunes, 10, 64)
+	return checkItems(path, fv, func(path string, v reflect.Value) error {
+		return checkMaxLen(path, v, nBytes, nRunes, w.report)
+	})
+}
+
+// checkMaxLen calls fn if string v is longer than maxBytes bytes
+// or maxRunes runes. Zero limits are not checked.
+func checkMaxLen(
+	path string, v reflect.Value, maxBytes, maxRunes int64,
+	fn func(path string, err error) error,
+) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	s := v.String()
+	if n := int64(len(s)); maxBytes > 0 && n > maxBytes {
+		return fn(path, fmt.Errorf("%w: %d bytes, at most %d", ErrTooLong, n, maxBytes))
+	}
+	if n := int64(utf8.RuneCountInString(s)); maxRunes > 0 && n > maxRunes {
+		return fn(path, fmt.Errorf("%w: %d runes, at most %d", ErrTooLong, n, maxRunes))
+	}
+	return nil
+}
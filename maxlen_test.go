@@ -0,0 +1,96 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxLenTags(t *testing.T) {
+	type TestConfig struct {
+		// "héllo" is 5 runes but 6 bytes.
+		Name  string   `yaml:"name" maxbytes:"6"`
+		Title *string  `yaml:"title" maxrunes:"5" env:"TITLE"`
+		Tags  []string `yaml:"tags" maxbytes:"4" maxrunes:"2"`
+	}
+
+	t.Run("within", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("name: héllo\ntitle: ünïcö\ntags: [ab, éé]\n")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Name: "héllo", Title: PtrTo("ünïcö"), Tags: []string{"ab", "éé"},
+		}, *c)
+	})
+
+	t.Run("bytes_exceeded", func(t *testing.T) {
+		// 6 runes but 7 bytes.
+		_, err := LoadSrc[TestConfig]("name: héllo!\ntitle: null\ntags: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrTooLong)
+		require.Equal(t, `at 1:7: "name": too long: 7 bytes, at most 6`, err.Error())
+
+		// 3 runes but 6 bytes.
+		_, err = LoadSrc[TestConfig]("name: ééé\ntitle: null\ntags: []\n")
+		require.NoError(t, err)
+	})
+
+	t.Run("runes_exceeded", func(t *testing.T) {
+		// 6 runes, 12 bytes.
+		_, err := LoadSrc[TestConfig]("name: x\ntitle: ünïcöd\ntags: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrTooLong)
+		require.Equal(t, `at 2:8: "title": too long: 6 runes, at most 5`, err.Error())
+	})
+
+	t.Run("slice_item", func(t *testing.T) {
+		// 2 runes but 6 bytes.
+		_, err := LoadSrc[TestConfig]("name: x\ntitle: null\ntags:\n  - ab\n  - 世界\n")
+		require.ErrorIs(t, err, yamagiconf.ErrTooLong)
+		require.Equal(t, `at 5:5: "tags": too long: 6 bytes, at most 4`, err.Error())
+
+		// 3 runes and 3 bytes.
+		_, err = LoadSrc[TestConfig]("name: x\ntitle: null\ntags: [abc]\n")
+		require.ErrorIs(t, err, yamagiconf.ErrTooLong)
+		require.Equal(t, `at 3:8: "tags": too long: 3 runes, at most 2`, err.Error())
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("TITLE", "ünïcödé")
+		_, err := LoadSrc[TestConfig]("name: x\ntitle: null\ntags: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrTooLong)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.Title: invalid env var TITLE: `+
+			`too long: 7 runes, at most 5`, err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{
+			Name: "ééé!", Tags: []string{"a", "abcd"},
+		}, yamagiconf.WithAllErrors())
+		require.ErrorIs(t, err, yamagiconf.ErrTooLong)
+		require.Equal(t, `at TestConfig.Name: too long: 7 bytes, at most 6
+at TestConfig.Tags[1]: too long: 4 runes, at most 2`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidMaxLenTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Field string `yaml:"field" maxbytes:"0"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidMaxLenTag)
+	require.Equal(t, `at struct{...}.Field: invalid maxbytes or maxrunes struct tag: `+
+		`maxbytes "0" is not a positive integer`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field string `yaml:"field" maxrunes:"ten"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidMaxLenTag)
+	require.Equal(t, `at struct{...}.Field: invalid maxbytes or maxrunes struct tag: `+
+		`maxrunes "ten" is not a positive integer`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field uint16 `yaml:"field" maxbytes:"2"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidMaxLenTag)
+	require.Equal(t, "at struct{...}.Field: invalid maxbytes or maxrunes struct tag: "+
+		"uint16 is not a string", err.Error())
+}
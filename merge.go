@@ -0,0 +1,33 @@
+package yamagiconf
+
+import "gopkg.in/yaml.v3"
+
+// mergeNodes deep-merges override over base and returns the result.
+// Mappings are merged key by key, any other kind of node in override
+// replaces the corresponding node in base entirely.
+// base is modified in place.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return override
+	}
+	for i := 0; i < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		if j := findMappingKey(base, key.Value); j != -1 {
+			base.Content[j+1] = mergeNodes(base.Content[j+1], value)
+			continue
+		}
+		base.Content = append(base.Content, key, value)
+	}
+	return base
+}
+
+// findMappingKey returns the index of the key node with value key
+// in mapping node n or -1 if n has no such key.
+func findMappingKey(n *yaml.Node, key string) int {
+	for i := 0; i < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,82 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// validateMultipleOfField returns an error if f has a `multipleof` struct tag
+// that isn't a positive integer or f isn't an integer, a pointer to an integer
+// or a slice or array of those.
+func validateMultipleOfField(f reflect.StructField) error {
+	m, ok := f.Tag.Lookup("multipleof")
+	if !ok {
+		return nil
+	}
+	if n, err := strconv.ParseInt(m, 10, 64); err != nil || n < 1 {
+		return fmt.Errorf("%w: %q is not a positive integer",
+			ErrTypeInvalidMultipleOfTag, m)
+	}
+	t := f.Type
+	if k := t.Kind(); k == reflect.Slice || k == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if !kindIsInteger(t.Kind()) || t == typeTimeDuration {
+		return fmt.Errorf("%w: %s is not an integer",
+			ErrTypeInvalidMultipleOfTag, f.Type.String())
+	}
+	return nil
+}
+
+func kindIsInteger(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// checkMultipleOfField reports the integers of field f that aren't
+// a multiple of its `multipleof` struct tag. Slice and array items
+// are checked individually.
+func checkMultipleOfField(
+	w *fieldWalker, path string, _ reflect.Value, f reflect.StructField, fv reflect.Value,
+) error {
+	m, ok := f.Tag.Lookup("multipleof")
+	if !ok {
+		return nil
+	}
+	n, _ := strconv.ParseInt(m, 10, 64) // Already checked by ValidateType.
+	return checkItems(path, fv, func(path string, v reflect.Value) error {
+		return checkMultiple(path, v, n, w.report)
+	})
+}
+
+// checkMultiple calls fn if integer v isn't a multiple of n.
+func checkMultiple(
+	path string, v reflect.Value, n int64,
+	fn func(path string, err error) error,
+) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if x := v.Uint(); x%uint64(n) != 0 {
+			return fn(path, fmt.Errorf("value %d %w %d", x, ErrNotMultipleOf, n))
+		}
+	default:
+		if x := v.Int(); x%n != 0 {
+			return fn(path, fmt.Errorf("value %d %w %d", x, ErrNotMultipleOf, n))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipleOfTag(t *testing.T) {
+	type TestConfig struct {
+		BufferSize uint32   `yaml:"buffer-size" multipleof:"4096"`
+		PageSize   *int64   `yaml:"page-size" multipleof:"512" env:"PAGE_SIZE"`
+		Blocks     []uint16 `yaml:"blocks" multipleof:"8"`
+	}
+
+	t.Run("aligned", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("buffer-size: 8192\n" +
+			"page-size: 1024\nblocks: [0, 8, 64]\n")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			BufferSize: 8192, PageSize: PtrTo(int64(1024)), Blocks: []uint16{0, 8, 64},
+		}, *c)
+
+		c, err = LoadSrc[TestConfig]("buffer-size: 4096\n" +
+			"page-size: null\nblocks: []\n")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{BufferSize: 4096, Blocks: []uint16{}}, *c)
+	})
+
+	t.Run("misaligned", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("buffer-size: 4000\n" +
+			"page-size: null\nblocks: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrNotMultipleOf)
+		require.Equal(t, `at 1:14: "buffer-size": value 4000 must be a multiple of 4096`,
+			err.Error())
+	})
+
+	t.Run("misaligned_pointer", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("buffer-size: 4096\n" +
+			"page-size: -100\nblocks: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrNotMultipleOf)
+		require.Equal(t, `at 2:12: "page-size": value -100 must be a multiple of 512`,
+			err.Error())
+	})
+
+	t.Run("misaligned_slice_item", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("buffer-size: 4096\n" +
+			"page-size: null\nblocks:\n  - 16\n  - 12\n")
+		require.ErrorIs(t, err, yamagiconf.ErrNotMultipleOf)
+		require.Equal(t, `at 5:5: "blocks": value 12 must be a multiple of 8`,
+			err.Error())
+	})
+
+	t.Run("misaligned_env", func(t *testing.T) {
+		t.Setenv("PAGE_SIZE", "1000")
+		_, err := LoadSrc[TestConfig]("buffer-size: 4096\n" +
+			"page-size: null\nblocks: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrNotMultipleOf)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.PageSize: invalid env var PAGE_SIZE: `+
+			`value 1000 must be a multiple of 512`, err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{
+			BufferSize: 100, Blocks: []uint16{8, 9},
+		}, yamagiconf.WithAllErrors())
+		require.ErrorIs(t, err, yamagiconf.ErrNotMultipleOf)
+		require.Equal(t, `at TestConfig.BufferSize: value 100 must be a multiple of 4096
+at TestConfig.Blocks[1]: value 9 must be a multiple of 8`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidMultipleOfTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Field int32 `yaml:"field" multipleof:"0"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidMultipleOfTag)
+	require.Equal(t, `at struct{...}.Field: invalid multipleof struct tag: `+
+		`"0" is not a positive integer`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field float64 `yaml:"field" multipleof:"2"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidMultipleOfTag)
+	require.Equal(t, "at struct{...}.Field: invalid multipleof struct tag: "+
+		"float64 is not an integer", err.Error())
+}
@@ -0,0 +1,102 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+	"gopkg.in/yaml.v3"
+)
+
+// normalizers maps the names accepted by the `normalize` struct tag
+// to their implementations.
+var normalizers = map[string]func(string) string{
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"nfc":   norm.NFC.String,
+}
+
+// validateNormalizeField returns an error if f has a `normalize` struct tag
+// with unknown normalizations or f isn't a string or pointer to string.
+func validateNormalizeField(f reflect.StructField) error {
+	n, ok := f.Tag.Lookup("normalize")
+	if !ok {
+		return nil
+	}
+	for _, name := range strings.Split(n, ",") {
+		if _, ok := normalizers[name]; !ok {
+			return fmt.Errorf("%w: unknown normalization %q",
+				ErrTypeInvalidNormalizeTag, name)
+		}
+	}
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String ||
+		implementsInterface[encoding.TextUnmarshaler](t) ||
+		implementsInterface[yaml.Unmarshaler](t) {
+		return fmt.Errorf("%w: %s is not a string",
+			ErrTypeInvalidNormalizeTag, f.Type.String())
+	}
+	return nil
+}
+
+// normalizeStrings traverses v and applies the normalizations of
+// all string fields with a `normalize` struct tag in the order
+// they're listed. Assumes that the config type has already been validated.
+func normalizeStrings(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			normalizeStrings(v.Elem())
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			// Interface values aren't addressable, normalize a copy.
+			val := reflect.New(v.Elem().Type()).Elem()
+			val.Set(v.Elem())
+			normalizeStrings(val)
+			v.Set(val)
+		}
+	case reflect.Struct:
+		tp := v.Type()
+		for i := range tp.NumField() {
+			f := tp.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			fv := v.Field(i)
+			n, ok := f.Tag.Lookup("normalize")
+			if !ok {
+				normalizeStrings(fv)
+				continue
+			}
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			s := fv.String()
+			for _, name := range strings.Split(n, ",") {
+				s = normalizers[name](s)
+			}
+			fv.SetString(s)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			normalizeStrings(v.Index(i))
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			// Map values aren't addressable, normalize a copy.
+			val := reflect.New(v.Type().Elem()).Elem()
+			val.Set(v.MapIndex(key))
+			normalizeStrings(val)
+			v.SetMapIndex(key, val)
+		}
+	}
+}
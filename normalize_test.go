@@ -0,0 +1,107 @@
+package yamagiconf_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+
+	"github.com/stretchr/testify/require"
+)
+
+type LowerValidated string
+
+func (v LowerValidated) Validate() error {
+	if v != "" && v != "admin" && v != "user" {
+		return fmt.Errorf("invalid role: %q", string(v))
+	}
+	return nil
+}
+
+func TestNormalize(t *testing.T) {
+	type User struct {
+		Name string `yaml:"name" normalize:"trim"`
+	}
+	type TestConfig struct {
+		Mode     string          `yaml:"mode" normalize:"trim,lower" validate:"oneof=dev prod"`
+		Role     LowerValidated  `yaml:"role" normalize:"lower"`
+		Ptr      *string         `yaml:"ptr" normalize:"lower"`
+		NilPtr   *string         `yaml:"nil-ptr" normalize:"lower"`
+		NFC      string          `yaml:"nfc" normalize:"nfc"`
+		Users    []User          `yaml:"users"`
+		ByName   map[string]User `yaml:"by-name"`
+		Env      string          `yaml:"env" env:"NORMALIZE_ENV" normalize:"lower"`
+		Verbatim string          `yaml:"verbatim"`
+	}
+
+	t.Setenv("NORMALIZE_ENV", "FROM-ENV")
+	c, err := LoadSrc[TestConfig](`mode: ' PROD '
+role: ADMIN
+ptr: ABC
+nil-ptr: null
+nfc: "e\u0301"
+users:
+  - name: ' alice '
+by-name:
+  bob:
+    name: "bob\t"
+env: x
+verbatim: ' AS IS '
+`)
+	require.NoError(t, err)
+	require.Equal(t, TestConfig{
+		Mode:     "prod",
+		Role:     "admin",
+		Ptr:      PtrTo("abc"),
+		NFC:      "\u00e9",
+		Users:    []User{{Name: "alice"}},
+		ByName:   map[string]User{"bob": {Name: "bob"}},
+		Env:      "from-env",
+		Verbatim: " AS IS ",
+	}, *c)
+}
+
+func TestNormalizeOrder(t *testing.T) {
+	type TestConfig struct {
+		// Without trimming first "oneof" would see the white space.
+		Mode string `yaml:"mode" normalize:"lower" validate:"oneof=dev prod"`
+	}
+	_, err := LoadSrc[TestConfig]("mode: ' PROD'\n")
+	require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+	require.Equal(t, `at 1:7: "mode" violates validation rule: "oneof"`,
+		err.Error())
+}
+
+func TestValidateTypeErrInvalidNormalizeTag(t *testing.T) {
+	t.Run("unknown", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			Name string `yaml:"name" normalize:"trim,upper"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidNormalizeTag)
+		require.Equal(t, `at struct{...}.Name: invalid normalize struct tag: `+
+			`unknown normalization "upper"`, err.Error())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			Name string `yaml:"name" normalize:""`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidNormalizeTag)
+	})
+
+	t.Run("non_string", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			Names []string `yaml:"names" normalize:"lower"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidNormalizeTag)
+		require.Equal(t, `at struct{...}.Names: invalid normalize struct tag: `+
+			`[]string is not a string`, err.Error())
+	})
+
+	t.Run("text_unmarshaler", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			Text TextUnmarshaler `yaml:"text" normalize:"lower"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidNormalizeTag)
+	})
+}
@@ -0,0 +1,559 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// Option configures the behavior of LoadWithOptions, LoadFileWithOptions,
+// LoadReaderWithOptions, LoadFSWithOptions, LoadFileWithLocal, LoadSequence,
+// Validate, Marshal, JSONSchema and ExampleYAML. Options that don't apply to a function are ignored.
+// The zero value of all options is strict and matches the behavior of Load.
+type Option func(*options)
+
+type options struct {
+	rawValidators []func(root *yaml.Node) error
+	floatFmt      byte
+	floatPrec     int
+	mapSortOrder  MapSortOrder
+	redact        bool // Only set by MarshalRedacted.
+	omitDefaults  bool // Only set by MarshalNonDefault.
+	redactPattern *regexp.Regexp
+
+	maxDepth             int // Recursive types are allowed if > 0.
+	depth                int // Current depth of validateYAMLValues.
+	allErrors            bool
+	reportAllMissing     bool
+	defaultProvider      func(goPath string, fieldType reflect.Type) (any, bool)
+	allowEmptyFile       bool
+	allowMultiDoc        bool
+	allowPlatformInts    bool
+	jsonEnvOverride      string                // Env var name, see WithJSONEnvOverride.
+	provenance           map[string]Provenance // See MarshalWithProvenance.
+	hostSectionKey       string                // See WithHostSectionKey.
+	hostname             string                // See WithHostname.
+	baseDir              string                // Directory of the loaded YAML file, see `fileexists`.
+	phases               Phase                 // Defaults to PhaseAll, see WithPhases.
+	quotedNumberCoercion bool
+	extendedBooleans     bool
+	emptyStringAsNull    bool
+	strictAliasTypes     bool
+	indexedEnvOverrides  bool
+	noEnvOverrides       bool
+	envFileSecrets       bool
+	typeValidated        bool                               // Set by LoadWithTypeInfo and LoadDir.
+	yamlFields           map[reflect.Type]map[string]string // Set by LoadWithTypeInfo.
+	ignoreUnknownFields  bool                               // See WithAllowUnknownFields.
+	strictUnmarshalers   bool
+	anchorNamePolicy     func(name string) error
+	allowUnusedAnchors   bool
+	trimTrailingSpace    bool
+	envSources           map[string]string // Go path -> env var name.
+	requireFileMode      bool
+	fileMode             os.FileMode
+	enums                map[reflect.Type]*enumMapping
+	intEnums             map[reflect.Type]*intEnum
+	coerced              map[*yaml.Node]*yaml.Node // See coercible.
+	source               *sourceIndex              // Source being loaded, see errorAt.
+	resolvers            map[string]func(string) (any, error)
+	lazyResolver         func(ref string) (string, error)
+	envLookup            func(key string) (string, bool) // See WithEnvSource.
+	expandEnv            bool
+	expanded             map[*yaml.Node]bool // Nodes expanded by WithExpandEnv.
+	decryptor            func(ciphertext string) (string, error)
+	resolved             map[*yaml.Node]bool // Nodes produced by resolvers and decryptor.
+	polymorphic          map[reflect.Type]*polymorphic
+	polymorphicNodes     map[*yaml.Node]polymorphicNode
+	missing              []error         // See WithReportAllMissing.
+	missingPaths         map[string]bool // Go paths of missing.
+
+	report            *LoadReport // Only set by LoadWithReport.
+	structValidations []structValidation
+	validatorFuncs    []validatorFunc
+}
+
+type structValidation struct {
+	fn    validator.StructLevelFunc
+	types []any
+}
+
+type validatorFunc struct {
+	tag string
+	fn  validator.Func
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		floatFmt:      'g',
+		floatPrec:     -1,
+		redactPattern: defaultRedactPattern,
+		phases:        PhaseAll,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithRawValidator adds fn to the list of validators invoked on the root node
+// of the document right after parsing and before any other checks are made.
+// This allows asserting document-level invariants that can't be expressed
+// in the Go type, such as limiting the number of top-level keys.
+// Errors returned by fn are wrapped with ErrRawValidation.
+// Validators are invoked in the order they were added, fn must not modify root.
+func WithRawValidator(fn func(root *yaml.Node) error) Option {
+	return func(o *options) { o.rawValidators = append(o.rawValidators, fn) }
+}
+
+func (o *options) validateRaw(root *yaml.Node) error {
+	for _, fn := range o.rawValidators {
+		if err := fn(root); err != nil {
+			return fmt.Errorf("%w: %w", ErrRawValidation, err)
+		}
+	}
+	return nil
+}
+
+// WithIndexedEnvOverrides enables overwriting individual entries of maps
+// with an `env` struct tag. Given `env:"FEATURE_FLAGS"`, env var FEATURE_FLAGS_BETA
+// sets the value of key "beta". Map keys are matched against the env var name
+// suffix after upper-casing letters and replacing all characters except ASCII
+// letters and digits with an underscore, so both "beta-ui" and "beta_ui"
+// match FEATURE_FLAGS_BETA_UI. If no key matches then a new entry is added
+// using the lower-cased suffix as key. Values are parsed the same way as
+// for fields with an `env` struct tag.
+// Without this option `env` struct tags on maps have no effect.
+func WithIndexedEnvOverrides() Option {
+	return func(o *options) { o.indexedEnvOverrides = true }
+}
+
+// WithNoEnvOverrides disables `env` struct tags making the loaded config
+// exactly match the document regardless of the environment, which is useful
+// in tests and wherever env tampering is a concern.
+// `env` struct tags are still checked by ValidateType and
+// option "required" of `env` struct tags has no effect.
+func WithNoEnvOverrides() Option {
+	return func(o *options) { o.noEnvOverrides = true }
+}
+
+// WithEnvFileSecrets makes fields with an `env` struct tag read their value
+// from the file referenced by env var <NAME>_FILE if env var NAME isn't set,
+// which is the convention for secrets mounted as files in Docker and
+// Kubernetes. Given `env:"DB_PASSWORD"` and DB_PASSWORD_FILE=/run/secrets/db
+// the contents of /run/secrets/db with a single trailing line break trimmed
+// are used as if they were the value of DB_PASSWORD.
+// If the env var lists fallbacks, NAME_FILE is checked right after NAME.
+// Files that can't be read are reported as ErrEnvInvalidVar.
+func WithEnvFileSecrets() Option {
+	return func(o *options) { o.envFileSecrets = true }
+}
+
+// WithEnvSource makes env vars be looked up using lookup instead of
+// os.LookupEnv, which allows for deterministic tests and loading configs
+// with different environments in the same process without modifying
+// the environment of the process. lookup must report whether the env var
+// is set the same way os.LookupEnv does. This applies to `env` and
+// `envDefault` struct tags, WithJSONEnvOverride, LoadEnvBase64 and
+// LazyString values unless WithLazyResolver is used.
+// Since lookup can't list env vars, WithIndexedEnvOverrides only overwrites
+// existing map entries and doesn't add new ones.
+func WithEnvSource(lookup func(key string) (string, bool)) Option {
+	return func(o *options) { o.envLookup = lookup }
+}
+
+// WithAllowRecursiveTypes permits recursive types such as
+// a menu with submenus which are otherwise rejected with ErrTypeRecursive.
+// To guard against excessive nesting, values nested deeper than maxDepth
+// levels below the root are rejected with ErrYAMLTooDeep.
+// Every struct field, sequence item and map entry is one level deeper
+// than its parent, inlined embedded struct fields are on the level of the parent.
+// Inlining a type into itself is still rejected.
+// maxDepth < 1 disables this option.
+func WithAllowRecursiveTypes(maxDepth int) Option {
+	return func(o *options) { o.maxDepth = maxDepth }
+}
+
+// WithAllowPlatformInts makes ValidateType accept int and uint, which are
+// rejected by default in favor of integer types with specified width,
+// for configs embedding types that can't be changed. Their values are
+// decoded and parsed from env vars with the width of the platform,
+// which is 64 bits on 64-bit platforms.
+func WithAllowPlatformInts() Option {
+	return func(o *options) { o.allowPlatformInts = true }
+}
+
+// WithAllErrors makes Validate return a *MultiError listing all violations
+// instead of just the first one. Load and friends return a *MultiError
+// listing all failing Validate methods and validator struct tag violations
+// with their locations, while all other errors are still reported
+// as soon as they're found.
+func WithAllErrors() Option {
+	return func(o *options) { o.allErrors = true }
+}
+
+// WithReportAllMissing makes Load and friends report all fields missing
+// in the document at once as a *MultiError instead of failing with
+// ErrYAMLMissingConfig on the first one, which saves round trips when
+// adding multiple new required fields. Other errors are still reported
+// as soon as they're found.
+func WithReportAllMissing() Option {
+	return func(o *options) { o.reportAllMissing = true }
+}
+
+// WithDefaultProvider makes Load invoke provider for every field that's
+// missing in the document instead of failing with ErrYAMLMissingConfig.
+// goPath is the Go path of the field, such as "Config.Server.Workers".
+// If provider returns true then the returned value is used as if it was
+// specified in the document and goes through the same validation, otherwise
+// the field is reported missing. This allows for defaults that must be
+// computed at runtime, such as the hostname or the number of CPUs.
+// The value must be assignable to the type of the field,
+// otherwise ErrInvalidDefaultValue is returned.
+func WithDefaultProvider(
+	provider func(goPath string, fieldType reflect.Type) (any, bool),
+) Option {
+	return func(o *options) { o.defaultProvider = provider }
+}
+
+// WithAllowEmptyFile makes Load treat an empty source as an empty mapping
+// (`{}`) instead of returning ErrYAMLEmptyFile. Since all fields are missing
+// in an empty mapping this is mostly useful with WithDefaultProvider,
+// otherwise the first missing field is reported.
+func WithAllowEmptyFile() Option {
+	return func(o *options) { o.allowEmptyFile = true }
+}
+
+// WithAllowMultiDoc makes Load decode only the first document of
+// a multi-document source and ignore all subsequent documents
+// instead of returning ErrYAMLMultidoc. Subsequent documents aren't parsed
+// and all checks, including the checks for redeclared and unused anchors,
+// apply to the first document only.
+func WithAllowMultiDoc() Option {
+	return func(o *options) { o.allowMultiDoc = true }
+}
+
+// WithAllowUnknownFields makes Load ignore keys in the document that don't
+// correspond to any field of the target struct type on all levels, such as
+// keys introduced by a newer version of the config schema.
+// All fields of the target type must still be present.
+// Unknown keys are not checked, so a typo in a key name may go unnoticed.
+func WithAllowUnknownFields() Option {
+	return func(o *options) { o.ignoreUnknownFields = true }
+}
+
+// WithQuotedNumberCoercion makes Load accept quoted numbers such as
+// `port: "8080"` for integer and float fields, which is useful when the
+// document is generated by tools that quote all values.
+// Quoted values are parsed as decimal numbers of the exact type of the field,
+// values that can't be parsed or are out of range are rejected with
+// ErrYAMLInvalidQuotedNumber. time.Duration, types registered with
+// WithEnumMapping and types implementing an unmarshaler interface
+// aren't affected. Unquoted values are handled as usual.
+func WithQuotedNumberCoercion() Option {
+	return func(o *options) { o.quotedNumberCoercion = true }
+}
+
+// WithAllowExtendedBooleans makes Load accept the YAML 1.1 boolean literals
+// `yes`, `on` (true) and `no`, `off` (false) in any case, such as `Yes`
+// or `OFF`, for bool fields, items and map values in addition to `true`
+// and `false`, which is useful when migrating legacy configs.
+// Quoted values aren't accepted.
+func WithAllowExtendedBooleans() Option {
+	return func(o *options) { o.extendedBooleans = true }
+}
+
+// WithEmptyStringAsNull makes Load treat empty strings, double or single
+// quoted, assigned to pointers to strings like null leaving the pointer nil
+// instead of pointing to an empty string, which is useful when the document
+// is generated by tools that can't emit null for unset optional values.
+// Types implementing an unmarshaler interface aren't affected.
+func WithEmptyStringAsNull() Option {
+	return func(o *options) { o.emptyStringAsNull = true }
+}
+
+// WithStrictAliasTypes makes Load reject aliases of scalar anchors used for
+// fields of a different type than the field the anchor is defined on with
+// ErrYAMLAliasTypeMismatch, for example an anchor defined on a string field
+// used for an integer field, which would otherwise decode silently.
+// Integers are accepted by float fields. Without this option mismatches
+// are reported as warnings by LoadWithReport.
+func WithStrictAliasTypes() Option {
+	return func(o *options) { o.strictAliasTypes = true }
+}
+
+// WithStrictUnmarshalers makes empty values (like `field:`) for non-pointer
+// types implementing encoding.TextUnmarshaler or yaml.Unmarshaler an error
+// (ErrYAMLEmptyValueForUnmarshaler). By default, the unmarshaler isn't invoked
+// for empty values and the field is left at its zero value.
+// Explicit empty strings (`field: ""`) are always passed to the unmarshaler.
+func WithStrictUnmarshalers() Option {
+	return func(o *options) { o.strictUnmarshalers = true }
+}
+
+// WithTrimTrailingSpace trims trailing horizontal white space from plain
+// (unquoted) scalar values. The YAML parser already removes trailing ASCII
+// spaces and tabs from plain scalars but keeps other Unicode white space
+// characters such as the no-break space (U+00A0) which are easily introduced
+// when copy-pasting and hard to spot. Quoted and block scalars are left
+// untouched since their white space is considered intentional.
+func WithTrimTrailingSpace() Option {
+	return func(o *options) { o.trimTrailingSpace = true }
+}
+
+// WithAnchorNamePolicy makes Load invoke policy for every anchor defined
+// in the document. If policy returns an error then loading fails with
+// ErrYAMLAnchorName reported at the location of the anchor.
+// By default, any anchor name is accepted.
+func WithAnchorNamePolicy(policy func(name string) error) Option {
+	return func(o *options) { o.anchorNamePolicy = policy }
+}
+
+// WithAllowUnusedAnchors makes Load accept anchors that aren't referenced
+// by any alias instead of failing with ErrYAMLAnchorUnused, which is useful
+// for generated documents defining shared anchors not every document uses.
+// Keep in mind that this weakens the guarantee that every value in the
+// document affects the config, an anchor left behind after removing
+// its aliases goes unnoticed. Anchors must still be unique
+// (ErrYAMLAnchorRedefined) and have a value (ErrYAMLAnchorNoValue).
+func WithAllowUnusedAnchors() Option {
+	return func(o *options) { o.allowUnusedAnchors = true }
+}
+
+// WithRequireFileMode makes LoadFileWithOptions and LoadFileWithLocal
+// return ErrInsecureFileMode if the permission bits of the file grant
+// any permission that mode doesn't, for example a group or world readable file
+// given 0o600. This is similar to how SSH treats private keys and is useful for
+// files containing secrets. The check is skipped on Windows where
+// the permission bits don't reflect access control.
+func WithRequireFileMode(mode os.FileMode) Option {
+	return func(o *options) {
+		o.requireFileMode, o.fileMode = true, mode.Perm()
+	}
+}
+
+// checkFileMode returns ErrInsecureFileMode if the file at path
+// is more permissive than required by WithRequireFileMode.
+func (o *options) checkFileMode(path string) error {
+	if !o.requireFileMode || runtime.GOOS == "windows" {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("reading file %q: %w", path, err)
+	}
+	if perm := info.Mode().Perm(); perm&^o.fileMode != 0 {
+		return fmt.Errorf("%w: file %q has mode %04o, permitted at most %04o",
+			ErrInsecureFileMode, path, perm, o.fileMode)
+	}
+	return nil
+}
+
+// WithStructValidation registers fn as go-playground/validator struct level
+// validation function for types, see validator.Validate.RegisterStructValidation.
+// This allows validating relationships between fields of a struct in one place.
+// Violations reported through validator.StructLevel.ReportError with
+// an empty field name are reported at the location of the struct itself.
+func WithStructValidation(fn validator.StructLevelFunc, types ...any) Option {
+	return func(o *options) {
+		o.structValidations = append(o.structValidations,
+			structValidation{fn: fn, types: types})
+	}
+}
+
+// WithValidatorFunc registers fn as go-playground/validator validation
+// function for tag, see validator.Validate.RegisterValidation.
+// This allows using domain specific rules such as `validate:"semver"`
+// in struct tags, violations are reported like those of built-in rules.
+// Registering a tag that's already defined overrides it.
+// Load and Validate return ErrInvalidValidatorFunc if tag is reserved
+// by the validator, such as "dive" or "omitempty", or if fn is nil.
+func WithValidatorFunc(tag string, fn validator.Func) Option {
+	return func(o *options) {
+		o.validatorFuncs = append(o.validatorFuncs, validatorFunc{tag: tag, fn: fn})
+	}
+}
+
+// MapSortOrder defines the order in which Marshal writes map entries.
+type MapSortOrder int8
+
+const (
+	// MapSortAsc writes map entries in ascending key order (default).
+	// Numeric keys are compared by value, strings lexicographically
+	// and any other keys by their formatted value.
+	MapSortAsc MapSortOrder = iota
+	// MapSortDesc writes map entries in descending key order.
+	MapSortDesc
+)
+
+// WithMapSortOrder sets the order in which Marshal writes map entries.
+// Go maps are unordered, therefore map entries are always sorted
+// to keep the output stable.
+func WithMapSortOrder(order MapSortOrder) Option {
+	return func(o *options) { o.mapSortOrder = order }
+}
+
+// WithRedactPattern sets the pattern MarshalRedacted matches field names,
+// yaml struct tags and env var names against to detect sensitive fields
+// without an explicit `redact` struct tag. Nil disables the detection.
+func WithRedactPattern(pattern *regexp.Regexp) Option {
+	return func(o *options) { o.redactPattern = pattern }
+}
+
+// WithFloatFormat sets the format and precision Marshal uses to write floats
+// as accepted by strconv.FormatFloat. The default is 'g' with precision -1
+// which is the shortest representation that loads back to the exact same value.
+func WithFloatFormat(fmt byte, prec int) Option {
+	return func(o *options) { o.floatFmt, o.floatPrec = fmt, prec }
+}
+
+func (o *options) newValidator() (*validator.Validate, error) {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	for _, s := range o.structValidations {
+		v.RegisterStructValidation(s.fn, s.types...)
+	}
+	for _, f := range o.validatorFuncs {
+		if f.fn == nil {
+			return nil, fmt.Errorf("%w %q: nil function", ErrInvalidValidatorFunc, f.tag)
+		}
+		if err := registerValidation(v, f.tag, f.fn); err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidValidatorFunc, f.tag, err)
+		}
+	}
+	return v, nil
+}
+
+// registerValidation invokes v.RegisterValidation converting panics,
+// which it uses to reject restricted tags, into errors.
+func registerValidation(v *validator.Validate, tag string, fn validator.Func) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return v.RegisterValidation(tag, fn)
+}
+
+// lookupEnv is os.LookupEnv or the source set by WithEnvSource
+// counting lookups when reporting.
+func (o *options) lookupEnv(name string) (string, bool) {
+	if name == "" {
+		return "", false // Field without env tag.
+	}
+	if o.report != nil {
+		o.report.EnvLookups++
+	}
+	if o.envLookup != nil {
+		return o.envLookup(name)
+	}
+	return os.LookupEnv(name)
+}
+
+// lookupEnvVars looks up the env vars in names in order and returns
+// the name and value of the first one that's set. With WithEnvFileSecrets
+// the value of the file referenced by <NAME>_FILE is used if NAME isn't set.
+// The returned error wraps ErrEnvInvalidVar if the file can't be read.
+func (o *options) lookupEnvVars(names []string) (name, value string, ok bool, err error) {
+	for _, name := range names {
+		if value, ok := o.lookupEnv(name); ok {
+			return name, value, true, nil
+		}
+		if !o.envFileSecrets {
+			continue
+		}
+		fileVar := name + "_FILE"
+		filePath, ok := o.lookupEnv(fileVar)
+		if !ok {
+			continue
+		}
+		b, err := os.ReadFile(filePath)
+		if err != nil {
+			return fileVar, "", false, fmt.Errorf("%w %s: reading file %q: %w",
+				ErrEnvInvalidVar, fileVar, filePath, err)
+		}
+		return fileVar, strings.TrimSuffix(string(b), "\n"), true, nil
+	}
+	return "", "", false, nil
+}
+
+// setEnvSource records that the value at Go path was set from envVar.
+func (o *options) setEnvSource(path, envVar string) {
+	if o.envSources == nil {
+		o.envSources = make(map[string]string)
+	}
+	o.envSources[trimPathRoot(path)] = envVar
+	o.setSource(path, Provenance{Source: SourceEnv, EnvVar: envVar})
+}
+
+// setSource records where the value at Go path came from
+// if a report is collected.
+func (o *options) setSource(path string, p Provenance) {
+	if o.report == nil {
+		return
+	}
+	if o.report.Sources == nil {
+		o.report.Sources = make(map[string]Provenance)
+	}
+	o.report.Sources[path] = p
+}
+
+// envSource returns the name of the env var the value at Go path
+// (or validator namespace) was set from if any.
+func (o *options) envSource(path string) (envVar string, ok bool) {
+	envVar, ok = o.envSources[trimPathRoot(path)]
+	return envVar, ok
+}
+
+// trimPathRoot removes the root type name from Go path
+// since it's not necessarily the same in validator namespaces.
+func trimPathRoot(path string) string {
+	if i := strings.IndexAny(path, ".["); i != -1 {
+		return path[i:]
+	}
+	return ""
+}
+
+// now returns the current time when reporting, otherwise the zero time.
+func (o *options) now() time.Time {
+	if o.report == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+type phase int8
+
+const (
+	phaseParse phase = iota
+	phaseValues
+	phaseDecode
+	phaseEnv
+	phaseValidators
+)
+
+// since adds the time elapsed since start to the duration of p when reporting.
+func (o *options) since(p phase, start time.Time) {
+	if o.report == nil {
+		return
+	}
+	d := time.Since(start)
+	switch p {
+	case phaseParse:
+		o.report.TimeParse += d
+	case phaseValues:
+		o.report.TimeValues += d
+	case phaseDecode:
+		o.report.TimeDecode += d
+	case phaseEnv:
+		o.report.TimeEnv += d
+	case phaseValidators:
+		o.report.TimeValidators += d
+	}
+}
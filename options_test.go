@@ -0,0 +1,1746 @@
+package yamagiconf_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWithRawValidator(t *testing.T) {
+	type TestConfig struct {
+		Foo string `yaml:"foo"`
+		Bar string `yaml:"bar"`
+	}
+
+	maxKeys := func(limit int) func(root *yaml.Node) error {
+		return func(root *yaml.Node) error {
+			if l := len(root.Content) / 2; l > limit {
+				return fmt.Errorf("at %d:%d: too many top-level keys: %d",
+					root.Line, root.Column, l)
+			}
+			return nil
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfig
+		var invoked []int
+		err := yamagiconf.LoadWithOptions("foo: a\nbar: b\n", &c,
+			yamagiconf.WithRawValidator(func(root *yaml.Node) error {
+				invoked = append(invoked, 1)
+				return nil
+			}),
+			yamagiconf.WithRawValidator(maxKeys(2)),
+			yamagiconf.WithRawValidator(func(root *yaml.Node) error {
+				invoked = append(invoked, 3)
+				return nil
+			}),
+		)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Foo: "a", Bar: "b"}, c)
+		require.Equal(t, []int{1, 3}, invoked)
+	})
+
+	t.Run("err", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("foo: a\nbar: b\n", &c,
+			yamagiconf.WithRawValidator(maxKeys(1)))
+		require.ErrorIs(t, err, yamagiconf.ErrRawValidation)
+		require.Equal(t, "raw validation: at 1:1: too many top-level keys: 2",
+			err.Error())
+	})
+
+	t.Run("err_before_typed_validation", func(t *testing.T) {
+		// The raw validator is invoked before the typed checks
+		// which would otherwise report the unknown field.
+		errCustom := errors.New("custom")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("foo: a\nbar: b\nbaz: c\n", &c,
+			yamagiconf.WithRawValidator(func(*yaml.Node) error { return errCustom }))
+		require.ErrorIs(t, err, yamagiconf.ErrRawValidation)
+		require.ErrorIs(t, err, errCustom)
+		require.Equal(t, "raw validation: custom", err.Error())
+	})
+
+	t.Run("file", func(t *testing.T) {
+		p := filepath.Join(t.TempDir(), "config.yaml")
+		err := os.WriteFile(p, []byte("foo: a\nbar: b\n"), 0o600)
+		require.NoError(t, err)
+
+		var c TestConfig
+		err = yamagiconf.LoadFileWithOptions(p, &c,
+			yamagiconf.WithRawValidator(maxKeys(1)))
+		require.ErrorIs(t, err, yamagiconf.ErrRawValidation)
+		require.Equal(t, "raw validation: at 1:1: too many top-level keys: 2",
+			err.Error())
+	})
+
+	t.Run("sequence", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader("- foo: a\n  bar: b\n"),
+			func(int, TestConfig) error {
+				t.Fatal("unexpected call")
+				return nil
+			},
+			yamagiconf.WithRawValidator(func(root *yaml.Node) error {
+				require.Equal(t, yaml.SequenceNode, root.Kind)
+				return errors.New("rejected")
+			}))
+		require.ErrorIs(t, err, yamagiconf.ErrRawValidation)
+		require.Equal(t, "raw validation: rejected", err.Error())
+	})
+}
+
+func TestWithIndexedEnvOverrides(t *testing.T) {
+	type TestConfig struct {
+		Flags    map[string]bool    `yaml:"flags" env:"FEATURE_FLAGS"`
+		Limits   map[string]*uint16 `yaml:"limits" env:"LIMITS"`
+		Durs     map[string]string  `yaml:"durs" env:"DURS"`
+		Untagged map[string]string  `yaml:"untagged"`
+	}
+	const src = `
+flags:
+  alpha: false
+  beta-ui: false
+limits:
+  x: 1
+  y: null
+durs: null
+untagged:
+  a: a
+`
+
+	t.Run("ok", func(t *testing.T) {
+		t.Setenv("FEATURE_FLAGS_BETA_UI", "true")
+		t.Setenv("FEATURE_FLAGS_GAMMA", "true")
+		t.Setenv("LIMITS_X", "null")
+		t.Setenv("LIMITS_Y", "42")
+		t.Setenv("DURS_SHORT", "1s")
+		t.Setenv("UNTAGGED_A", "ignored")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithIndexedEnvOverrides())
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{
+			"alpha": false, "beta-ui": true, "gamma": true,
+		}, c.Flags)
+		require.Equal(t, map[string]*uint16{
+			"x": nil, "y": PtrTo(uint16(42)),
+		}, c.Limits)
+		require.Equal(t, map[string]string{"short": "1s"}, c.Durs)
+		require.Equal(t, map[string]string{"a": "a"}, c.Untagged)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("FEATURE_FLAGS_ALPHA", "true")
+		var c TestConfig
+		require.NoError(t, yamagiconf.Load(src, &c))
+		require.Equal(t, map[string]bool{"alpha": false, "beta-ui": false}, c.Flags)
+	})
+
+	t.Run("err_invalid_value", func(t *testing.T) {
+		t.Setenv("FEATURE_FLAGS_ALPHA", "yes")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithIndexedEnvOverrides())
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, "at 3:10: \"flags\" (TestConfig.Flags[alpha]): "+
+			"invalid env var FEATURE_FLAGS_ALPHA: expected bool", err.Error())
+	})
+
+	t.Run("err_ambiguous", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`
+flags:
+  beta-ui: false
+  beta_ui: false
+limits: null
+durs: null
+untagged: null
+`, &c, yamagiconf.WithIndexedEnvOverrides())
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, "at TestConfig.Flags: invalid env var "+
+			`FEATURE_FLAGS_BETA_UI: ambiguous, matches keys "beta-ui" and "beta_ui"`,
+			err.Error())
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		type TestConfig struct {
+			Flags map[string]bool `yaml:"flags" env:"PROD_FLAGS,FLAGS"`
+		}
+		t.Setenv("PROD_FLAGS_A", "true")
+		t.Setenv("FLAGS_A", "false")
+		t.Setenv("FLAGS_B", "true")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("flags: {}\n", &c,
+			yamagiconf.WithIndexedEnvOverrides())
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"a": true, "b": true}, c.Flags)
+	})
+}
+
+func TestValidateTypeErrEnvOnUnsupportedMap(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Map map[int8]string `yaml:"map" env:"MAP"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeEnvVarOnUnsupportedType)
+
+	err = yamagiconf.ValidateType[struct {
+		Map map[string][]string `yaml:"map" env:"MAP"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeEnvVarOnUnsupportedType)
+
+	err = yamagiconf.ValidateType[struct {
+		Map map[string]string `yaml:"map" env:"MAP,required"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeEnvVarOnUnsupportedType)
+	require.Equal(t, "at struct{...}.Map: env var on unsupported type: "+
+		"option required on map[string]string", err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Map map[string]string `yaml:"map" env:"MAP,exclusive"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeEnvVarOnUnsupportedType)
+	require.Equal(t, "at struct{...}.Map: env var on unsupported type: "+
+		"option exclusive on map[string]string", err.Error())
+}
+
+type TestRange struct {
+	Min int32 `yaml:"min"`
+	Max int32 `yaml:"max"`
+}
+
+type TestConfigWithRange struct {
+	Name  string     `yaml:"name"`
+	Range TestRange  `yaml:"range"`
+	Opt   *TestRange `yaml:"opt"`
+}
+
+func validateTestRange(sl validator.StructLevel) {
+	r := sl.Current().Interface().(TestRange)
+	if r.Min > r.Max {
+		sl.ReportError(r, "", "", "minmax", "")
+	}
+}
+
+func TestWithStructValidation(t *testing.T) {
+	opt := yamagiconf.WithStructValidation(validateTestRange, TestRange{})
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfigWithRange
+		err := yamagiconf.LoadWithOptions(`
+name: test
+range:
+  min: 1
+  max: 2
+opt: null
+`, &c, opt)
+		require.NoError(t, err)
+		require.Equal(t, TestRange{Min: 1, Max: 2}, c.Range)
+	})
+
+	t.Run("err", func(t *testing.T) {
+		var c TestConfigWithRange
+		err := yamagiconf.LoadWithOptions(`
+name: test
+range:
+  min: 3
+  max: 2
+opt: null
+`, &c, opt)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 4:3: "range" violates validation rule: "minmax"`,
+			err.Error())
+	})
+
+	t.Run("err_pointer", func(t *testing.T) {
+		var c TestConfigWithRange
+		err := yamagiconf.LoadWithOptions(`
+name: test
+range:
+  min: 1
+  max: 2
+opt:
+  min: 5
+  max: 4
+`, &c, opt)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 7:3: "opt" violates validation rule: "minmax"`,
+			err.Error())
+	})
+
+	t.Run("err_field", func(t *testing.T) {
+		var c TestConfigWithRange
+		err := yamagiconf.LoadWithOptions(`
+name: test
+range:
+  min: 3
+  max: 2
+opt: null
+`, &c, yamagiconf.WithStructValidation(func(sl validator.StructLevel) {
+			r := sl.Current().Interface().(TestRange)
+			if r.Min > r.Max {
+				sl.ReportError(r.Max, "Max", "Max", "gtefield", "Min")
+			}
+		}, TestRange{}))
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 5:8: "max" violates validation rule: "gtefield"`,
+			err.Error())
+	})
+
+	t.Run("err_root", func(t *testing.T) {
+		var c TestConfigWithRange
+		err := yamagiconf.LoadWithOptions(`
+name: ""
+range:
+  min: 1
+  max: 2
+opt: null
+`, &c, yamagiconf.WithStructValidation(func(sl validator.StructLevel) {
+			c := sl.Current().Interface().(TestConfigWithRange)
+			if c.Name == "" && c.Opt == nil {
+				sl.ReportError(c, "", "", "nameoropt", "")
+			}
+		}, TestConfigWithRange{}))
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 2:1: violates validation rule: "nameoropt"`,
+			err.Error())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var c TestConfigWithRange
+		err := yamagiconf.Load(`
+name: test
+range:
+  min: 3
+  max: 2
+opt: null
+`, &c)
+		require.NoError(t, err)
+	})
+}
+
+func TestWithValidatorFunc(t *testing.T) {
+	type Server struct {
+		Version string `yaml:"version" validate:"semver"`
+	}
+	type TestConfig struct {
+		Server  Server   `yaml:"server"`
+		Phones  []string `yaml:"phones" validate:"dive,phone"`
+		Version string   `yaml:"version" validate:"required,semver"`
+	}
+	isPhone := func(fl validator.FieldLevel) bool {
+		return strings.HasPrefix(fl.Field().String(), "+")
+	}
+	isSemver := func(fl validator.FieldLevel) bool {
+		return strings.Count(fl.Field().String(), ".") == 2
+	}
+	opts := []yamagiconf.Option{
+		yamagiconf.WithValidatorFunc("phone", isPhone),
+		yamagiconf.WithValidatorFunc("semver", isSemver),
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`
+server:
+  version: 1.2.3
+phones: ["+41000000000"]
+version: 2.0.0
+`, &c, opts...)
+		require.NoError(t, err)
+		require.NoError(t, yamagiconf.Validate(c, opts...))
+	})
+
+	t.Run("err", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`
+server:
+  version: 1.2
+phones: ["+41000000000"]
+version: 2.0.0
+`, &c, opts...)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 3:12: "version" violates validation rule: "semver"`,
+			err.Error())
+	})
+
+	t.Run("err_dive", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`
+server:
+  version: 1.2.3
+phones: ["+41000000000", "0000"]
+version: 2.0.0
+`, &c, opts...)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 4:26: "phones" violates validation rule: "phone"`,
+			err.Error())
+	})
+
+	t.Run("err_validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{
+			Server:  Server{Version: "1"},
+			Version: "2.0.0",
+		}, opts...)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Server.Version: `+
+			`violates validation rule: "semver"`, err.Error())
+	})
+
+	t.Run("err_reserved_tag", func(t *testing.T) {
+		type TestConfig struct {
+			Name string `yaml:"name"`
+		}
+		err := yamagiconf.Validate(TestConfig{},
+			yamagiconf.WithValidatorFunc("dive", isSemver))
+		require.ErrorIs(t, err, yamagiconf.ErrInvalidValidatorFunc)
+		require.Equal(t, `invalid validator function "dive": `+
+			`Tag 'dive' either contains restricted characters or is the same `+
+			`as a restricted tag needed for normal operation`, err.Error())
+	})
+
+	t.Run("err_nil", func(t *testing.T) {
+		type TestConfig struct {
+			Name string `yaml:"name"`
+		}
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("name: x\n", &c,
+			yamagiconf.WithValidatorFunc("semver", nil))
+		require.ErrorIs(t, err, yamagiconf.ErrInvalidValidatorFunc)
+		require.Equal(t, `invalid validator function "semver": nil function`,
+			err.Error())
+	})
+}
+
+// NonEmptyTextUnmarshaler rejects empty input.
+type NonEmptyTextUnmarshaler struct{ Str string }
+
+var ErrEmptyText = errors.New("empty text")
+
+func (u *NonEmptyTextUnmarshaler) UnmarshalText(t []byte) error {
+	if len(t) == 0 {
+		return ErrEmptyText
+	}
+	u.Str = string(t)
+	return nil
+}
+
+func TestWithStrictUnmarshalers(t *testing.T) {
+	type TestConfig struct {
+		Text    NonEmptyTextUnmarshaler  `yaml:"text"`
+		TextPtr *NonEmptyTextUnmarshaler `yaml:"text-ptr"`
+		YAML    YAMLUnmarshaler          `yaml:"yaml"`
+	}
+
+	t.Run("default_empty_is_zero", func(t *testing.T) {
+		// UnmarshalText isn't invoked for empty values.
+		var c TestConfig
+		err := yamagiconf.Load("text:\ntext-ptr:\nyaml:\n", &c)
+		require.NoError(t, err)
+		require.Zero(t, c)
+	})
+
+	t.Run("default_empty_string", func(t *testing.T) {
+		// UnmarshalText is invoked for explicit empty strings.
+		var c TestConfig
+		err := yamagiconf.Load("text: ''\ntext-ptr:\nyaml:\n", &c)
+		require.ErrorIs(t, err, ErrEmptyText)
+		require.Equal(t, `at 1:7: "text" (TestConfig.Text): empty text`, err.Error())
+	})
+
+	t.Run("strict_ok", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("text: x\ntext-ptr:\nyaml: y\n", &c,
+			yamagiconf.WithStrictUnmarshalers())
+		require.NoError(t, err)
+		require.Equal(t, "x", c.Text.Str)
+		require.Nil(t, c.TextPtr)
+	})
+
+	t.Run("strict_text_unmarshaler", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("text:\ntext-ptr:\nyaml: y\n", &c,
+			yamagiconf.WithStrictUnmarshalers())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyValueForUnmarshaler)
+		require.Equal(t, `at 1:6: "text" (TestConfig.Text): empty value for `+
+			`non-pointer type implementing an unmarshaler interface`, err.Error())
+	})
+
+	t.Run("strict_yaml_unmarshaler", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("text: x\ntext-ptr:\nyaml:\n", &c,
+			yamagiconf.WithStrictUnmarshalers())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyValueForUnmarshaler)
+		require.Equal(t, `at 3:6: "yaml" (TestConfig.YAML): empty value for `+
+			`non-pointer type implementing an unmarshaler interface`, err.Error())
+	})
+
+	t.Run("strict_map_value", func(t *testing.T) {
+		var c struct {
+			Map map[string]NonEmptyTextUnmarshaler `yaml:"map"`
+		}
+		err := yamagiconf.LoadWithOptions("map:\n  a: x\n  b:\n", &c,
+			yamagiconf.WithStrictUnmarshalers())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyValueForUnmarshaler)
+		require.Equal(t, `at 3:5: "map" (struct{...}.Map["b"]): empty value for `+
+			`non-pointer type implementing an unmarshaler interface`, err.Error())
+	})
+}
+
+func TestWithAnchorNamePolicy(t *testing.T) {
+	type TestConfig struct {
+		Foo string   `yaml:"foo"`
+		Bar string   `yaml:"bar"`
+		Baz []string `yaml:"baz"`
+	}
+	errNotKebab := errors.New("must be kebab-case")
+	kebabCase := func(name string) error {
+		for _, r := range name {
+			if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+				return errNotKebab
+			}
+		}
+		return nil
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var names []string
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`
+foo: &some-value x
+bar: *some-value
+baz:
+  - &item-1 y
+  - *item-1
+`, &c, yamagiconf.WithAnchorNamePolicy(func(name string) error {
+			names = append(names, name)
+			return kebabCase(name)
+		}))
+		require.NoError(t, err)
+		require.Equal(t, []string{"some-value", "item-1"}, names)
+	})
+
+	t.Run("err", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`
+foo: &some-value x
+bar: *some-value
+baz:
+  - &Item_1 y
+  - *Item_1
+`, &c, yamagiconf.WithAnchorNamePolicy(kebabCase))
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLAnchorName)
+		require.ErrorIs(t, err, errNotKebab)
+		require.Equal(t, `at 5:5: anchor "Item_1": `+
+			`anchor name rejected by policy: must be kebab-case`, err.Error())
+	})
+
+	t.Run("default", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.Load(`
+foo: &Some_Value x
+bar: *Some_Value
+baz: []
+`, &c)
+		require.NoError(t, err)
+	})
+}
+
+func TestWithAllowUnusedAnchors(t *testing.T) {
+	type TestConfig struct {
+		Foo string   `yaml:"foo"`
+		Bar string   `yaml:"bar"`
+		Baz []string `yaml:"baz"`
+	}
+	load := func(src string) error {
+		var c TestConfig
+		return yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithAllowUnusedAnchors())
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`
+foo: &unused x
+bar: &used y
+baz: [*used]
+`, &c, yamagiconf.WithAllowUnusedAnchors())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Foo: "x", Bar: "y", Baz: []string{"y"}}, c)
+	})
+
+	t.Run("err_redefined", func(t *testing.T) {
+		err := load("foo: &a x\nbar: &a y\nbaz: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLAnchorRedefined)
+	})
+
+	t.Run("err_no_value", func(t *testing.T) {
+		type TestConfig struct {
+			Ptr *string `yaml:"ptr"`
+		}
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("ptr: &a\n", &c,
+			yamagiconf.WithAllowUnusedAnchors())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLAnchorNoValue)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("foo: &unused x\nbar: y\nbaz: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLAnchorUnused)
+		require.Equal(t, `at 1:6: anchor "unused": `+
+			`yaml anchors must be referenced at least once`, err.Error())
+	})
+}
+
+func TestWithTrimTrailingSpace(t *testing.T) {
+	type TestConfig struct {
+		Plain        string   `yaml:"plain"`
+		PlainASCII   string   `yaml:"plain-ascii"`
+		SingleQuoted string   `yaml:"single-quoted"`
+		DoubleQuoted string   `yaml:"double-quoted"`
+		Literal      string   `yaml:"literal"`
+		Int          int32    `yaml:"int"`
+		Ptr          *string  `yaml:"ptr"`
+		List         []string `yaml:"list"`
+	}
+	const src = "plain: abc\u00a0\u3000\n" +
+		"plain-ascii: abc \t \n" +
+		"single-quoted: 'abc\u00a0 '\n" +
+		"double-quoted: \"abc\u00a0 \"\n" +
+		"literal: |\n  abc\u00a0\n" +
+		"int: 42\u00a0\n" +
+		"ptr: null\u00a0\n" +
+		"list: [a\u00a0, b]\n"
+
+	t.Run("enabled", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithTrimTrailingSpace())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Plain:        "abc",
+			PlainASCII:   "abc",
+			SingleQuoted: "abc\u00a0 ",
+			DoubleQuoted: "abc\u00a0 ",
+			Literal:      "abc\u00a0\n",
+			Int:          42,
+			List:         []string{"a", "b"},
+		}, c)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		type TestConfig struct {
+			Plain      string `yaml:"plain"`
+			PlainASCII string `yaml:"plain-ascii"`
+		}
+		var c TestConfig
+		err := yamagiconf.Load("plain: abc\u00a0\nplain-ascii: abc \t \n", &c)
+		require.NoError(t, err)
+		// Trailing ASCII white space is always removed by the parser.
+		require.Equal(t, TestConfig{Plain: "abc\u00a0", PlainASCII: "abc"}, c)
+	})
+}
+
+func TestWithRequireFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permission bits don't apply on windows")
+	}
+	type TestConfig struct {
+		Secret string `yaml:"secret"`
+	}
+	write := func(t *testing.T, name string, mode os.FileMode) string {
+		t.Helper()
+		p := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(p, []byte("secret: x\n"), mode))
+		require.NoError(t, os.Chmod(p, mode)) // Ignore umask.
+		return p
+	}
+
+	for _, td := range []struct {
+		mode  os.FileMode
+		allow bool
+	}{
+		{mode: 0o600, allow: true},
+		{mode: 0o400, allow: true},
+		{mode: 0o640, allow: false},
+		{mode: 0o604, allow: false},
+		{mode: 0o644, allow: false},
+		{mode: 0o700, allow: false},
+	} {
+		t.Run(fmt.Sprintf("%04o", td.mode), func(t *testing.T) {
+			p := write(t, "config.yaml", td.mode)
+			var c TestConfig
+			err := yamagiconf.LoadFileWithOptions(p, &c,
+				yamagiconf.WithRequireFileMode(0o600))
+			if td.allow {
+				require.NoError(t, err)
+				require.Equal(t, "x", c.Secret)
+				return
+			}
+			require.ErrorIs(t, err, yamagiconf.ErrInsecureFileMode)
+			require.Equal(t, fmt.Sprintf("file permissions too permissive: "+
+				"file %q has mode %04o, permitted at most 0600", p, td.mode),
+				err.Error())
+		})
+	}
+
+	t.Run("without_option", func(t *testing.T) {
+		p := write(t, "config.yaml", 0o644)
+		var c TestConfig
+		require.NoError(t, yamagiconf.LoadFile(p, &c))
+	})
+
+	t.Run("local", func(t *testing.T) {
+		p := write(t, "config.yaml", 0o600)
+		local := filepath.Join(filepath.Dir(p), "config.local.yaml")
+		require.NoError(t, os.WriteFile(local, []byte("secret: y\n"), 0o644))
+		require.NoError(t, os.Chmod(local, 0o644))
+		var c TestConfig
+		err := yamagiconf.LoadFileWithLocal(p, &c,
+			yamagiconf.WithRequireFileMode(0o600))
+		require.ErrorIs(t, err, yamagiconf.ErrInsecureFileMode)
+
+		require.NoError(t, os.Chmod(local, 0o600))
+		err = yamagiconf.LoadFileWithLocal(p, &c,
+			yamagiconf.WithRequireFileMode(0o600))
+		require.NoError(t, err)
+		require.Equal(t, "y", c.Secret)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		var c TestConfig
+		p := filepath.Join(t.TempDir(), "missing.yaml")
+		err := yamagiconf.LoadFileWithOptions(p, &c,
+			yamagiconf.WithRequireFileMode(0o600))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestWithNoEnvOverrides(t *testing.T) {
+	type TestConfig struct {
+		Host  string          `yaml:"host" env:"HOST"`
+		Port  *uint16         `yaml:"port" env:"PORT,required"`
+		Flags map[string]bool `yaml:"flags" env:"FLAGS"`
+	}
+	t.Setenv("HOST", "from-env")
+	t.Setenv("FLAGS_A", "true")
+	const src = "host: from-file\nport: 80\nflags:\n  a: false\n"
+
+	var c TestConfig
+	err := yamagiconf.LoadWithOptions(src, &c,
+		yamagiconf.WithNoEnvOverrides(), yamagiconf.WithIndexedEnvOverrides())
+	require.NoError(t, err)
+	require.Equal(t, TestConfig{
+		Host:  "from-file",
+		Port:  PtrTo(uint16(80)),
+		Flags: map[string]bool{"a": false},
+	}, c)
+
+	t.Run("type_still_checked", func(t *testing.T) {
+		type TestConfig struct {
+			Host string `yaml:"host" env:"host"`
+		}
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("host: x\n", &c,
+			yamagiconf.WithNoEnvOverrides())
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnvTag)
+	})
+}
+
+type Menu struct {
+	Title string `yaml:"title" validate:"required"`
+	Items []Menu `yaml:"items"`
+	Next  *Menu  `yaml:"next"`
+}
+
+func TestWithEnvFileSecrets(t *testing.T) {
+	type TestConfig struct {
+		Password string            `yaml:"password" env:"PROD_PASSWORD,PASSWORD"`
+		Port     *uint16           `yaml:"port" env:"PORT"`
+		Tokens   map[string]string `yaml:"tokens" env:"TOKENS"`
+	}
+	const src = "password: from-file\nport: null\ntokens: {}\n"
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+		return p
+	}
+	load := func(opts ...yamagiconf.Option) (TestConfig, error) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, append(opts,
+			yamagiconf.WithEnvFileSecrets(), yamagiconf.WithIndexedEnvOverrides())...)
+		return c, err
+	}
+
+	t.Run("file", func(t *testing.T) {
+		t.Setenv("PASSWORD_FILE", writeFile("password", "secret\n\n"))
+		t.Setenv("PORT_FILE", writeFile("port", "8080\n"))
+		t.Setenv("TOKENS_A_FILE", writeFile("token", "token-a"))
+		c, err := load()
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Password: "secret\n", // Only a single line break is trimmed.
+			Port:     PtrTo(uint16(8080)),
+			Tokens:   map[string]string{"a": "token-a"},
+		}, c)
+	})
+
+	t.Run("env_var_takes_precedence", func(t *testing.T) {
+		t.Setenv("PASSWORD", "from-env")
+		t.Setenv("PASSWORD_FILE", writeFile("password", "secret"))
+		c, err := load()
+		require.NoError(t, err)
+		require.Equal(t, "from-env", c.Password)
+	})
+
+	t.Run("fallback_order", func(t *testing.T) {
+		t.Setenv("PROD_PASSWORD_FILE", writeFile("password", "secret"))
+		t.Setenv("PASSWORD", "from-env")
+		c, err := load()
+		require.NoError(t, err)
+		require.Equal(t, "secret", c.Password)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Setenv("PASSWORD_FILE", writeFile("password", "secret"))
+		var c TestConfig
+		require.NoError(t, yamagiconf.Load(src, &c))
+		require.Equal(t, "from-file", c.Password)
+	})
+
+	t.Run("err_read", func(t *testing.T) {
+		p := filepath.Join(dir, "missing")
+		t.Setenv("PASSWORD_FILE", p)
+		_, err := load()
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.ErrorIs(t, err, os.ErrNotExist)
+		require.Equal(t, `at 1:11: "password" (TestConfig.Password): `+
+			`invalid env var PASSWORD_FILE: reading file "`+p+`": `+
+			`open `+p+`: no such file or directory`, err.Error())
+	})
+
+	t.Run("err_invalid", func(t *testing.T) {
+		t.Setenv("PORT_FILE", writeFile("port", "x"))
+		_, err := load()
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at 2:7: "port" (TestConfig.Port): `+
+			`invalid env var PORT_FILE: expected uint16: `+
+			`strconv.ParseUint: parsing "x": invalid syntax`, err.Error())
+	})
+}
+
+func TestWithEnvSource(t *testing.T) {
+	type TestConfig struct {
+		Host     string                `yaml:"host" env:"HOST"`
+		Port     *uint16               `yaml:"port" env:"PORT,required"`
+		Debug    bool                  `yaml:"-" env:"DEBUG" envDefault:"true"`
+		Password yamagiconf.LazyString `yaml:"password"`
+	}
+	const src = "host: from-file\nport: null\npassword: SECRET\n"
+	// The process environment must not be used.
+	t.Setenv("HOST", "from-process")
+	t.Setenv("DEBUG", "false")
+	t.Setenv("SECRET", "from-process")
+
+	env := func(vars map[string]string) yamagiconf.Option {
+		return yamagiconf.WithEnvSource(func(key string) (string, bool) {
+			v, ok := vars[key]
+			return v, ok
+		})
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var a, b TestConfig
+		err := yamagiconf.LoadWithOptions(src, &a, env(map[string]string{
+			"HOST": "a", "PORT": "1", "SECRET": "secret-a",
+		}))
+		require.NoError(t, err)
+		err = yamagiconf.LoadWithOptions(src, &b, env(map[string]string{
+			"PORT": "2", "DEBUG": "false", "SECRET": "secret-b",
+		}))
+		require.NoError(t, err)
+
+		require.Equal(t, "a", a.Host)
+		require.Equal(t, PtrTo(uint16(1)), a.Port)
+		require.True(t, a.Debug)
+		password, err := a.Password.Get()
+		require.NoError(t, err)
+		require.Equal(t, "secret-a", password)
+
+		require.Equal(t, "from-file", b.Host)
+		require.Equal(t, PtrTo(uint16(2)), b.Port)
+		require.False(t, b.Debug)
+		password, err = b.Password.Get()
+		require.NoError(t, err)
+		require.Equal(t, "secret-b", password)
+	})
+
+	t.Run("err_required", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, env(nil))
+		require.ErrorIs(t, err, yamagiconf.ErrEnvMissingVar)
+		require.Equal(t, "at TestConfig.Port: missing env var PORT", err.Error())
+	})
+
+	t.Run("indexed", func(t *testing.T) {
+		type TestConfig struct {
+			Flags map[string]bool `yaml:"flags" env:"FLAGS"`
+		}
+		t.Setenv("FLAGS_C", "true")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("flags:\n  a: false\n  b: false\n", &c,
+			yamagiconf.WithIndexedEnvOverrides(), env(map[string]string{
+				"FLAGS_A": "true", "FLAGS_D": "true",
+			}))
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"a": true, "b": false}, c.Flags)
+	})
+
+	t.Run("base64", func(t *testing.T) {
+		type TestConfig struct {
+			Host string `yaml:"host"`
+		}
+		var c TestConfig
+		err := yamagiconf.LoadEnvBase64("CONFIG_B64", &c, env(map[string]string{
+			"CONFIG_B64": "aG9zdDogZXhhbXBsZS5jb20K", // host: example.com
+		}))
+		require.NoError(t, err)
+		require.Equal(t, "example.com", c.Host)
+	})
+}
+
+func TestWithAllowRecursiveTypes(t *testing.T) {
+	type TestConfig struct {
+		Menu Menu `yaml:"menu"`
+	}
+	const src = `menu:
+  title: root
+  next: null
+  items:
+    - title: file
+      next: null
+      items:
+        - title: open
+          items: []
+          next: null
+    - title: edit
+      items: []
+      next:
+        title: edit2
+        items: []
+        next: null
+`
+
+	t.Run("disallowed_by_default", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.Load(src, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeRecursive)
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		// The deepest value is the title of "open" at depth 6:
+		// Menu, Items, [0], Items, [0], Title.
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithAllowRecursiveTypes(6))
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Menu: Menu{
+			Title: "root",
+			Items: []Menu{
+				{Title: "file", Items: []Menu{{Title: "open", Items: []Menu{}}}},
+				{Title: "edit", Items: []Menu{}, Next: &Menu{
+					Title: "edit2", Items: []Menu{},
+				}},
+			},
+		}}, c)
+
+		b, err := yamagiconf.Marshal(c, yamagiconf.WithAllowRecursiveTypes(6))
+		require.NoError(t, err)
+		var c2 TestConfig
+		err = yamagiconf.LoadWithOptions(b, &c2,
+			yamagiconf.WithAllowRecursiveTypes(6))
+		require.NoError(t, err)
+		require.Equal(t, c, c2)
+	})
+
+	t.Run("err_too_deep", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithAllowRecursiveTypes(5))
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLTooDeep)
+		require.Equal(t, `at 8:18: "title" (TestConfig.Menu.Items[0].Items[0].Title): `+
+			`nesting too deep: exceeds maximum depth 5`, err.Error())
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`menu:
+  title: root
+  items: []
+  next:
+    title: ''
+    items: []
+    next: null
+`, &c, yamagiconf.WithAllowRecursiveTypes(6))
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 5:12: "title" violates validation rule: "required"`,
+			err.Error())
+	})
+
+	t.Run("err_recursive_inline", func(t *testing.T) {
+		type Node struct {
+			*Node `yaml:",inline"`
+			Name  string `yaml:"name"`
+		}
+		type TestConfig struct {
+			Node Node `yaml:"node"`
+		}
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("node:\n  name: x\n", &c,
+			yamagiconf.WithAllowRecursiveTypes(6))
+		require.ErrorIs(t, err, yamagiconf.ErrTypeRecursive)
+		require.Equal(t, "at TestConfig.Node.Node: recursive type", err.Error())
+	})
+}
+
+func TestWithDefaultProvider(t *testing.T) {
+	type Server struct {
+		Host    string  `yaml:"host" validate:"required"`
+		Workers int32   `yaml:"workers" validate:"min=1"`
+		Limit   *uint16 `yaml:"limit"`
+	}
+	type TestConfig struct {
+		Server Server `yaml:"server"`
+	}
+	var calls []string
+	provider := func(goPath string, fieldType reflect.Type) (any, bool) {
+		calls = append(calls, goPath+" "+fieldType.String())
+		switch goPath {
+		case "TestConfig.Server.Host":
+			return "host-1", true
+		case "TestConfig.Server.Workers":
+			return int32(8), true
+		case "TestConfig.Server.Limit":
+			return nil, true
+		}
+		return nil, false
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		calls = nil
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("server:\n  host: explicit\n", &c,
+			yamagiconf.WithDefaultProvider(provider))
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Server: Server{Host: "explicit", Workers: 8}}, c)
+		require.Equal(t, []string{
+			"TestConfig.Server.Workers int32",
+			"TestConfig.Server.Limit *uint16",
+		}, calls)
+	})
+
+	t.Run("all_provided", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("server: {}\n", &c,
+			yamagiconf.WithDefaultProvider(provider))
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Server: Server{Host: "host-1", Workers: 8}}, c)
+	})
+
+	t.Run("not_provided", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("{}\n", &c,
+			yamagiconf.WithDefaultProvider(provider))
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at TestConfig.Server (as "server"): `+
+			`missing field in config file`, err.Error())
+	})
+
+	t.Run("validated", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("server:\n  host: x\n", &c,
+			yamagiconf.WithDefaultProvider(
+				func(goPath string, fieldType reflect.Type) (any, bool) {
+					return reflect.Zero(fieldType).Interface(), true
+				}))
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 2:3: "workers" violates validation rule: "min"`,
+			err.Error())
+	})
+
+	t.Run("err_not_assignable", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("server:\n  host: x\n", &c,
+			yamagiconf.WithDefaultProvider(
+				func(goPath string, fieldType reflect.Type) (any, bool) {
+					return 8, true
+				}))
+		require.ErrorIs(t, err, yamagiconf.ErrInvalidDefaultValue)
+		require.Equal(t, `at TestConfig.Server.Workers (as "workers"): `+
+			`invalid default value: int is not assignable to int32`, err.Error())
+	})
+
+	t.Run("err_nil_on_non_nilable", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("server:\n  workers: 1\n", &c,
+			yamagiconf.WithDefaultProvider(
+				func(goPath string, fieldType reflect.Type) (any, bool) {
+					return nil, true
+				}))
+		require.ErrorIs(t, err, yamagiconf.ErrInvalidDefaultValue)
+		require.Equal(t, `at TestConfig.Server.Host (as "host"): `+
+			`invalid default value: nil for string`, err.Error())
+	})
+}
+
+func TestWithEmptyStringAsNull(t *testing.T) {
+	type TestConfig struct {
+		Nickname *string          `yaml:"nickname"`
+		Comment  *string          `yaml:"comment"`
+		Name     string           `yaml:"name"`
+		Host     *ValidatedString `yaml:"host"`
+	}
+	const src = "nickname: \"\"\ncomment: ''\nname: \"\"\nhost: valid\n"
+
+	t.Run("empty_string", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithEmptyStringAsNull())
+		require.NoError(t, err)
+		require.Nil(t, c.Nickname)
+		require.Nil(t, c.Comment)
+		require.Equal(t, "", c.Name)
+	})
+
+	t.Run("null", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(
+			"nickname: null\ncomment: null\nname: x\nhost: null\n", &c,
+			yamagiconf.WithEmptyStringAsNull())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Name: "x"}, c)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(
+			"nickname: nick\ncomment: \" \"\nname: x\nhost: valid\n", &c,
+			yamagiconf.WithEmptyStringAsNull())
+		require.NoError(t, err)
+		require.Equal(t, PtrTo("nick"), c.Nickname)
+		require.Equal(t, PtrTo(" "), c.Comment)
+		require.Equal(t, PtrTo(ValidatedString("valid")), c.Host)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c)
+		require.NoError(t, err)
+		require.Equal(t, PtrTo(""), c.Nickname)
+		require.Equal(t, PtrTo(""), c.Comment)
+	})
+}
+
+func TestWithQuotedNumberCoercion(t *testing.T) {
+	type TestConfig struct {
+		Port    uint16        `yaml:"port"`
+		Offset  int8          `yaml:"offset"`
+		Ratio   float32       `yaml:"ratio"`
+		Max     *int64        `yaml:"max"`
+		Timeout time.Duration `yaml:"timeout"`
+		Name    string        `yaml:"name"`
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("port: \"8080\"\n"+
+			"offset: '-12'\n"+
+			"ratio: \"0.5\"\n"+
+			"max: \"9223372036854775807\"\n"+
+			"timeout: \"5s\"\n"+
+			"name: \"42\"\n", &c,
+			yamagiconf.WithQuotedNumberCoercion())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Port:    8080,
+			Offset:  -12,
+			Ratio:   0.5,
+			Max:     PtrTo(int64(9223372036854775807)),
+			Timeout: 5 * time.Second,
+			Name:    "42",
+		}, c)
+	})
+
+	t.Run("alias", func(t *testing.T) {
+		// The anchored value must remain unchanged for aliases of other types.
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("port: &x \"016\"\n"+
+			"offset: *x\nratio: *x\nmax: *x\ntimeout: 0s\nname: *x\n", &c,
+			yamagiconf.WithQuotedNumberCoercion())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Port: 16, Offset: 16, Ratio: 16, Max: PtrTo(int64(16)), Name: "016",
+		}, c)
+	})
+
+	t.Run("alias_of_string", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("name: &x \"8080\"\n"+
+			"port: *x\noffset: 0\nratio: 0\nmax: null\ntimeout: 0s\n", &c,
+			yamagiconf.WithQuotedNumberCoercion())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Port: 8080, Name: "8080"}, c)
+	})
+
+	t.Run("unquoted", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("port: 8080\n"+
+			"offset: -12\n"+
+			"ratio: 0.5\n"+
+			"max: null\n"+
+			"timeout: 5s\n"+
+			"name: x\n", &c,
+			yamagiconf.WithQuotedNumberCoercion())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Port: 8080, Offset: -12, Ratio: 0.5, Timeout: 5 * time.Second, Name: "x",
+		}, c)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("port: \"8080\"\n"+
+			"offset: 0\nratio: 0\nmax: null\ntimeout: 0s\nname: x\n", &c)
+		require.Error(t, err)
+		require.NotErrorIs(t, err, yamagiconf.ErrYAMLInvalidQuotedNumber)
+	})
+
+	t.Run("out_of_range", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("port: 8080\n"+
+			"offset: \"128\"\n"+
+			"ratio: 0\nmax: null\ntimeout: 0s\nname: x\n", &c,
+			yamagiconf.WithQuotedNumberCoercion())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLInvalidQuotedNumber)
+		require.Equal(t, `at 2:9: "offset" (TestConfig.Offset): `+
+			`invalid quoted number: strconv.ParseInt: parsing "128": `+
+			`value out of range`, err.Error())
+	})
+
+	t.Run("negative_unsigned", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("port: \"-1\"\n"+
+			"offset: 0\nratio: 0\nmax: null\ntimeout: 0s\nname: x\n", &c,
+			yamagiconf.WithQuotedNumberCoercion())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLInvalidQuotedNumber)
+		require.Equal(t, `at 1:7: "port" (TestConfig.Port): `+
+			`invalid quoted number: strconv.ParseUint: parsing "-1": `+
+			`invalid syntax`, err.Error())
+	})
+
+	t.Run("float_out_of_range", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("port: 0\noffset: 0\n"+
+			"ratio: \"1e39\"\n"+
+			"max: null\ntimeout: 0s\nname: x\n", &c,
+			yamagiconf.WithQuotedNumberCoercion())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLInvalidQuotedNumber)
+		require.Equal(t, `at 3:8: "ratio" (TestConfig.Ratio): `+
+			`invalid quoted number: strconv.ParseFloat: parsing "1e39": `+
+			`value out of range`, err.Error())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("port: 0\noffset: 0\nratio: 0\n"+
+			"max: \"12abc\"\n"+
+			"timeout: 0s\nname: x\n", &c,
+			yamagiconf.WithQuotedNumberCoercion())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLInvalidQuotedNumber)
+		require.Equal(t, `at 4:6: "max" (TestConfig.Max): `+
+			`invalid quoted number: strconv.ParseInt: parsing "12abc": `+
+			`invalid syntax`, err.Error())
+	})
+}
+
+func TestWithAllowExtendedBooleans(t *testing.T) {
+	type TestConfig struct {
+		Enabled  bool            `yaml:"enabled"`
+		Debug    *bool           `yaml:"debug"`
+		Name     string          `yaml:"name"`
+		Flags    []bool          `yaml:"flags"`
+		Features map[string]bool `yaml:"features"`
+	}
+	load := func(src string) (TestConfig, error) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithAllowExtendedBooleans())
+		return c, err
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		c, err := load(`enabled: Yes
+debug: off
+name: no
+flags: [yes, NO, On, OFF, true, false]
+features:
+  a: ON
+  b: no
+`)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Enabled:  true,
+			Debug:    PtrTo(false),
+			Name:     "no",
+			Flags:    []bool{true, false, true, false, true, false},
+			Features: map[string]bool{"a": true, "b": false},
+		}, c)
+	})
+
+	t.Run("alias", func(t *testing.T) {
+		// The anchored value must remain unchanged for aliases of other types.
+		c, err := load("enabled: &x yes\ndebug: *x\nname: *x\n" +
+			"flags: [*x]\nfeatures: {}\n")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Enabled:  true,
+			Debug:    PtrTo(true),
+			Name:     "yes",
+			Flags:    []bool{true},
+			Features: map[string]bool{},
+		}, c)
+	})
+
+	t.Run("err_quoted", func(t *testing.T) {
+		_, err := load("enabled: \"yes\"\ndebug: null\nname: x\nflags: []\nfeatures: {}\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadBoolLiteral)
+		require.Equal(t, `at 1:10: "enabled" (TestConfig.Enabled): `+
+			yamagiconf.ErrYAMLBadBoolLiteral.Error(), err.Error())
+	})
+
+	t.Run("err_other_literal", func(t *testing.T) {
+		_, err := load("enabled: y\ndebug: null\nname: x\nflags: []\nfeatures: {}\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadBoolLiteral)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("enabled: yes\ndebug: null\nname: x\n" +
+			"flags: []\nfeatures: {}\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadBoolLiteral)
+	})
+}
+
+func TestWithStrictAliasTypes(t *testing.T) {
+	type TestConfig struct {
+		Name    string   `yaml:"name"`
+		Port    int16    `yaml:"port"`
+		Ratio   float64  `yaml:"ratio"`
+		Backup  *string  `yaml:"backup"`
+		Aliases []string `yaml:"aliases"`
+	}
+
+	t.Run("string_into_int", func(t *testing.T) {
+		const src = "name: &n 8080\nport: *n\nratio: 1\nbackup: null\naliases: []\n"
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithStrictAliasTypes())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLAliasTypeMismatch)
+		require.Equal(t, `at 2:7: "port" (TestConfig.Port): `+
+			`alias used for incompatible type: anchor "n" defined at 1:7 `+
+			`for TestConfig.Name (string), used for int16`, err.Error())
+
+		// Without the option it's a warning.
+		r, err := yamagiconf.LoadWithReport(src, &c)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Name: "8080", Port: 8080, Ratio: 1, Aliases: []string{}}, c)
+		require.Equal(t, []string{`at 2:7: "port" (TestConfig.Port): ` +
+			`alias used for incompatible type: anchor "n" defined at 1:7 ` +
+			`for TestConfig.Name (string), used for int16`}, r.Warnings)
+	})
+
+	t.Run("int_into_float", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(
+			"name: x\nport: &p 8080\nratio: *p\nbackup: null\naliases: []\n", &c,
+			yamagiconf.WithStrictAliasTypes())
+		require.NoError(t, err)
+		require.Equal(t, float64(8080), c.Ratio)
+	})
+
+	t.Run("same_type", func(t *testing.T) {
+		var c TestConfig
+		r, err := yamagiconf.LoadWithReport(
+			"name: &n x\nport: 1\nratio: 1\nbackup: *n\naliases: [*n]\n", &c,
+			yamagiconf.WithStrictAliasTypes())
+		require.NoError(t, err)
+		require.Empty(t, r.Warnings)
+		require.Equal(t, TestConfig{
+			Name: "x", Port: 1, Ratio: 1, Backup: PtrTo("x"), Aliases: []string{"x"},
+		}, c)
+	})
+}
+
+func TestWithAllowEmptyFile(t *testing.T) {
+	type TestConfig struct {
+		Host string `yaml:"host"`
+		Port uint16 `yaml:"port"`
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyFile)
+	})
+
+	t.Run("missing_fields", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("", &c, yamagiconf.WithAllowEmptyFile())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at TestConfig.Host (as "host"): `+
+			`missing field in config file`, err.Error())
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions([]byte{}, &c, yamagiconf.WithAllowEmptyFile(),
+			yamagiconf.WithDefaultProvider(
+				func(goPath string, fieldType reflect.Type) (any, bool) {
+					switch goPath {
+					case "TestConfig.Host":
+						return "localhost", true
+					case "TestConfig.Port":
+						return uint16(8080), true
+					}
+					return nil, false
+				}))
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Host: "localhost", Port: 8080}, c)
+	})
+
+	t.Run("not_empty", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("host: x\nport: 1\n", &c,
+			yamagiconf.WithAllowEmptyFile())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Host: "x", Port: 1}, c)
+	})
+}
+
+func TestWithAllowUnknownFields(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host"`
+	}
+	type TestConfig struct {
+		Server Server `yaml:"server"`
+		Port   uint16 `yaml:"port"`
+	}
+	src := "server:\n  host: x\n  tls: true\nport: 1\nmetrics:\n  enabled: true\n"
+
+	t.Run("disabled", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 5:1: malformed YAML: `+
+			`field "metrics" not found in type yamagiconf_test.TestConfig`, err.Error())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithAllowUnknownFields())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Server: Server{Host: "x"}, Port: 1}, c)
+	})
+
+	t.Run("missing_field", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("server:\n  host: x\nmetrics: {}\n", &c,
+			yamagiconf.WithAllowUnknownFields())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at TestConfig.Port (as "port"): `+
+			`missing field in config file`, err.Error())
+	})
+}
+
+func TestWithAllowMultiDoc(t *testing.T) {
+	type TestConfig struct {
+		Host string `yaml:"host"`
+		Port uint16 `yaml:"port"`
+		Name string `yaml:"name"`
+	}
+	src := `host: &h localhost
+port: 8080
+name: *h
+---
+host: &h other
+port: 9090
+name: x
+---
+:
+`
+
+	t.Run("disabled", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMultidoc)
+		require.Equal(t, "at 4:1: "+yamagiconf.ErrYAMLMultidoc.Error(), err.Error())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithAllowMultiDoc())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Host: "localhost", Port: 8080, Name: "localhost"}, c)
+	})
+
+	t.Run("first_doc_checked", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("host: &h localhost\nport: 8080\nname: x\n"+
+			"---\nhost: *h\nport: 9090\nname: x\n", &c, yamagiconf.WithAllowMultiDoc())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLAnchorUnused)
+		require.Equal(t, `at 1:7: anchor "h": `+
+			`yaml anchors must be referenced at least once`, err.Error())
+	})
+}
+
+func TestWithAllowPlatformInts(t *testing.T) {
+	type TestConfig struct {
+		Int      int            `yaml:"int" env:"INT"`
+		Uint     uint           `yaml:"uint" env:"UINT"`
+		IntPtr   *int           `yaml:"int-ptr" env:"INT_PTR"`
+		Uints    []uint         `yaml:"uints"`
+		Quoted   int            `yaml:"quoted"`
+		ByNumber map[int]string `yaml:"by-number"`
+		Default  uint           `yaml:"default" default:"42"`
+	}
+	src := `int: -9223372036854775808
+uint: 18446744073709551615
+int-ptr: null
+uints: [1, 2]
+quoted: "7"
+by-number:
+  -1: x
+default: 1
+`
+
+	t.Run("disabled", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeEnvVarOnUnsupportedType)
+		require.Equal(t, "at TestConfig.Int: env var on unsupported type: int",
+			err.Error())
+
+		err = yamagiconf.ValidateType[struct {
+			Uint uint `yaml:"uint"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeUnsupported)
+		require.Equal(t, "at struct{...}.Uint: unsupported type: uint, "+
+			"use unsigned integer type with specified width, "+
+			"such as uint8, uint16, uint32 or uint64 instead of uint", err.Error())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		if strconv.IntSize != 64 {
+			t.Skip("requires a 64-bit platform")
+		}
+		opts := []yamagiconf.Option{
+			yamagiconf.WithAllowPlatformInts(), yamagiconf.WithQuotedNumberCoercion(),
+		}
+		_, err := yamagiconf.PrecomputeType[TestConfig](opts...)
+		require.NoError(t, err)
+
+		var c TestConfig
+		err = yamagiconf.LoadWithOptions(src, &c, opts...)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Int:      math.MinInt64,
+			Uint:     math.MaxUint64,
+			Uints:    []uint{1, 2},
+			Quoted:   7,
+			ByNumber: map[int]string{-1: "x"},
+			Default:  1,
+		}, c)
+
+		b, err := yamagiconf.Marshal(c, opts...)
+		require.NoError(t, err)
+		require.Contains(t, string(b), "uint: 18446744073709551615\n")
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("INT", "9223372036854775807")
+		t.Setenv("UINT", "0")
+		t.Setenv("INT_PTR", "-5")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithAllowPlatformInts(), yamagiconf.WithQuotedNumberCoercion())
+		require.NoError(t, err)
+		require.Equal(t, math.MaxInt, c.Int)
+		require.Zero(t, c.Uint)
+		require.Equal(t, PtrTo(-5), c.IntPtr)
+	})
+
+	t.Run("env_invalid", func(t *testing.T) {
+		t.Setenv("UINT", "-1")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithAllowPlatformInts(), yamagiconf.WithQuotedNumberCoercion())
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+	})
+}
+
+func TestWithPhases(t *testing.T) {
+	type TestConfig struct {
+		Name string          `yaml:"name" env:"NAME" validate:"required"`
+		Host ValidatedString `yaml:"host"`
+		Port uint16          `yaml:"port" multipleof:"10"`
+	}
+
+	t.Run("lint", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("name: x\nhost: valid\nport: 80\n", &c,
+			yamagiconf.WithPhases(yamagiconf.PhaseTypeCheck|yamagiconf.PhaseValueRules))
+		require.NoError(t, err)
+		require.Zero(t, c)
+
+		err = yamagiconf.LoadWithOptions("name: x\nhost: valid\nport: 80\nx: 1\n", &c,
+			yamagiconf.WithPhases(yamagiconf.PhaseTypeCheck|yamagiconf.PhaseValueRules))
+		require.Error(t, err)
+		require.Equal(t, `at 4:1: malformed YAML: `+
+			`field "x" not found in type yamagiconf_test.TestConfig`, err.Error())
+		require.Zero(t, c)
+	})
+
+	t.Run("skip_validators", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("name: x\nhost: invalid\nport: 81\n", &c,
+			yamagiconf.WithPhases(yamagiconf.PhaseAll&^yamagiconf.PhaseValidators))
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Name: "x", Host: "invalid", Port: 81}, c)
+
+		err = yamagiconf.LoadWithOptions("name: ''\nhost: invalid\nport: 81\n", &c,
+			yamagiconf.WithPhases(yamagiconf.PhaseAll&^yamagiconf.PhaseValidators))
+		require.Error(t, err)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+	})
+
+	t.Run("skip_go_validator", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("name: ''\nhost: valid\nport: 80\n", &c,
+			yamagiconf.WithPhases(yamagiconf.PhaseAll&^yamagiconf.PhaseGoValidator))
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Host: "valid", Port: 80}, c)
+	})
+
+	t.Run("skip_env", func(t *testing.T) {
+		t.Setenv("NAME", "from-env")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("name: x\nhost: valid\nport: 80\n", &c,
+			yamagiconf.WithPhases(yamagiconf.PhaseAll&^yamagiconf.PhaseEnv))
+		require.NoError(t, err)
+		require.Equal(t, "x", c.Name)
+
+		err = yamagiconf.LoadWithOptions("name: x\nhost: valid\nport: 80\n", &c)
+		require.NoError(t, err)
+		require.Equal(t, "from-env", c.Name)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, phases := range []yamagiconf.Phase{
+			yamagiconf.PhaseDecode,
+			yamagiconf.PhaseValueRules | yamagiconf.PhaseEnv,
+			yamagiconf.PhaseValueRules | yamagiconf.PhaseGoValidator,
+			yamagiconf.PhaseAll + 1,
+		} {
+			var c TestConfig
+			err := yamagiconf.LoadWithOptions("name: x\nhost: valid\nport: 80\n", &c,
+				yamagiconf.WithPhases(phases))
+			require.ErrorIs(t, err, yamagiconf.ErrInvalidPhases, "phases: %b", phases)
+			require.Zero(t, c)
+		}
+	})
+}
+
+func TestWithReportAllMissing(t *testing.T) {
+	type Embedded struct {
+		Region string `yaml:"region"`
+	}
+	type Server struct {
+		Embedded `yaml:",inline"`
+		Host     string        `yaml:"host"`
+		Timeout  time.Duration `yaml:"timeout"`
+	}
+	type TestConfig struct {
+		Name    string            `yaml:"name"`
+		Server  Server            `yaml:"server"`
+		Servers []*Server         `yaml:"servers"`
+		ByName  map[string]Server `yaml:"by-name"`
+		Port    uint16            `yaml:"port" default:"8080"`
+	}
+	load := func(src string) error {
+		var c TestConfig
+		return yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithReportAllMissing())
+	}
+
+	t.Run("all", func(t *testing.T) {
+		err := load(`server:
+  host: x
+servers:
+  - region: eu
+    host: x
+    timeout: 1s
+  - host: y
+by-name:
+  a:
+    region: eu
+    host: x
+    timout: 1s
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		var m *yamagiconf.MultiError
+		require.ErrorAs(t, err, &m)
+		require.Len(t, m.Errors, 6)
+		require.Equal(t, `at TestConfig.Name (as "name"): `+
+			`missing field in config file
+at TestConfig.Server.Embedded.Region (as "region"): `+
+			`missing field in config file
+at TestConfig.Server.Timeout (as "timeout"): `+
+			`missing field in config file
+at TestConfig.Servers[1].Embedded.Region (as "region"): `+
+			`missing field in config file
+at TestConfig.Servers[1].Timeout (as "timeout"): `+
+			`missing field in config file
+at TestConfig.ByName["a"].Timeout (as "timeout"): `+
+			`missing field in config file: found similar key "timout" at 12:5`,
+			err.Error())
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		require.NoError(t, load(`name: n
+server: {region: eu, host: x, timeout: 1s}
+servers: []
+by-name: {}
+`))
+	})
+
+	t.Run("other_error", func(t *testing.T) {
+		err := load("server: {}\nunknown: x\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.NotErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("{}\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at TestConfig.Name (as "name"): `+
+			`missing field in config file`, err.Error())
+	})
+}
@@ -0,0 +1,113 @@
+package yamagiconf
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var typeTime = reflect.TypeFor[time.Time]()
+
+// orderTags are the struct tags comparing a field with a sibling field.
+var orderTags = [...]string{"before", "after"}
+
+// validateOrderField returns an error if f has a `before` or `after`
+// struct tag that doesn't reference another exported field of struct parent
+// of the same type or if the type isn't time.Time, time.Duration or a number.
+func validateOrderField(parent reflect.Type, f reflect.StructField) error {
+	for _, tag := range orderTags {
+		name, ok := f.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		g, ok := parent.FieldByName(name)
+		if !ok || !g.IsExported() || name == f.Name {
+			return fmt.Errorf("%w: %s:%q: field not found",
+				ErrTypeInvalidOrderTag, tag, name)
+		}
+		t, gt := f.Type, g.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if gt.Kind() == reflect.Pointer {
+			gt = gt.Elem()
+		}
+		if !isOrdered(t) {
+			return fmt.Errorf("%w: %s:%q: %s is not ordered",
+				ErrTypeInvalidOrderTag, tag, name, f.Type.String())
+		}
+		if gt != t {
+			return fmt.Errorf("%w: %s:%q: field is of type %s, expected %s",
+				ErrTypeInvalidOrderTag, tag, name, g.Type.String(), t.String())
+		}
+	}
+	return nil
+}
+
+// isOrdered returns true for time.Time, time.Duration and numbers.
+func isOrdered(t reflect.Type) bool {
+	if t == typeTime {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// checkOrderField reports field f of struct v if its value isn't before
+// or after the value of the sibling field referenced by its `before`
+// or `after` struct tag. Fields and siblings that are nil pointers
+// are not checked.
+func checkOrderField(
+	w *fieldWalker, path string, v reflect.Value, f reflect.StructField, fv reflect.Value,
+) error {
+	for _, tag := range orderTags {
+		name, ok := f.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		g, _ := v.Type().FieldByName(name) // Already checked by ValidateType.
+		c, ok := compareOrdered(fv, v.FieldByIndex(g.Index))
+		if !ok {
+			continue // nil
+		}
+		if tag == "before" && c >= 0 || tag == "after" && c <= 0 {
+			err := fmt.Errorf("%w: must be %s %s", ErrFieldOrder, tag, w.name(g))
+			if err := w.report(path, err); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compareOrdered compares a and b which are values of the same ordered type
+// or pointers to it. Returns ok=false if either is a nil pointer.
+func compareOrdered(a, b reflect.Value) (c int, ok bool) {
+	if a.Kind() == reflect.Pointer {
+		if a.IsNil() {
+			return 0, false
+		}
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Pointer {
+		if b.IsNil() {
+			return 0, false
+		}
+		b = b.Elem()
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(a.Uint(), b.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float()), true
+	}
+	return a.Interface().(time.Time).Compare(b.Interface().(time.Time)), true
+}
@@ -0,0 +1,101 @@
+package yamagiconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderTags(t *testing.T) {
+	type Window struct {
+		Start time.Time  `yaml:"start" before:"End"`
+		End   *time.Time `yaml:"end"`
+	}
+	type TestConfig struct {
+		Windows []Window      `yaml:"windows"`
+		Min     time.Duration `yaml:"min"`
+		Max     time.Duration `yaml:"max" after:"Min" env:"MAX"`
+		Low     float64       `yaml:"low" before:"High"`
+		High    float64       `yaml:"high"`
+	}
+	const tail = "min: 1s\nmax: 1m\nlow: 0.5\nhigh: 1.5\n"
+
+	t.Run("valid", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("windows:\n" +
+			"  - start: 2024-01-01T00:00:00Z\n    end: 2024-02-01T00:00:00Z\n" +
+			"  - start: 2024-03-01T00:00:00Z\n    end: null\n" + tail)
+		require.NoError(t, err)
+		require.Len(t, c.Windows, 2)
+		require.Nil(t, c.Windows[1].End)
+	})
+
+	t.Run("equal", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("windows:\n" +
+			"  - start: 2024-01-01T00:00:00Z\n    end: 2024-01-01T00:00:00Z\n" + tail)
+		require.ErrorIs(t, err, yamagiconf.ErrFieldOrder)
+		require.Equal(t, `at 2:12: "start": invalid field order: must be before "end"`,
+			err.Error())
+	})
+
+	t.Run("reversed", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("windows: []\n" +
+			"min: 1m\nmax: 1s\nlow: 0.5\nhigh: 1.5\n")
+		require.ErrorIs(t, err, yamagiconf.ErrFieldOrder)
+		require.Equal(t, `at 3:6: "max": invalid field order: must be after "min"`,
+			err.Error())
+
+		_, err = LoadSrc[TestConfig]("windows: []\n" +
+			"min: 1s\nmax: 1m\nlow: 2.5\nhigh: 1.5\n")
+		require.ErrorIs(t, err, yamagiconf.ErrFieldOrder)
+		require.Equal(t, `at 4:6: "low": invalid field order: must be before "high"`,
+			err.Error())
+	})
+
+	t.Run("reversed_env", func(t *testing.T) {
+		t.Setenv("MAX", "500ms")
+		_, err := LoadSrc[TestConfig]("windows: []\n" + tail)
+		require.ErrorIs(t, err, yamagiconf.ErrFieldOrder)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.Max: invalid env var MAX: `+
+			`invalid field order: must be after "min"`, err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		err := yamagiconf.Validate(TestConfig{
+			Windows: []Window{{Start: start, End: PtrTo(start.Add(-time.Hour))}},
+			Min:     time.Second, Max: time.Second, High: 1,
+		}, yamagiconf.WithAllErrors())
+		require.ErrorIs(t, err, yamagiconf.ErrFieldOrder)
+		require.Equal(t, `at TestConfig.Windows[0].Start: invalid field order: must be before End
+at TestConfig.Max: invalid field order: must be after Min`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidOrderTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Start time.Time `yaml:"start" before:"Stop"`
+		End   time.Time `yaml:"end"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidOrderTag)
+	require.Equal(t, `at struct{...}.Start: invalid before or after struct tag: `+
+		`before:"Stop": field not found`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Start time.Time     `yaml:"start" before:"End"`
+		End   time.Duration `yaml:"end"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidOrderTag)
+	require.Equal(t, `at struct{...}.Start: invalid before or after struct tag: `+
+		`before:"End": field is of type time.Duration, expected time.Time`, err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		From string `yaml:"from"`
+		To   string `yaml:"to" after:"From"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidOrderTag)
+	require.Equal(t, `at struct{...}.To: invalid before or after struct tag: `+
+		`after:"From": string is not ordered`, err.Error())
+}
@@ -0,0 +1,73 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// patterns caches the compiled regular expressions of `pattern` struct tags.
+var patterns sync.Map // string -> *regexp.Regexp
+
+// compilePattern returns the compiled regular expression of pattern.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if r, ok := patterns.Load(pattern); ok {
+		return r.(*regexp.Regexp), nil
+	}
+	r, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, r)
+	return r, nil
+}
+
+// validatePatternField returns an error if f has a `pattern` struct tag
+// that isn't a valid regular expression or f isn't a string or
+// pointer to string.
+func validatePatternField(f reflect.StructField) error {
+	p, ok := f.Tag.Lookup("pattern")
+	if !ok {
+		return nil
+	}
+	if _, err := compilePattern(p); err != nil {
+		return fmt.Errorf("%w: %w", ErrTypeInvalidPatternTag, err)
+	}
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.String ||
+		implementsInterface[encoding.TextUnmarshaler](t) ||
+		implementsInterface[yaml.Unmarshaler](t) {
+		return fmt.Errorf("%w: %s is not a string",
+			ErrTypeInvalidPatternTag, f.Type.String())
+	}
+	return nil
+}
+
+// checkPatternField reports string field f if its value doesn't match
+// its `pattern` struct tag. Nil pointers are not checked.
+func checkPatternField(
+	w *fieldWalker, path string, _ reflect.Value, f reflect.StructField, fv reflect.Value,
+) error {
+	p, ok := f.Tag.Lookup("pattern")
+	if !ok {
+		return nil
+	}
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	r, _ := compilePattern(p) // Already checked by ValidateType.
+	if s := fv.String(); !r.MatchString(s) {
+		return w.report(path, fmt.Errorf("value %q %w %s", s, ErrPatternMismatch, p))
+	}
+	return nil
+}
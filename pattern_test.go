@@ -0,0 +1,75 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatternTag(t *testing.T) {
+	type Service struct {
+		Name  string  `yaml:"name" pattern:"^[a-z0-9-]+$"`
+		Alias *string `yaml:"alias" pattern:"^[a-z]+$" env:"SERVICE_ALIAS"`
+	}
+	type TestConfig struct {
+		Services []Service `yaml:"services"`
+	}
+
+	t.Run("match", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("services:\n" +
+			"  - name: api-v2\n    alias: api\n" +
+			"  - name: worker\n    alias: null\n")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Services: []Service{
+			{Name: "api-v2", Alias: PtrTo("api")},
+			{Name: "worker"},
+		}}, *c)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("services:\n" +
+			"  - name: api\n    alias: null\n" +
+			"  - name: Worker_1\n    alias: null\n")
+		require.ErrorIs(t, err, yamagiconf.ErrPatternMismatch)
+		require.Equal(t, `at 4:11: "name": value "Worker_1" `+
+			`does not match pattern ^[a-z0-9-]+$`, err.Error())
+	})
+
+	t.Run("mismatch_env", func(t *testing.T) {
+		t.Setenv("SERVICE_ALIAS", "API")
+		_, err := LoadSrc[TestConfig]("services:\n" +
+			"  - name: api\n    alias: null\n")
+		require.ErrorIs(t, err, yamagiconf.ErrPatternMismatch)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.Services[0].Alias: invalid env var `+
+			`SERVICE_ALIAS: value "API" does not match pattern ^[a-z]+$`, err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{Services: []Service{
+			{Name: "ok"}, {Name: "not ok", Alias: PtrTo("x1")},
+		}}, yamagiconf.WithAllErrors())
+		require.ErrorIs(t, err, yamagiconf.ErrPatternMismatch)
+		require.Equal(t, `at TestConfig.Services[1].Name: value "not ok" `+
+			`does not match pattern ^[a-z0-9-]+$
+at TestConfig.Services[1].Alias: value "x1" does not match pattern ^[a-z]+$`,
+			err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidPatternTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Field string `yaml:"field" pattern:"^[a-z"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidPatternTag)
+	require.Equal(t, "at struct{...}.Field: invalid pattern struct tag: "+
+		"error parsing regexp: missing closing ]: `[a-z`", err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field int32 `yaml:"field" pattern:"^[0-9]+$"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidPatternTag)
+	require.Equal(t, "at struct{...}.Field: invalid pattern struct tag: "+
+		"int32 is not a string", err.Error())
+}
@@ -0,0 +1,82 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Phase is a set of phases of loading a document, see WithPhases.
+type Phase uint8
+
+const (
+	// PhaseTypeCheck checks the Go type like ValidateType.
+	// Loading types that don't pass the check may panic or fail in
+	// unexpected ways, so only leave this phase out if the type is known
+	// to be valid.
+	PhaseTypeCheck Phase = 1 << iota
+
+	// PhaseValueRules checks the document against the Go type and the rules
+	// of the supported subset of YAML and resolves values such as enum names.
+	PhaseValueRules
+
+	// PhaseDecode decodes the document into the config.
+	// Requires PhaseValueRules.
+	PhaseDecode
+
+	// PhaseEnv overwrites fields from env vars. Requires PhaseDecode.
+	PhaseEnv
+
+	// PhaseValidators invokes the Validate methods and checks the struct tags
+	// of this package such as `pattern` and `format`. Requires PhaseDecode.
+	PhaseValidators
+
+	// PhaseGoValidator checks the go-playground/validator struct tags.
+	// Requires PhaseDecode.
+	PhaseGoValidator
+
+	// PhaseAll is the default set of phases.
+	PhaseAll = PhaseTypeCheck | PhaseValueRules | PhaseDecode |
+		PhaseEnv | PhaseValidators | PhaseGoValidator
+)
+
+// WithPhases makes the Load functions run only the given phases, such as
+// PhaseTypeCheck|PhaseValueRules for a linter that doesn't need the decoded
+// config or PhaseAll&^PhaseValidators for a reloader skipping expensive
+// Validate methods. The config is left untouched if PhaseDecode isn't included.
+// Phases that depend on phases that aren't included are rejected
+// with ErrInvalidPhases. Phases always run in the order they're declared.
+func WithPhases(phases Phase) Option {
+	return func(o *options) { o.phases = phases }
+}
+
+// runs returns true if all of p are included.
+func (o *options) runs(p Phase) bool { return o.phases&p == p }
+
+// checkPhases returns ErrInvalidPhases if a phase depends
+// on a phase that isn't included.
+func (o *options) checkPhases() error {
+	if o.phases&^PhaseAll != 0 {
+		return fmt.Errorf("%w: unknown phases %b", ErrInvalidPhases, o.phases&^PhaseAll)
+	}
+	if o.runs(PhaseDecode) && !o.runs(PhaseValueRules) {
+		return fmt.Errorf("%w: decode requires value rules", ErrInvalidPhases)
+	}
+	if o.phases&(PhaseEnv|PhaseValidators|PhaseGoValidator) != 0 &&
+		!o.runs(PhaseDecode) {
+		return fmt.Errorf("%w: env, validators and go validator require decode",
+			ErrInvalidPhases)
+	}
+	return nil
+}
+
+// validateLoadType checks the phases and validates type tp unless
+// PhaseTypeCheck is excluded or the type was already validated.
+func (o *options) validateLoadType(tp reflect.Type) error {
+	if err := o.checkPhases(); err != nil {
+		return err
+	}
+	if o.typeValidated || !o.runs(PhaseTypeCheck) {
+		return nil
+	}
+	return o.validateType(tp)
+}
@@ -0,0 +1,263 @@
+package yamagiconf
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WithPolymorphic allows fields of interface type ifaceType which are
+// otherwise rejected with ErrTypeUnsupported. The value of such a field
+// must be a mapping with key discriminatorKey holding the name of the
+// concrete type in registry to decode the mapping into, for example
+// `{type: http, url: "https://example.com"}` given
+// {"http": reflect.TypeFor[HTTPPlugin]()}. The concrete types must be
+// structs or pointers to structs implementing ifaceType and are validated
+// just like any other struct type. The concrete types don't need to have
+// a field for discriminatorKey. Unknown names and missing discriminator
+// keys are rejected with ErrYAMLPolymorphic. Marshal writes the
+// discriminator key as the first key of the mapping.
+func WithPolymorphic(
+	ifaceType reflect.Type, discriminatorKey string, registry map[string]reflect.Type,
+) Option {
+	p := &polymorphic{
+		key:      discriminatorKey,
+		registry: make(map[string]reflect.Type, len(registry)),
+		names:    make(map[reflect.Type]string, len(registry)),
+	}
+	names := make([]string, 0, len(registry))
+	for name, tp := range registry {
+		p.registry[name] = tp
+		p.names[tp] = name
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	p.sortedNames = names
+	return func(o *options) {
+		if o.polymorphic == nil {
+			o.polymorphic = make(map[reflect.Type]*polymorphic)
+		}
+		o.polymorphic[ifaceType] = p
+	}
+}
+
+type polymorphic struct {
+	key         string
+	registry    map[string]reflect.Type
+	names       map[reflect.Type]string
+	sortedNames []string
+}
+
+// polymorphicNode is a mapping node holding a value of a polymorphic type.
+type polymorphicNode struct {
+	key      string       // Discriminator key.
+	concrete reflect.Type // Type the mapping is decoded into.
+}
+
+// validateConcreteType returns an error if concrete type tp registered
+// under name can't be used for interface type iface.
+func validateConcreteType(iface reflect.Type, name string, tp reflect.Type) error {
+	t := tp
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() != reflect.Struct:
+		return fmt.Errorf("%w: %s registered as %q for %s is not a struct",
+			ErrTypeUnsupported, tp.String(), name, iface.String())
+	case !tp.Implements(iface):
+		return fmt.Errorf("%w: %s registered as %q doesn't implement %s",
+			ErrTypeUnsupported, tp.String(), name, iface.String())
+	}
+	return nil
+}
+
+// resolve returns the concrete type named by the discriminator of
+// mapping node holding a value of polymorphic type p.
+func (p *polymorphic) resolve(node *yaml.Node) (reflect.Type, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w: expected mapping with key %q",
+			ErrYAMLPolymorphic, p.key)
+	}
+	v := findContentNodeByTag(node, p.key)
+	if v == nil {
+		return nil, fmt.Errorf("%w: missing key %q", ErrYAMLPolymorphic, p.key)
+	}
+	tp, ok := p.registry[v.Value]
+	if !ok || v.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("%w: unknown %s %q, allowed: %s",
+			ErrYAMLPolymorphic, p.key, v.Value, strings.Join(p.sortedNames, ", "))
+	}
+	return tp, nil
+}
+
+// validatePolymorphicValue validates node against the concrete type
+// its discriminator refers to.
+func validatePolymorphicValue(
+	o *options, anchors map[string]*anchor, p *polymorphic,
+	yamlTag, path string, node *yaml.Node,
+) error {
+	n := node
+	if n.Alias != nil {
+		n = n.Alias
+	}
+	concrete, err := p.resolve(n)
+	if err != nil {
+		at := n
+		if v := findContentNodeByTag(n, p.key); v != nil {
+			at = v
+		}
+		return o.valueError(at, yamlTag, path, err)
+	}
+	if o.polymorphicNodes == nil {
+		o.polymorphicNodes = make(map[*yaml.Node]polymorphicNode)
+	}
+	o.polymorphicNodes[n] = polymorphicNode{key: p.key, concrete: concrete}
+	if node.Alias != nil {
+		// The anchored value was already validated where it's defined.
+		return nil
+	}
+	return validateYAMLValues(o, anchors, yamlTag, path, concrete, n)
+}
+
+// decodePolymorphic decodes node into config taking care of polymorphic
+// values which the YAML decoder can't decode.
+func (o *options) decodePolymorphic(config reflect.Value, node *yaml.Node) error {
+	if len(o.polymorphicNodes) < 1 {
+		return node.Decode(config.Interface())
+	}
+	// Replace polymorphic values by null for the decoder to skip them
+	// and decode them separately.
+	original := make(map[*yaml.Node]yaml.Node, len(o.polymorphicNodes))
+	for n := range o.polymorphicNodes {
+		original[n] = *n
+		*n = yaml.Node{
+			Kind: yaml.ScalarNode, Tag: "!!null", Value: "null",
+			Line: n.Line, Column: n.Column,
+		}
+	}
+	defer func() {
+		for n, orig := range original {
+			*n = orig
+		}
+	}()
+	if err := node.Decode(config.Interface()); err != nil {
+		return err
+	}
+	return o.decodePolymorphicValues(config, node, original)
+}
+
+// decodePolymorphicValues traverses v and node in parallel and decodes
+// the original nodes of all polymorphic values into their concrete types.
+func (o *options) decodePolymorphicValues(
+	v reflect.Value, node *yaml.Node, original map[*yaml.Node]yaml.Node,
+) error {
+	if node == nil {
+		return nil
+	}
+	if node.Alias != nil {
+		node = node.Alias
+	}
+	tp := v.Type()
+	if implementsInterface[encoding.TextUnmarshaler](tp) ||
+		implementsInterface[yaml.Unmarshaler](tp) {
+		return nil
+	}
+	switch tp.Kind() {
+	case reflect.Interface:
+		orig, ok := original[node]
+		if !ok {
+			return nil // null
+		}
+		concrete := o.polymorphicNodes[node].concrete
+		nv := reflect.New(concrete)
+		if concrete.Kind() == reflect.Pointer {
+			nv.Elem().Set(reflect.New(concrete.Elem()))
+		}
+		if err := orig.Decode(nv.Interface()); err != nil {
+			return err
+		}
+		if err := o.decodePolymorphicValues(nv.Elem(), &orig, original); err != nil {
+			return err
+		}
+		v.Set(nv.Elem())
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return o.decodePolymorphicValues(v.Elem(), node, original)
+		}
+	case reflect.Struct:
+		for i := range tp.NumField() {
+			f := tp.Field(i)
+			yamlTag := getYAMLFieldName(f.Tag)
+			if !f.IsExported() || yamlTag == "-" {
+				continue
+			}
+			n := node
+			if !f.Anonymous {
+				n = findContentNodeByTag(node, yamlTag)
+			}
+			if err := o.decodePolymorphicValues(v.Field(i), n, original); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i := range min(v.Len(), len(node.Content)) {
+			err := o.decodePolymorphicValues(v.Index(i), node.Content[i], original)
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i < len(node.Content); i += 2 {
+			key := reflect.New(tp.Key())
+			if err := node.Content[i].Decode(key.Interface()); err != nil {
+				return err
+			}
+			value := v.MapIndex(key.Elem())
+			if !value.IsValid() {
+				continue
+			}
+			// Map values aren't addressable, decode into a copy.
+			val := reflect.New(tp.Elem()).Elem()
+			val.Set(value)
+			err := o.decodePolymorphicValues(val, node.Content[i+1], original)
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(key.Elem(), val)
+		}
+	}
+	return nil
+}
+
+// marshalPolymorphic returns the mapping node of value v of polymorphic type p
+// with the discriminator key first.
+func marshalPolymorphic(
+	o *options, p *polymorphic, path string, v reflect.Value,
+) (*yaml.Node, error) {
+	name, ok := p.names[v.Type()]
+	if !ok {
+		return nil, fmt.Errorf("at %s: %w: unregistered type %s",
+			path, ErrYAMLPolymorphic, v.Type().String())
+	}
+	n, err := marshalNode(o, path, v)
+	if err != nil {
+		return nil, err
+	}
+	if findContentNodeByTag(n, p.key) == nil {
+		n.Content = append(
+			[]*yaml.Node{newStringNode(p.key), newStringNode(name)}, n.Content...,
+		)
+	}
+	return n, nil
+}
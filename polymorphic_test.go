@@ -0,0 +1,168 @@
+package yamagiconf_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+type Plugin interface{ PluginName() string }
+
+type HTTPPlugin struct {
+	URL string `yaml:"url" validate:"url"`
+}
+
+func (HTTPPlugin) PluginName() string { return "http" }
+
+type ExecPlugin struct {
+	Command string   `yaml:"command" validate:"required"`
+	Args    []string `yaml:"args"`
+}
+
+func (*ExecPlugin) PluginName() string { return "exec" }
+
+func withPlugins() yamagiconf.Option {
+	return yamagiconf.WithPolymorphic(
+		reflect.TypeFor[Plugin](), "type", map[string]reflect.Type{
+			"http": reflect.TypeFor[HTTPPlugin](),
+			"exec": reflect.TypeFor[*ExecPlugin](),
+		},
+	)
+}
+
+func TestPolymorphic(t *testing.T) {
+	type TestConfig struct {
+		Main     Plugin            `yaml:"main"`
+		Fallback Plugin            `yaml:"fallback"`
+		Plugins  []Plugin          `yaml:"plugins"`
+		Named    map[string]Plugin `yaml:"named"`
+	}
+
+	t.Run("unsupported_without_option", func(t *testing.T) {
+		err := yamagiconf.ValidateType[TestConfig]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeUnsupported)
+		require.Equal(t, "at TestConfig.Main: unsupported type: "+
+			"yamagiconf_test.Plugin", err.Error())
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`main:
+  type: http
+  url: https://example.com
+fallback: null
+plugins:
+  - type: exec
+    command: ls
+    args: [-la]
+  - {type: http, url: "https://example.org"}
+named:
+  x: {url: "https://x.example", type: http}
+`, &c, withPlugins())
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Main: HTTPPlugin{URL: "https://example.com"},
+			Plugins: []Plugin{
+				&ExecPlugin{Command: "ls", Args: []string{"-la"}},
+				HTTPPlugin{URL: "https://example.org"},
+			},
+			Named: map[string]Plugin{"x": HTTPPlugin{URL: "https://x.example"}},
+		}, c)
+
+		b, err := yamagiconf.Marshal(c, withPlugins())
+		require.NoError(t, err)
+		require.Equal(t, `main:
+  type: http
+  url: https://example.com
+fallback: null
+plugins:
+  - type: exec
+    command: ls
+    args:
+      - -la
+  - type: http
+    url: https://example.org
+named:
+  x:
+    type: http
+    url: https://x.example
+`, string(b))
+	})
+
+	t.Run("unknown_type", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`main:
+  type: http
+  url: https://example.com
+fallback: null
+plugins:
+  - type: grpc
+    url: https://example.org
+named: {}
+`, &c, withPlugins())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLPolymorphic)
+		require.Equal(t, `at 6:11: "plugins" (TestConfig.Plugins[0]): `+
+			`invalid polymorphic value: unknown type "grpc", allowed: exec, http`,
+			err.Error())
+	})
+
+	t.Run("missing_discriminator", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`main:
+  url: https://example.com
+fallback: null
+plugins: []
+named: {}
+`, &c, withPlugins())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLPolymorphic)
+		require.Equal(t, `at 2:3: "main" (TestConfig.Main): `+
+			`invalid polymorphic value: missing key "type"`, err.Error())
+	})
+
+	t.Run("unknown_field", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`main:
+  type: exec
+  url: https://example.com
+fallback: null
+plugins: []
+named: {}
+`, &c, withPlugins())
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 3:3: malformed YAML: field "url" not found `+
+			`in type yamagiconf_test.ExecPlugin`, err.Error())
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`main:
+  type: exec
+  command: ""
+  args: []
+fallback: null
+plugins: []
+named: {}
+`, &c, withPlugins())
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 3:12: "command" violates validation rule: "required"`,
+			err.Error())
+	})
+}
+
+func TestPolymorphicErrTypeUnsupported(t *testing.T) {
+	type TestConfig struct {
+		Main Plugin `yaml:"main"`
+	}
+	err := yamagiconf.LoadWithOptions("main: null\n", new(TestConfig),
+		yamagiconf.WithPolymorphic(
+			reflect.TypeFor[Plugin](), "type", map[string]reflect.Type{
+				"exec": reflect.TypeFor[ExecPlugin](),
+			},
+		))
+	require.ErrorIs(t, err, yamagiconf.ErrTypeUnsupported)
+	require.Equal(t, "at TestConfig.Main: unsupported type: "+
+		`yamagiconf_test.ExecPlugin registered as "exec" doesn't implement `+
+		"yamagiconf_test.Plugin", err.Error())
+}
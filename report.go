@@ -0,0 +1,111 @@
+package yamagiconf
+
+import "time"
+
+// LoadReport provides statistics about a call to LoadWithReport.
+type LoadReport struct {
+	// NodesWalked is the number of YAML nodes checked against the Go type.
+	NodesWalked int
+
+	// Anchors is the number of anchors defined in the document.
+	Anchors int
+
+	// EnvLookups is the number of env vars looked up.
+	EnvLookups int
+
+	// TimeParse is the time spent parsing the YAML document.
+	TimeParse time.Duration
+
+	// TimeValues is the time spent checking YAML values against the Go type.
+	TimeValues time.Duration
+
+	// TimeDecode is the time spent decoding YAML values into the Go type.
+	TimeDecode time.Duration
+
+	// TimeEnv is the time spent overwriting fields from env vars.
+	TimeEnv time.Duration
+
+	// TimeValidators is the time spent invoking Validate methods
+	// and checking validator struct tags.
+	TimeValidators time.Duration
+
+	// Warnings lists suspicious but accepted contents of the document
+	// such as aliases used for fields of a different type
+	// (see WithStrictAliasTypes).
+	Warnings []string
+
+	// AliasResolutions lists the fields populated via an alias
+	// in the order they appear in the document.
+	AliasResolutions []AliasResolution
+
+	// Sources maps the Go paths of fields, such as "Config.DB.Host",
+	// to where their values came from if not from the YAML document
+	// (see MarshalWithProvenance).
+	Sources map[string]Provenance
+}
+
+// Source is where the value of a field came from.
+type Source int8
+
+const (
+	// SourceYAML is the YAML document.
+	SourceYAML Source = iota
+
+	// SourceEnv is an env var overwriting the value from the document.
+	SourceEnv
+
+	// SourceDefault is the provider set by WithDefaultProvider
+	// or the `default` struct tag of a field missing in the document
+	// or the `envDefault` struct tag of a field whose env vars aren't set
+	// and that's missing in the document.
+	SourceDefault
+
+	// SourceSecret is an encrypted value decrypted
+	// by the decryptor set by WithFieldDecryptor.
+	SourceSecret
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceYAML:
+		return "yaml"
+	case SourceEnv:
+		return "env"
+	case SourceDefault:
+		return "default"
+	case SourceSecret:
+		return "secret"
+	}
+	return "unknown"
+}
+
+// Provenance describes where the value of a field came from.
+type Provenance struct {
+	Source Source
+
+	// EnvVar is the name of the env var if Source is SourceEnv.
+	EnvVar string
+}
+
+// String returns a description like "from env DB_HOST".
+func (p Provenance) String() string {
+	if p.Source == SourceEnv {
+		return "from env " + p.EnvVar
+	}
+	return "from " + p.Source.String()
+}
+
+// AliasResolution records which anchor supplied the value of a field.
+type AliasResolution struct {
+	// GoPath is the path of the field, such as "Config.ServerFallback.Host".
+	GoPath string
+
+	// AnchorName is the name of the anchor without the leading '&'.
+	AnchorName string
+
+	// DefinedAt is the position of the value the anchor is defined on.
+	DefinedAt Position
+}
+
+// Position is a position in the YAML source.
+type Position struct{ Line, Column int }
@@ -0,0 +1,166 @@
+package yamagiconf_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+
+	"github.com/stretchr/testify/require"
+)
+
+// SlowValidator takes some time to validate.
+type SlowValidator string
+
+func (SlowValidator) Validate() error {
+	time.Sleep(10 * time.Millisecond)
+	return nil
+}
+
+func TestLoadWithReport(t *testing.T) {
+	type Item struct {
+		Name string `yaml:"name" env:"ITEM_NAME"`
+	}
+	type TestConfig struct {
+		Str   string        `yaml:"str" env:"STR"`
+		List  []string      `yaml:"list"`
+		Item  Item          `yaml:"item"`
+		Slow  SlowValidator `yaml:"slow"`
+		Alias string        `yaml:"alias"`
+	}
+
+	var c TestConfig
+	r, err := yamagiconf.LoadWithReport(`
+str: &a text
+list: [a, b]
+item:
+  name: x
+slow: s
+alias: *a
+`, &c)
+	require.NoError(t, err)
+	require.NotNil(t, r)
+
+	// Root + 5 fields + 2 list items + 1 item field.
+	require.Equal(t, 9, r.NodesWalked)
+	require.Equal(t, 1, r.Anchors)
+	require.Equal(t, 2, r.EnvLookups)
+	require.GreaterOrEqual(t, r.TimeValidators, 10*time.Millisecond)
+	require.NotZero(t, r.TimeParse)
+	require.NotZero(t, r.TimeValues)
+	require.NotZero(t, r.TimeDecode)
+}
+
+func TestLoadWithReportAliasResolutions(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host"`
+		Port uint16 `yaml:"port"`
+	}
+	type TestConfig struct {
+		Server         Server `yaml:"server"`
+		ServerFallback Server `yaml:"server-fallback"`
+		Backup         Server `yaml:"backup"`
+	}
+
+	var c TestConfig
+	r, err := yamagiconf.LoadWithReport(`
+server:
+  host: &default localhost
+  port: &port 8080
+server-fallback:
+  host: *default
+  port: 8081
+backup:
+  host: *default
+  port: *port
+`, &c)
+	require.NoError(t, err)
+	require.Equal(t, "localhost", c.ServerFallback.Host)
+	require.Equal(t, []yamagiconf.AliasResolution{
+		{
+			GoPath:     "TestConfig.ServerFallback.Host",
+			AnchorName: "default",
+			DefinedAt:  yamagiconf.Position{Line: 3, Column: 9},
+		},
+		{
+			GoPath:     "TestConfig.Backup.Host",
+			AnchorName: "default",
+			DefinedAt:  yamagiconf.Position{Line: 3, Column: 9},
+		},
+		{
+			GoPath:     "TestConfig.Backup.Port",
+			AnchorName: "port",
+			DefinedAt:  yamagiconf.Position{Line: 4, Column: 9},
+		},
+	}, r.AliasResolutions)
+}
+
+func TestLoadWithReportErr(t *testing.T) {
+	type TestConfig struct {
+		Str  string `yaml:"str"`
+		Bool bool   `yaml:"bool"`
+	}
+	var c TestConfig
+	r, err := yamagiconf.LoadWithReport("str: x\nbool: yes\n", &c)
+	require.ErrorIs(t, err, yamagiconf.ErrYAMLBadBoolLiteral)
+	require.NotNil(t, r)
+	require.Equal(t, 3, r.NodesWalked)
+	require.NotZero(t, r.TimeParse)
+	require.Zero(t, r.TimeDecode)
+	require.Zero(t, r.EnvLookups)
+}
+
+func TestMarshalWithProvenance(t *testing.T) {
+	type DB struct {
+		Host     string `yaml:"host" env:"DB_HOST"`
+		Port     uint16 `yaml:"port"`
+		Password string `yaml:"password" encrypted:"true"`
+	}
+	type TestConfig struct {
+		DB       DB       `yaml:"db"`
+		Token    string   `yaml:"token" env:"API_TOKEN"`
+		Workers  uint8    `yaml:"workers"`
+		Tags     []string `yaml:"tags"`
+		Replicas []string `yaml:"replicas"`
+	}
+
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("API_TOKEN", "t0k3n")
+	var c TestConfig
+	r, err := yamagiconf.LoadWithReport(`
+db:
+  host: localhost
+  port: 5432
+  password: enc:ROT13:cnffjbeq
+token: x
+tags:
+  - a
+replicas: []
+`, &c,
+		yamagiconf.WithFieldDecryptor(rot13Decryptor),
+		yamagiconf.WithDefaultProvider(func(goPath string, _ reflect.Type) (any, bool) {
+			return uint8(4), goPath == "TestConfig.Workers"
+		}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, map[string]yamagiconf.Provenance{
+		"TestConfig.DB.Host":     {Source: yamagiconf.SourceEnv, EnvVar: "DB_HOST"},
+		"TestConfig.DB.Password": {Source: yamagiconf.SourceSecret},
+		"TestConfig.Token":       {Source: yamagiconf.SourceEnv, EnvVar: "API_TOKEN"},
+		"TestConfig.Workers":     {Source: yamagiconf.SourceDefault},
+	}, r.Sources)
+
+	b, err := yamagiconf.MarshalWithProvenance(c, r)
+	require.NoError(t, err)
+	require.Equal(t, `db:
+  host: db.internal # from env DB_HOST
+  port: 5432 # from yaml
+  password: '[REDACTED]' # from secret
+token: '[REDACTED]' # from env API_TOKEN
+workers: 4 # from default
+tags: # from yaml
+  - a
+replicas: [] # from yaml
+`, string(b))
+}
@@ -0,0 +1,92 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WithScalarResolver registers resolver under name for fields with the
+// struct tag `resolve:"<name>"`, for example `resolve:"cron"`. The value
+// of the tagged field is passed to resolver and the returned value, which must
+// be assignable to the type of the field, is assigned instead. This allows
+// fields of the same type to be parsed and validated differently without
+// declaring a wrapper type for each. Errors returned by resolver and
+// fields referring to unregistered resolvers are reported with
+// ErrScalarResolver at the location of the value. null is not resolved.
+func WithScalarResolver(name string, resolver func(s string) (any, error)) Option {
+	return func(o *options) {
+		if o.resolvers == nil {
+			o.resolvers = make(map[string]func(string) (any, error))
+		}
+		o.resolvers[name] = resolver
+	}
+}
+
+// validateResolveField returns an error if f has an empty `resolve`
+// struct tag or f isn't of a type that can be represented by a scalar.
+func validateResolveField(f reflect.StructField) error {
+	n, ok := f.Tag.Lookup("resolve")
+	if !ok {
+		return nil
+	}
+	if n == "" {
+		return fmt.Errorf("%w: empty resolver name", ErrTypeInvalidResolveTag)
+	}
+	if !isEnvSupportedType(f.Type) {
+		return fmt.Errorf("%w: %s is not a scalar type",
+			ErrTypeInvalidResolveTag, f.Type.String())
+	}
+	return nil
+}
+
+// resolveScalar invokes the resolver of struct field f on its value node
+// and returns the node of the resolved value.
+// Returns node if f has no `resolve` struct tag or node is null.
+func resolveScalar(
+	o *options, path, yamlTag string, f reflect.StructField, node *yaml.Node,
+) (*yaml.Node, error) {
+	name, ok := f.Tag.Lookup("resolve")
+	if !ok || o.resolved[node] {
+		// Nodes of aliased mappings are visited multiple times.
+		return node, nil
+	}
+	n := node
+	if n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Tag == "!!null" {
+		return node, nil
+	}
+	errorf := func(format string, a ...any) error {
+		return o.valueError(node, yamlTag, path, fmt.Errorf("%w %q: %w",
+			ErrScalarResolver, name, fmt.Errorf(format, a...)))
+	}
+	resolver := o.resolvers[name]
+	if resolver == nil {
+		return nil, errorf("not registered")
+	}
+	x, err := resolver(n.Value)
+	if err != nil {
+		return nil, errorf("failed: %w", err)
+	}
+	tp := f.Type
+	if x != nil && !reflect.TypeOf(x).AssignableTo(tp) && tp.Kind() == reflect.Pointer {
+		tp = tp.Elem()
+	}
+	if x == nil || !reflect.TypeOf(x).AssignableTo(tp) {
+		return nil, errorf("returned %T, expected %s", x, f.Type.String())
+	}
+	resolved, err := marshalNode(o, path, reflect.ValueOf(x))
+	if err != nil {
+		return nil, errorf("%v", err)
+	}
+	// Report errors at the location of the original value.
+	resolved.Line, resolved.Column = node.Line, node.Column
+	if o.resolved == nil {
+		o.resolved = make(map[*yaml.Node]bool)
+	}
+	o.resolved[resolved] = true
+	return resolved, nil
+}
@@ -0,0 +1,141 @@
+package yamagiconf_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func resolveCron(s string) (any, error) {
+	if len(strings.Fields(s)) != 5 {
+		return nil, errors.New("expected 5 fields")
+	}
+	return strings.Join(strings.Fields(s), " "), nil
+}
+
+func resolveHostPort(s string) (any, error) {
+	host, port, ok := strings.Cut(s, ":")
+	if !ok || host == "" || port == "" {
+		return nil, fmt.Errorf("missing port in %q", s)
+	}
+	return strings.ToLower(s), nil
+}
+
+func TestWithScalarResolver(t *testing.T) {
+	type Job struct {
+		Schedule string  `yaml:"schedule" resolve:"cron"`
+		Target   string  `yaml:"target" resolve:"hostport"`
+		Backup   *string `yaml:"backup" resolve:"hostport"`
+		Comment  string  `yaml:"comment"`
+	}
+	type TestConfig struct {
+		Jobs []Job `yaml:"jobs"`
+	}
+	opts := []yamagiconf.Option{
+		yamagiconf.WithScalarResolver("cron", resolveCron),
+		yamagiconf.WithScalarResolver("hostport", resolveHostPort),
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`jobs:
+  - schedule: "0  3 * * *"
+    target: &t Example.com:80
+    backup: null
+    comment: not resolved
+  - schedule: "*/5 * * * *"
+    target: *t
+    backup: Backup.example.com:81
+    comment: ""
+`, &c, opts...)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Jobs: []Job{
+			{
+				Schedule: "0 3 * * *",
+				Target:   "example.com:80",
+				Comment:  "not resolved",
+			},
+			{
+				Schedule: "*/5 * * * *",
+				Target:   "example.com:80",
+				Backup:   PtrTo("backup.example.com:81"),
+			},
+		}}, c)
+	})
+
+	t.Run("err_cron", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`jobs:
+  - schedule: "0 3 * *"
+    target: example.com:80
+    backup: null
+    comment: ""
+`, &c, opts...)
+		require.ErrorIs(t, err, yamagiconf.ErrScalarResolver)
+		require.Equal(t, `at 2:15: "schedule" (TestConfig.Jobs[0].Schedule): `+
+			`scalar resolver "cron": failed: expected 5 fields`, err.Error())
+	})
+
+	t.Run("err_hostport", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`jobs:
+  - schedule: "0 3 * * *"
+    target: example.com:80
+    backup: example.com
+    comment: ""
+`, &c, opts...)
+		require.ErrorIs(t, err, yamagiconf.ErrScalarResolver)
+		require.Equal(t, `at 4:13: "backup" (TestConfig.Jobs[0].Backup): `+
+			`scalar resolver "hostport": failed: `+
+			`missing port in "example.com"`, err.Error())
+	})
+
+	t.Run("err_not_registered", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`jobs:
+  - schedule: "0 3 * * *"
+    target: example.com:80
+    backup: null
+    comment: ""
+`, &c, yamagiconf.WithScalarResolver("cron", resolveCron))
+		require.ErrorIs(t, err, yamagiconf.ErrScalarResolver)
+		require.Equal(t, `at 3:13: "target" (TestConfig.Jobs[0].Target): `+
+			`scalar resolver "hostport": not registered`, err.Error())
+	})
+
+	t.Run("err_type_mismatch", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(`jobs:
+  - schedule: "0 3 * * *"
+    target: example.com:80
+    backup: null
+    comment: ""
+`, &c, yamagiconf.WithScalarResolver("cron", resolveCron),
+			yamagiconf.WithScalarResolver("hostport", func(s string) (any, error) {
+				return int32(80), nil
+			}))
+		require.ErrorIs(t, err, yamagiconf.ErrScalarResolver)
+		require.Equal(t, `at 3:13: "target" (TestConfig.Jobs[0].Target): `+
+			`scalar resolver "hostport": returned int32, expected string`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidResolveTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Field string `yaml:"field" resolve:""`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidResolveTag)
+	require.Equal(t, "at struct{...}.Field: invalid resolve struct tag: "+
+		"empty resolver name", err.Error())
+
+	err = yamagiconf.ValidateType[struct {
+		Field []string `yaml:"field" resolve:"list"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidResolveTag)
+	require.Equal(t, "at struct{...}.Field: invalid resolve struct tag: "+
+		"[]string is not a scalar type", err.Error())
+}
@@ -0,0 +1,53 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// LoadScalar reads and validates a YAML document consisting of a single
+// scalar value of type T, for example a connection string.
+// T must be a primitive type, time.Duration, a type implementing
+// encoding.TextUnmarshaler or a pointer to any of them.
+// The same value rules as for Load apply (no YAML tags, strict null and bool
+// literals, etc.) and the Validate method of T is invoked if implemented.
+// `env` struct tags don't apply since there's no field to tag.
+func LoadScalar[T any](src []byte, v *T, opts ...Option) error {
+	o := newOptions(opts)
+	if v == nil {
+		return ErrConfigNil
+	}
+	tp := reflect.TypeFor[T]()
+	if !isEnvSupportedType(tp) {
+		return fmt.Errorf("at %s: %w", tp.String(), ErrTypeIllegalScalarRoot)
+	}
+	if len(src) == 0 {
+		return ErrYAMLEmptyFile
+	}
+
+	node, err := parseDocument(src, o.allowMultiDoc)
+	if err != nil {
+		return err
+	}
+	o.source = newSourceIndex(src, node)
+	if err := o.validateRaw(node); err != nil {
+		return err
+	}
+
+	path := tp.Name()
+	if path == "" {
+		path = tp.String()
+	}
+	anchors := make(map[string]*anchor)
+	if err := validateYAMLValues(o, anchors, "", path, tp, node); err != nil {
+		return err
+	}
+	if err := checkUnusedAnchors(o, anchors); err != nil {
+		return err
+	}
+	node = o.replaceCoerced(node)
+	if err := node.Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", ErrYAMLMalformed, err)
+	}
+	return invokeValidateRecursively(o, path, reflect.ValueOf(v).Elem(), node, nil)
+}
@@ -0,0 +1,131 @@
+package yamagiconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadScalar(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		var v string
+		err := yamagiconf.LoadScalar([]byte("postgres://localhost:5432/db\n"), &v)
+		require.NoError(t, err)
+		require.Equal(t, "postgres://localhost:5432/db", v)
+	})
+
+	t.Run("int64", func(t *testing.T) {
+		var v int64
+		require.NoError(t, yamagiconf.LoadScalar([]byte("# comment\n-42"), &v))
+		require.Equal(t, int64(-42), v)
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		var v time.Duration
+		require.NoError(t, yamagiconf.LoadScalar([]byte("1m30s"), &v))
+		require.Equal(t, 90*time.Second, v)
+	})
+
+	t.Run("text_unmarshaler", func(t *testing.T) {
+		var v TextUnmarshaler
+		require.NoError(t, yamagiconf.LoadScalar([]byte("text"), &v))
+		require.Equal(t, TextUnmarshaler{Str: "text"}, v)
+	})
+
+	t.Run("pointer_null", func(t *testing.T) {
+		v := PtrTo("initial")
+		require.NoError(t, yamagiconf.LoadScalar([]byte("null"), &v))
+		require.Nil(t, v)
+	})
+
+	t.Run("validator", func(t *testing.T) {
+		var v ValidatedString
+		require.NoError(t, yamagiconf.LoadScalar([]byte("valid"), &v))
+		require.Equal(t, ValidatedString("valid"), v)
+	})
+}
+
+func TestLoadScalarErr(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		err := yamagiconf.LoadScalar[string]([]byte("x"), nil)
+		require.ErrorIs(t, err, yamagiconf.ErrConfigNil)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var v string
+		err := yamagiconf.LoadScalar([]byte(""), &v)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyFile)
+	})
+
+	t.Run("illegal_root", func(t *testing.T) {
+		var v struct {
+			Foo string `yaml:"foo"`
+		}
+		err := yamagiconf.LoadScalar([]byte("foo: bar"), &v)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeIllegalScalarRoot)
+		require.Equal(t, `at struct { Foo string "yaml:\"foo\"" }: `+
+			`scalar root type must be a primitive type, time.Duration `+
+			`or implement encoding.TextUnmarshaler`, err.Error())
+	})
+
+	t.Run("illegal_root_int", func(t *testing.T) {
+		var v int
+		err := yamagiconf.LoadScalar([]byte("42"), &v)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeIllegalScalarRoot)
+	})
+
+	t.Run("null_on_non_pointer", func(t *testing.T) {
+		var v string
+		err := yamagiconf.LoadScalar([]byte("null"), &v)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLNullOnNonPointer)
+		require.Equal(t, "at 1:1: string: cannot assign null to non-pointer type",
+			err.Error())
+	})
+
+	t.Run("bad_bool_literal", func(t *testing.T) {
+		var v bool
+		err := yamagiconf.LoadScalar([]byte("yes"), &v)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadBoolLiteral)
+		require.Equal(t, "at 1:1: bool: must be either false or true, "+
+			"other variants of boolean literals of YAML are not supported",
+			err.Error())
+	})
+
+	t.Run("tag", func(t *testing.T) {
+		var v string
+		err := yamagiconf.LoadScalar([]byte("!!str x"), &v)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLTagUsed)
+		require.Equal(t, `at 1:1: string: tag "!!str": avoid using YAML tags`,
+			err.Error())
+	})
+
+	t.Run("non_scalar", func(t *testing.T) {
+		var v string
+		err := yamagiconf.LoadScalar([]byte("[a, b]"), &v)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+	})
+
+	t.Run("multidoc", func(t *testing.T) {
+		var v string
+		err := yamagiconf.LoadScalar([]byte("a\n---\nb\n"), &v)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMultidoc)
+	})
+
+	t.Run("validator", func(t *testing.T) {
+		var v ValidatedString
+		err := yamagiconf.LoadScalar([]byte("invalid"), &v)
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.Equal(t, "at 1:1: at ValidatedString: validation: is not 'valid'",
+			err.Error())
+	})
+
+	t.Run("text_unmarshaler", func(t *testing.T) {
+		var v NonEmptyTextUnmarshaler
+		err := yamagiconf.LoadScalar([]byte("''"), &v)
+		require.ErrorIs(t, err, ErrEmptyText)
+		require.Equal(t, "at 1:1: NonEmptyTextUnmarshaler: empty text", err.Error())
+	})
+}
@@ -0,0 +1,354 @@
+package yamagiconf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadSequence reads a YAML document from r which must be a sequence and
+// validates each item against T before passing it to fn in order.
+// Each item is subject to the same checks as the root of a document passed to Load.
+// If fn returns an error then iteration stops and the error is returned as is.
+//
+// If the root of the document is a block sequence (items prefixed by `- `
+// at the start of the line) then items are read, decoded and passed to fn
+// one at a time and r isn't read beyond the first line of the next item
+// before fn returns. Neither decoded items nor their source are retained
+// once fn returns, except for the nodes of anchors that later items
+// may refer to. Errors in later items, including further documents,
+// are therefore only reported after earlier items were passed to fn.
+// Flow sequences (`[a, b]`), documents with directives and documents
+// loaded with WithRawValidator are read entirely before the first item
+// is validated since the raw validators receive the root of the document.
+// fn isn't called if PhaseDecode isn't included, see WithPhases.
+//
+// Anchors may be defined in one item and referenced in another,
+// unused anchors are therefore only reported after the last item was processed.
+func LoadSequence[T any](
+	r io.Reader, fn func(index int, item T) error, opts ...Option,
+) error {
+	o := newOptions(opts)
+	if err := o.validateLoadType(reflect.TypeFor[T]()); err != nil {
+		return err
+	}
+
+	s := &sequenceReader{r: bufio.NewReader(r), allowMultiDoc: o.allowMultiDoc}
+	header, streamed, err := s.readHeader()
+	if err != nil {
+		return err
+	}
+	if !streamed || len(o.rawValidators) > 0 {
+		rest, err := io.ReadAll(s.r)
+		if err != nil {
+			return err
+		}
+		return loadSequenceDocument(o, header+s.next+string(rest), fn)
+	}
+
+	anchors := make(map[string]*anchor)
+	anchored := make(map[string]*yaml.Node) // Anchored nodes of previous items.
+	for index := 0; ; index++ {
+		src, line, err := s.readItem()
+		if err != nil {
+			return err
+		}
+		if src == "" {
+			break
+		}
+		node, err := parseSequenceItem(src, line, anchored)
+		if err != nil {
+			return err
+		}
+		// The source isn't retained, byte offsets are unknown.
+		o.source = &sourceIndex{root: node, path: fmt.Sprintf("[%d]", index)}
+		if err := loadSequenceItem(o, anchors, index, node, fn); err != nil {
+			return err
+		}
+		collectAnchored(anchored, node)
+	}
+	if !o.runs(PhaseValueRules) {
+		return nil
+	}
+	return checkUnusedAnchors(o, anchors)
+}
+
+// loadSequenceDocument is LoadSequence for the entire document src.
+func loadSequenceDocument[T any](
+	o *options, src string, fn func(index int, item T) error,
+) error {
+	var rootNode yaml.Node
+	dec := yaml.NewDecoder(strings.NewReader(src))
+	if err := dec.Decode(&rootNode); err != nil {
+		if errors.Is(err, io.EOF) {
+			return ErrYAMLEmptyFile
+		}
+		return fmt.Errorf("%w: %w", ErrYAMLMalformed, err)
+	}
+	seq := rootNode.Content[0]
+	o.source = newSourceIndex(src, seq)
+	if !o.allowMultiDoc {
+		// Check if multi-doc
+		var n yaml.Node
+		if err := dec.Decode(&n); err == nil {
+			return o.errorAt(n.Line, n.Column, "", ErrYAMLMultidoc)
+		} else if !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: %w", ErrYAMLMultidoc, err)
+		}
+	}
+
+	if err := o.validateRaw(seq); err != nil {
+		return err
+	}
+	if seq.Kind != yaml.SequenceNode {
+		return o.errorAt(seq.Line, seq.Column, "", ErrYAMLRootNotSequence)
+	}
+
+	anchors := make(map[string]*anchor)
+	for index, node := range seq.Content {
+		if err := loadSequenceItem(o, anchors, index, node, fn); err != nil {
+			return err
+		}
+		// Release the item, aliases keep the nodes they refer to.
+		seq.Content[index] = nil
+	}
+	if !o.runs(PhaseValueRules) {
+		return nil
+	}
+	return checkUnusedAnchors(o, anchors)
+}
+
+// loadSequenceItem validates and decodes the item node at index
+// and passes it to fn.
+func loadSequenceItem[T any](
+	o *options, anchors map[string]*anchor,
+	index int, node *yaml.Node, fn func(index int, item T) error,
+) error {
+	if !o.runs(PhaseValueRules) {
+		return nil
+	}
+	itemType := reflect.TypeFor[T]()
+	path := fmt.Sprintf("%s[%d]", getConfigTypeName(itemType), index)
+	if node.Tag == "!!null" && node.Value == "" {
+		return o.errorAt(node.Line, node.Column, path,
+			fmt.Errorf("%s: %w", path, ErrYAMLEmptyArrayItem))
+	}
+	if err := validateDocumentValues(o, anchors, path, itemType, node); err != nil {
+		return err
+	}
+	if !o.runs(PhaseDecode) {
+		return nil
+	}
+	var item T
+	if err := decodeAndValidate(o, path, reflect.ValueOf(&item), node); err != nil {
+		return err
+	}
+	return fn(index, item)
+}
+
+// sequenceReader splits a YAML document whose root is a block sequence
+// into the source of its items.
+type sequenceReader struct {
+	r             *bufio.Reader
+	allowMultiDoc bool   // See WithAllowMultiDoc.
+	line          int    // Number of lines read.
+	next          string // First line of the next item.
+	nextLine      int    // Line number of next.
+	err           error  // Returned once the last item was read.
+}
+
+// readLine returns the next line of the document including
+// the line break or "" at the end of the document.
+func (s *sequenceReader) readLine() (string, error) {
+	line, err := s.r.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if line != "" {
+		s.line++
+	}
+	return line, nil
+}
+
+// readHeader reads the comments and the document start marker preceding
+// the first item. streamed is false if the root of the document isn't
+// a block sequence or it has directives. header is the source read
+// except the first line of the first item, which is s.next.
+func (s *sequenceReader) readHeader() (header string, streamed bool, err error) {
+	var b strings.Builder
+	started := false // Document start marker "---" was read.
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return "", false, err
+		}
+		switch {
+		case line == "":
+			return b.String(), false, nil
+		case isSequenceItemStart(line):
+			s.next, s.nextLine = line, s.line
+			return b.String(), true, nil
+		case isBlankOrComment(line):
+		case isDocumentMarker(line, "---") && !started &&
+			strings.TrimSpace(line) == "---":
+			started = true
+		default:
+			s.next = line
+			return b.String(), false, nil
+		}
+		b.WriteString(line)
+	}
+}
+
+// readItem returns the source of the next item and its line number
+// or "" if there are no more items.
+func (s *sequenceReader) readItem() (src string, line int, err error) {
+	if s.next == "" {
+		return "", 0, s.err
+	}
+	var b strings.Builder
+	b.WriteString(s.next)
+	src, line, s.next = "", s.nextLine, ""
+	for {
+		l, err := s.readLine()
+		if err != nil {
+			return "", 0, err
+		}
+		switch {
+		case l == "":
+			return b.String(), line, nil
+		case isSequenceItemStart(l):
+			s.next, s.nextLine = l, s.line
+			return b.String(), line, nil
+		case isDocumentMarker(l, "---"):
+			if !s.allowMultiDoc {
+				s.err = &Error{
+					Line: s.line, Column: 1, Offset: -1, Err: ErrYAMLMultidoc,
+				}
+			}
+			return b.String(), line, nil
+		case isDocumentMarker(l, "..."):
+			if s.err, err = s.readDocumentEnd(); err != nil {
+				return "", 0, err
+			}
+			return b.String(), line, nil
+		}
+		b.WriteString(l)
+	}
+}
+
+// readDocumentEnd reads the rest of the document after the document end
+// marker "..." and returns ErrYAMLMultidoc if another document follows.
+func (s *sequenceReader) readDocumentEnd() (multidoc, err error) {
+	for !s.allowMultiDoc {
+		line, err := s.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			break
+		}
+		if !isBlankOrComment(line) {
+			return &Error{Line: s.line, Column: 1, Offset: -1, Err: ErrYAMLMultidoc}, nil
+		}
+	}
+	return nil, nil
+}
+
+// isSequenceItemStart returns true if line starts an item
+// of a block sequence at the root of the document.
+func isSequenceItemStart(line string) bool {
+	return line != "" && line[0] == '-' &&
+		(len(line) == 1 || strings.IndexByte(" \t\r\n", line[1]) != -1)
+}
+
+// isDocumentMarker returns true if line is the document start marker "---"
+// or end marker "...", optionally followed by content.
+func isDocumentMarker(line, marker string) bool {
+	rest, ok := strings.CutPrefix(line, marker)
+	return ok && (rest == "" || strings.IndexByte(" \t\r\n", rest[0]) != -1)
+}
+
+func isBlankOrComment(line string) bool {
+	l := strings.TrimSpace(line)
+	return l == "" || l[0] == '#'
+}
+
+// parseSequenceItem parses the source of a block sequence item starting
+// at line of the document and returns its node. Aliases in src may refer
+// to the anchored nodes of previous items.
+func parseSequenceItem(
+	src string, line int, anchored map[string]*yaml.Node,
+) (*yaml.Node, error) {
+	// Define the anchors of previous items on a line of its own
+	// for the parser to accept aliases to them.
+	preamble := ""
+	if len(anchored) > 0 && strings.Contains(src, "*") {
+		var b strings.Builder
+		b.WriteString("- [")
+		for name := range anchored {
+			fmt.Fprintf(&b, "&%s null, ", name)
+		}
+		b.WriteString("]\n")
+		preamble = b.String()
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(preamble+src), &doc); err != nil {
+		if preamble != "" {
+			// Report the error at lines relative to the item.
+			if errItem := yaml.Unmarshal([]byte(src), &doc); errItem != nil {
+				err = errItem
+			}
+		}
+		return nil, fmt.Errorf("%w: item at line %d: %w", ErrYAMLMalformed, line, err)
+	}
+	seq := doc.Content[0]
+	node := seq.Content[len(seq.Content)-1]
+	var defined []*yaml.Node
+	if preamble != "" {
+		defined = seq.Content[0].Content
+	}
+	shift := line - 1
+	if preamble != "" {
+		shift--
+	}
+	walkItemNodes(node, func(n *yaml.Node) {
+		n.Line += shift
+		if n.Alias != nil && containsNode(defined, n.Alias) {
+			n.Alias = anchored[n.Value]
+		}
+	})
+	return node, nil
+}
+
+// collectAnchored records all anchored nodes within node in anchored.
+func collectAnchored(anchored map[string]*yaml.Node, node *yaml.Node) {
+	walkItemNodes(node, func(n *yaml.Node) {
+		if n.Anchor != "" {
+			anchored[n.Anchor] = n
+		}
+	})
+}
+
+// walkItemNodes calls fn for node and all nodes within it
+// without following aliases.
+func walkItemNodes(node *yaml.Node, fn func(n *yaml.Node)) {
+	fn(node)
+	for _, c := range node.Content {
+		walkItemNodes(c, fn)
+	}
+}
+
+func containsNode(nodes []*yaml.Node, n *yaml.Node) bool {
+	for _, x := range nodes {
+		if x == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// parseSortedTag parses a `sorted` struct tag like "asc" or "desc=Priority".
+func parseSortedTag(tag string) (desc bool, field string, err error) {
+	dir, field, hasField := strings.Cut(tag, "=")
+	switch dir {
+	case "asc":
+	case "desc":
+		desc = true
+	default:
+		return false, "", fmt.Errorf("%w: %q, expected asc or desc",
+			ErrTypeInvalidSortedTag, dir)
+	}
+	if hasField && field == "" {
+		return false, "", fmt.Errorf("%w: %q: empty field name",
+			ErrTypeInvalidSortedTag, tag)
+	}
+	return desc, field, nil
+}
+
+// validateSortedField returns an error if f has a `sorted` struct tag
+// that isn't valid or f isn't a slice or array of strings, time.Time,
+// time.Duration or numbers, or of structs with an exported field of
+// those types referenced by the tag.
+func validateSortedField(f reflect.StructField) error {
+	tag, ok := f.Tag.Lookup("sorted")
+	if !ok {
+		return nil
+	}
+	_, field, err := parseSortedTag(tag)
+	if err != nil {
+		return err
+	}
+	if k := f.Type.Kind(); k != reflect.Slice && k != reflect.Array {
+		return fmt.Errorf("%w: %s is not a slice or array",
+			ErrTypeInvalidSortedTag, f.Type.String())
+	}
+	t := f.Type.Elem()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if field != "" {
+		if t.Kind() != reflect.Struct || t == typeTime {
+			return fmt.Errorf("%w: %s is not a struct",
+				ErrTypeInvalidSortedTag, f.Type.Elem().String())
+		}
+		g, ok := t.FieldByName(field)
+		if !ok || !g.IsExported() {
+			return fmt.Errorf("%w: field %q not found in %s",
+				ErrTypeInvalidSortedTag, field, t.String())
+		}
+		t = g.Type
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	if !isOrdered(t) && t.Kind() != reflect.String {
+		return fmt.Errorf("%w: %s is not ordered",
+			ErrTypeInvalidSortedTag, t.String())
+	}
+	return nil
+}
+
+// checkSortedField reports slice or array field f if it isn't sorted as
+// required by its `sorted` struct tag, passing the path of the first item
+// out of order (or its field the slice is sorted by).
+// Equal neighbours are considered sorted. Nil pointers are not compared.
+func checkSortedField(
+	w *fieldWalker, path string, _ reflect.Value, f reflect.StructField, fv reflect.Value,
+) error {
+	tag, ok := f.Tag.Lookup("sorted")
+	if !ok {
+		return nil
+	}
+	desc, field, _ := parseSortedTag(tag) // Already checked by ValidateType.
+	return checkSortedItems(path, fv, desc, field, w.report)
+}
+
+// checkSortedItems calls fn for the first item of slice or array v that
+// is out of order. If field is non-empty the items are compared by it.
+func checkSortedItems(
+	path string, v reflect.Value, desc bool, field string,
+	fn func(path string, err error) error,
+) error {
+	order, relation := "ascending", "less"
+	if desc {
+		order, relation = "descending", "greater"
+	}
+	if field != "" {
+		order += " order of " + field
+	} else {
+		order += " order"
+	}
+	item := func(i int) (reflect.Value, bool) {
+		x := v.Index(i)
+		if field == "" {
+			return x, true
+		}
+		if x.Kind() == reflect.Pointer {
+			if x.IsNil() {
+				return x, false
+			}
+			x = x.Elem()
+		}
+		return x.FieldByName(field), true
+	}
+	for i := 1; i < v.Len(); i++ {
+		a, okA := item(i - 1)
+		b, okB := item(i)
+		if !okA || !okB {
+			continue
+		}
+		c, ok := compareSortable(a, b)
+		if !ok || c == 0 || (c < 0) != desc {
+			continue
+		}
+		p := fmt.Sprintf("%s[%d]", path, i)
+		if field != "" {
+			p += "." + field
+		}
+		return fn(p, fmt.Errorf("%w in %s: index %d is %s than index %d",
+			ErrNotSorted, order, i, relation, i-1))
+	}
+	return nil
+}
+
+// compareSortable is similar to compareOrdered but also compares strings.
+func compareSortable(a, b reflect.Value) (c int, ok bool) {
+	if a.Kind() == reflect.Pointer && b.Kind() == reflect.Pointer {
+		if a.IsNil() || b.IsNil() {
+			return 0, false
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+	if a.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+	return compareOrdered(a, b)
+}
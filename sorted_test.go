@@ -0,0 +1,158 @@
+package yamagiconf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortedTag(t *testing.T) {
+	type Route struct {
+		Path     string `yaml:"path"`
+		Priority *int32 `yaml:"priority"`
+	}
+	type TestConfig struct {
+		Versions []string        `yaml:"versions" sorted:"asc"`
+		Backoff  []time.Duration `yaml:"backoff" sorted:"asc"`
+		Weights  []float64       `yaml:"weights" sorted:"desc"`
+		Routes   []Route         `yaml:"routes" sorted:"desc=Priority"`
+	}
+
+	t.Run("sorted", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`versions: [v1.0, v1.1, v1.1, v2.0]
+backoff: [1s, 5s, 1m]
+weights: [0.9, 0.5, 0.5]
+routes:
+  - path: /a
+    priority: 10
+  - path: /b
+    priority: null
+  - path: /c
+    priority: 1
+`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"v1.0", "v1.1", "v1.1", "v2.0"}, c.Versions)
+
+		_, err = LoadSrc[TestConfig]("versions: []\nbackoff: []\nweights: [1]\nroutes: []\n")
+		require.NoError(t, err)
+	})
+
+	for _, td := range []struct {
+		name, src, expect string
+	}{
+		{
+			name: "unsorted_asc",
+			src:  "versions: [v1, v3, v2]\nbackoff: []\nweights: []\nroutes: []\n",
+			expect: `at 1:20: "versions": not sorted in ascending order: ` +
+				`index 2 is less than index 1`,
+		},
+		{
+			name: "unsorted_duration",
+			src:  "versions: []\nbackoff:\n  - 1m\n  - 5s\nweights: []\nroutes: []\n",
+			expect: `at 4:5: "backoff": not sorted in ascending order: ` +
+				`index 1 is less than index 0`,
+		},
+		{
+			name: "unsorted_desc",
+			src:  "versions: []\nbackoff: []\nweights: [0.1, 0.2]\nroutes: []\n",
+			expect: `at 3:16: "weights": not sorted in descending order: ` +
+				`index 1 is greater than index 0`,
+		},
+		{
+			name: "unsorted_struct_field",
+			src: `versions: []
+backoff: []
+weights: []
+routes:
+  - path: /a
+    priority: 1
+  - path: /b
+    priority: 5
+`,
+			expect: `at 8:15: "priority": not sorted in descending order of Priority: ` +
+				`index 1 is greater than index 0`,
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			_, err := LoadSrc[TestConfig](td.src)
+			require.ErrorIs(t, err, yamagiconf.ErrNotSorted)
+			require.Equal(t, td.expect, err.Error())
+		})
+	}
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{
+			Versions: []string{"b", "a"},
+			Weights:  []float64{1, 2},
+		}, yamagiconf.WithAllErrors())
+		require.ErrorIs(t, err, yamagiconf.ErrNotSorted)
+		require.Equal(t, `at TestConfig.Versions[1]: not sorted in ascending order: `+
+			`index 1 is less than index 0
+at TestConfig.Weights[1]: not sorted in descending order: `+
+			`index 1 is greater than index 0`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidSortedTag(t *testing.T) {
+	for _, td := range []struct {
+		name   string
+		check  func() error
+		expect string
+	}{
+		{
+			name: "direction",
+			check: yamagiconf.ValidateType[struct {
+				Field []string `yaml:"field" sorted:"ascending"`
+			}],
+			expect: `"ascending", expected asc or desc`,
+		},
+		{
+			name: "not_slice",
+			check: yamagiconf.ValidateType[struct {
+				Field string `yaml:"field" sorted:"asc"`
+			}],
+			expect: `string is not a slice or array`,
+		},
+		{
+			name: "not_ordered",
+			check: yamagiconf.ValidateType[struct {
+				Field []bool `yaml:"field" sorted:"asc"`
+			}],
+			expect: `bool is not ordered`,
+		},
+		{
+			name: "not_struct",
+			check: yamagiconf.ValidateType[struct {
+				Field []string `yaml:"field" sorted:"asc=Name"`
+			}],
+			expect: `string is not a struct`,
+		},
+		{
+			name: "field_not_found",
+			check: yamagiconf.ValidateType[struct {
+				Field []struct {
+					Name string `yaml:"name"`
+				} `yaml:"field" sorted:"asc=Priority"`
+			}],
+			expect: `field "Priority" not found in struct { Name string "yaml:\"name\"" }`,
+		},
+		{
+			name: "field_not_ordered",
+			check: yamagiconf.ValidateType[struct {
+				Field []struct {
+					Tags []string `yaml:"tags"`
+				} `yaml:"field" sorted:"asc=Tags"`
+			}],
+			expect: `[]string is not ordered`,
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			err := td.check()
+			require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidSortedTag)
+			require.Equal(t, `at struct{...}.Field: invalid sorted struct tag: `+
+				td.expect, err.Error())
+		})
+	}
+}
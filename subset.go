@@ -0,0 +1,222 @@
+package yamagiconf
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SubsetViolation is a construct found by CheckYAMLSubset that isn't part
+// of the subset of YAML supported by this package.
+type SubsetViolation struct {
+	// Line and Column are the 1-based location of the violation
+	// or 0 if the document can't be parsed.
+	Line, Column int
+
+	// Err wraps the sentinel error of the violated rule such as ErrYAMLTagUsed.
+	Err error
+}
+
+func (v SubsetViolation) Error() string {
+	if v.Line < 1 {
+		return v.Err.Error()
+	}
+	return fmt.Sprintf("at %d:%d: %v", v.Line, v.Column, v.Err)
+}
+
+func (v SubsetViolation) Unwrap() error { return v.Err }
+
+// CheckYAMLSubset scans src for all constructs that Load rejects regardless
+// of the Go type, which allows linting a document before it's bound to a type.
+// Reports YAML tags (ErrYAMLTagUsed), null literals other than `null`
+// (ErrYAMLBadNullLiteral), boolean literals other than `true` and `false`
+// (ErrYAMLBadBoolLiteral), merge keys (ErrYAMLMergeKey), redefined anchors
+// (ErrYAMLAnchorRedefined), unused anchors (ErrYAMLAnchorUnused), anchors with
+// implicit null values (ErrYAMLAnchorNoValue), multiple documents
+// (ErrYAMLMultidoc) and tabs used for indentation or separation (ErrYAMLTab).
+// Unlike the other rules, tabs are a style rule only checked by CheckYAMLSubset,
+// Load accepts tabs wherever the YAML parser does.
+// Since the type isn't known, `~` and variants of booleans such as `yes`
+// are reported even though they'd be accepted by fields with struct tag
+// `nullstyle:"tilde"` and by string fields respectively.
+// Violations are sorted by location. If src can't be parsed the returned
+// violations include ErrYAMLMalformed. Returns nil if there are no violations.
+func CheckYAMLSubset(src []byte) []SubsetViolation {
+	c := subsetChecker{
+		anchors: make(map[string]*yaml.Node),
+		used:    make(map[*yaml.Node]bool),
+	}
+	if len(bytes.TrimSpace(src)) == 0 {
+		return []SubsetViolation{{Err: ErrYAMLEmptyFile}}
+	}
+
+	multiline := make(map[int]bool) // Lines of multi-line scalars.
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	for i := 0; ; i++ {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			c.add(0, 0, fmt.Errorf("%w: %w", ErrYAMLMalformed, err))
+			break
+		}
+		if i > 0 {
+			c.add(doc.Line, doc.Column, ErrYAMLMultidoc)
+		}
+		c.check(&doc)
+		markMultilineScalars(&doc, multiline)
+	}
+	for _, n := range c.defined {
+		if !c.used[n] {
+			c.add(n.Line, n.Column, fmt.Errorf("anchor %q: %w",
+				n.Anchor, ErrYAMLAnchorUnused))
+		}
+	}
+	c.checkTabs(src, multiline)
+
+	slices.SortStableFunc(c.violations, func(a, b SubsetViolation) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+	return c.violations
+}
+
+type subsetChecker struct {
+	anchors    map[string]*yaml.Node // Name -> latest definition.
+	defined    []*yaml.Node          // All anchored nodes in document order.
+	used       map[*yaml.Node]bool   // Anchored nodes referenced by aliases.
+	violations []SubsetViolation
+}
+
+func (c *subsetChecker) add(line, column int, err error) {
+	c.violations = append(c.violations,
+		SubsetViolation{Line: line, Column: column, Err: err})
+}
+
+// check checks node and its children recursively.
+func (c *subsetChecker) check(n *yaml.Node) {
+	if n.Style&yaml.TaggedStyle != 0 {
+		c.add(n.Line, n.Column, fmt.Errorf("tag %q: %w", n.Tag, ErrYAMLTagUsed))
+	}
+	if n.Anchor != "" {
+		if prev, ok := c.anchors[n.Anchor]; ok {
+			c.add(n.Line, n.Column, fmt.Errorf("redefined anchor %q at %d:%d: %w",
+				n.Anchor, prev.Line, prev.Column, ErrYAMLAnchorRedefined))
+		}
+		c.anchors[n.Anchor] = n
+		c.defined = append(c.defined, n)
+		if n.Kind == yaml.ScalarNode && n.Tag == "!!null" && n.Value == "" {
+			c.add(n.Line, n.Column, fmt.Errorf("anchor %q: %w",
+				n.Anchor, ErrYAMLAnchorNoValue))
+		}
+	}
+	switch n.Kind {
+	case yaml.AliasNode:
+		c.used[n.Alias] = true
+	case yaml.ScalarNode:
+		if n.Style&(yaml.TaggedStyle|yaml.DoubleQuotedStyle|
+			yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			return // The value is explicitly typed.
+		}
+		switch v := n.Value; {
+		case v == "~" || strings.EqualFold(v, "null") && v != "null":
+			c.add(n.Line, n.Column, fmt.Errorf("%q: %w", v, ErrYAMLBadNullLiteral))
+		case isBoolVariant(v):
+			c.add(n.Line, n.Column, fmt.Errorf("%q: %w", v, ErrYAMLBadBoolLiteral))
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(n.Content); i += 2 {
+			if k := n.Content[i]; k.Tag == "!!merge" {
+				c.add(k.Line, k.Column, ErrYAMLMergeKey)
+			}
+		}
+	}
+	for _, n := range n.Content {
+		c.check(n)
+	}
+}
+
+// isBoolVariant returns true if v is a YAML 1.1 boolean literal
+// other than `true` and `false`.
+func isBoolVariant(v string) bool {
+	if v == "true" || v == "false" {
+		return false
+	}
+	switch strings.ToLower(v) {
+	case "true", "false", "yes", "no", "y", "n", "on", "off":
+		return true
+	}
+	return false
+}
+
+// markMultilineScalars marks the lines of scalars in n that span multiple
+// lines, such as block scalars, since tabs are content there.
+func markMultilineScalars(n *yaml.Node, lines map[int]bool) {
+	if n.Kind == yaml.ScalarNode {
+		count := strings.Count(n.Value, "\n")
+		if n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			count++ // Block scalar content starts on the next line.
+		}
+		for l := n.Line + 1; l <= n.Line+count; l++ {
+			lines[l] = true
+		}
+	}
+	for _, n := range n.Content {
+		markMultilineScalars(n, lines)
+	}
+}
+
+// checkTabs reports tabs in the indentation of lines and in the separation
+// after `-` and `:` indicators. Lines in multi-line scalars are skipped.
+func (c *subsetChecker) checkTabs(src []byte, multiline map[int]bool) {
+	for i, line := range strings.Split(string(src), "\n") {
+		if multiline[i+1] || !strings.Contains(line, "\t") {
+			continue
+		}
+		column := 0
+		report := func() { c.add(i+1, column+1, ErrYAMLTab) }
+		// Indentation and sequence indicators.
+		for column < len(line) {
+			switch line[column] {
+			case '\t':
+				report()
+				return
+			case ' ':
+				column++
+				continue
+			case '-':
+				if column+1 < len(line) &&
+					(line[column+1] == ' ' || line[column+1] == '\t') {
+					column++
+					continue
+				}
+			}
+			break
+		}
+		// Separation after the first mapping indicator outside of quotes.
+		var quote byte
+		for ; column < len(line); column++ {
+			switch b := line[column]; {
+			case quote != 0:
+				if b == quote {
+					quote = 0
+				}
+			case b == '"' || b == '\'':
+				quote = b
+			case b == '#':
+				column = len(line) // Comment.
+			case b == ':' && column+1 < len(line) && line[column+1] == '\t':
+				column++
+				report()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckYAMLSubset(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		require.Nil(t, yamagiconf.CheckYAMLSubset([]byte(`name: &name app
+enabled: true
+optional: null
+quoted: "yes"
+tilde: '~'
+labels:
+  team: *name
+script: |
+  echo	"tabs are content here"
+  exit 0
+list:
+  - a # comment	with tab
+  - "b:	c"
+`)))
+	})
+
+	t.Run("all_violations", func(t *testing.T) {
+		v := yamagiconf.CheckYAMLSubset([]byte("a: &x 1\n" +
+			"b: !!str text\n" +
+			"c: ~\n" +
+			"d:\tNULL\n" +
+			"e: &x\n" +
+			"f: *x\n" +
+			"<<: {g: 1}\n" +
+			"h: [yes, Off, True]\n" +
+			"i: &unused text\n" +
+			"---\n" +
+			"z: 1\n"))
+		errs := make([]string, len(v))
+		for i, v := range v {
+			errs[i] = v.Error()
+		}
+		require.Equal(t, []string{
+			`at 1:4: anchor "x": yaml anchors must be referenced at least once`,
+			`at 2:4: tag "!!str": avoid using YAML tags`,
+			`at 3:4: "~": must be null, ` +
+				`any other variants of null are not supported`,
+			`at 4:3: avoid using tabs for indentation and separation`,
+			`at 4:4: "NULL": must be null, ` +
+				`any other variants of null are not supported`,
+			`at 5:4: redefined anchor "x" at 1:4: ` +
+				`yaml anchors must be unique throughout the whole document`,
+			`at 5:4: anchor "x": don't use anchors with implicit null value`,
+			`at 7:1: avoid using YAML merge keys`,
+			`at 8:5: "yes": must be either false or true, ` +
+				`other variants of boolean literals of YAML are not supported`,
+			`at 8:10: "Off": must be either false or true, ` +
+				`other variants of boolean literals of YAML are not supported`,
+			`at 8:15: "True": must be either false or true, ` +
+				`other variants of boolean literals of YAML are not supported`,
+			`at 9:4: anchor "unused": yaml anchors must be referenced at least once`,
+			`at 10:1: multi-document YAML files are not supported`,
+		}, errs)
+		require.ErrorIs(t, v[1], yamagiconf.ErrYAMLTagUsed)
+		require.ErrorIs(t, v[3], yamagiconf.ErrYAMLTab)
+		require.Equal(t, 7, v[7].Line)
+		require.Equal(t, 1, v[7].Column)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		v := yamagiconf.CheckYAMLSubset([]byte("a:\n\t- b\n"))
+		require.Len(t, v, 2)
+		require.ErrorIs(t, v[0], yamagiconf.ErrYAMLMalformed)
+		require.Zero(t, v[0].Line)
+		require.ErrorIs(t, v[1], yamagiconf.ErrYAMLTab)
+		require.Equal(t, "at 2:1: avoid using tabs for indentation and separation",
+			v[1].Error())
+	})
+
+	t.Run("tab_accepted_by_load", func(t *testing.T) {
+		src := "name:\tx\n"
+		v := yamagiconf.CheckYAMLSubset([]byte(src))
+		require.Len(t, v, 1)
+		require.ErrorIs(t, v[0], yamagiconf.ErrYAMLTab)
+
+		var c struct {
+			Name string `yaml:"name"`
+		}
+		require.NoError(t, yamagiconf.Load(src, &c))
+		require.Equal(t, "x", c.Name)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		v := yamagiconf.CheckYAMLSubset([]byte("\n"))
+		require.Len(t, v, 1)
+		require.ErrorIs(t, v[0], yamagiconf.ErrYAMLEmptyFile)
+	})
+}
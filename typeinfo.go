@@ -0,0 +1,159 @@
+package yamagiconf
+
+import (
+	"cmp"
+	"encoding"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TypeInfo is the metadata of a config type precomputed by PrecomputeType.
+// TypeInfo is immutable and safe for concurrent use.
+type TypeInfo struct {
+	tp      reflect.Type
+	options string // See typeOptions.
+	fields  []FieldInfo
+
+	// yamlFields maps the struct types within tp to the YAML names
+	// of their fields, see collectYAMLFieldNames.
+	yamlFields map[reflect.Type]map[string]string
+}
+
+// FieldInfo describes a struct field of a config type.
+type FieldInfo struct {
+	// GoPath is the path of the field such as `Config.Server.Port`.
+	// Map keys and values are denoted by `[key]` and `[value]`,
+	// slice and array items share the path of the slice or array.
+	GoPath string
+
+	// YAMLName is the name of the field in the YAML document,
+	// empty for embedded structs since their fields are inlined.
+	YAMLName string
+
+	// Env and Validate are the `env` and `validate` struct tags.
+	Env, Validate string
+}
+
+// Type returns the config type described by i.
+func (i TypeInfo) Type() reflect.Type { return i.tp }
+
+// Fields returns all fields of the config type in declaration order
+// (depth-first) excluding unexported and ignored fields.
+// Fields of types implementing encoding.TextUnmarshaler or yaml.Unmarshaler
+// aren't included.
+func (i TypeInfo) Fields() []FieldInfo { return slices.Clone(i.fields) }
+
+// PrecomputeType validates type T just like ValidateType and returns
+// its metadata which LoadWithTypeInfo uses to skip validating the type
+// and looking up the YAML field names of its structs on every call.
+// This is useful on hot paths where the same config type is loaded many times.
+// Only WithAllowRecursiveTypes, WithAllowPlatformInts, WithPolymorphic,
+// WithEnumMapping and WithIntEnum are relevant in opts and LoadWithTypeInfo
+// must be passed the same ones.
+func PrecomputeType[T any](opts ...Option) (TypeInfo, error) {
+	o := newOptions(opts)
+	tp := reflect.TypeFor[T]()
+	if err := o.validateType(tp); err != nil {
+		return TypeInfo{}, err
+	}
+	info := TypeInfo{
+		tp: tp, options: o.typeOptions(),
+		yamlFields: make(map[reflect.Type]map[string]string),
+	}
+	info.collectFields(getConfigTypeName(tp), tp, nil)
+	return info, nil
+}
+
+// typeOptions returns a description of the options relevant to PrecomputeType
+// which is equal for equal options.
+func (o *options) typeOptions() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "maxdepth=%d platformints=%t", o.maxDepth, o.allowPlatformInts)
+	for _, tp := range sortedTypes(o.polymorphic) {
+		p := o.polymorphic[tp]
+		fmt.Fprintf(&b, " polymorphic(%s)=%s:%v", tp, p.key, p.registry)
+	}
+	for _, tp := range sortedTypes(o.enums) {
+		fmt.Fprintf(&b, " enum(%s)=%v", tp, o.enums[tp].values)
+	}
+	for _, tp := range sortedTypes(o.intEnums) {
+		fmt.Fprintf(&b, " intenum(%s)=%s", tp, o.intEnums[tp].allowed)
+	}
+	return b.String()
+}
+
+// sortedTypes returns the keys of m sorted by package path and name.
+func sortedTypes[V any](m map[reflect.Type]V) []reflect.Type {
+	return slices.SortedFunc(maps.Keys(m), func(a, b reflect.Type) int {
+		return cmp.Or(
+			strings.Compare(a.PkgPath(), b.PkgPath()),
+			strings.Compare(a.String(), b.String()),
+		)
+	})
+}
+
+// collectFields appends the fields of tp to i.fields.
+// stack holds the struct types being collected to stop at recursive types.
+func (i *TypeInfo) collectFields(path string, tp reflect.Type, stack []reflect.Type) {
+	if implementsInterface[encoding.TextUnmarshaler](tp) ||
+		implementsInterface[yaml.Unmarshaler](tp) {
+		return
+	}
+	switch tp.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		i.collectFields(path, tp.Elem(), stack)
+	case reflect.Map:
+		i.collectFields(path+"[key]", tp.Key(), stack)
+		i.collectFields(path+"[value]", tp.Elem(), stack)
+	case reflect.Struct:
+		if slices.Contains(stack, tp) {
+			return
+		}
+		stack = append(stack, tp)
+		if i.yamlFields[tp] == nil {
+			names := make(map[string]string, tp.NumField())
+			collectYAMLFieldNames("", tp, names)
+			i.yamlFields[tp] = names
+		}
+		for j := range tp.NumField() {
+			f := tp.Field(j)
+			yamlTag := getYAMLFieldName(f.Tag)
+			if !f.IsExported() || yamlTag == "-" {
+				continue
+			}
+			path := path + "." + f.Name
+			i.fields = append(i.fields, FieldInfo{
+				GoPath:   path,
+				YAMLName: yamlTag,
+				Env:      f.Tag.Get("env"),
+				Validate: f.Tag.Get("validate"),
+			})
+			i.collectFields(path, f.Type, stack)
+		}
+	}
+}
+
+// LoadWithTypeInfo is similar to LoadWithOptions but skips validating type T
+// and uses the YAML field names of its structs in info
+// since info was already computed for it by PrecomputeType.
+// Returns ErrTypeInfoMismatch if info wasn't computed for T
+// or with different options, see PrecomputeType.
+func LoadWithTypeInfo[T any, S string | []byte](
+	info TypeInfo, yamlSource S, config *T, opts ...Option,
+) error {
+	if tp := reflect.TypeFor[T](); info.tp != tp {
+		return fmt.Errorf("%w: computed for %v, expected %s",
+			ErrTypeInfoMismatch, info.tp, tp.String())
+	}
+	o := newOptions(opts)
+	if o.typeOptions() != info.options {
+		return fmt.Errorf("%w: computed with different options", ErrTypeInfoMismatch)
+	}
+	o.typeValidated, o.yamlFields = true, info.yamlFields
+	return load(o, yamlSource, config)
+}
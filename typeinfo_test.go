@@ -0,0 +1,187 @@
+package yamagiconf_test
+
+import (
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+type TypeInfoServer struct {
+	Host string `yaml:"host" env:"HOST" validate:"required"`
+	Port uint16 `yaml:"port"`
+}
+
+type TypeInfoConfig struct {
+	TypeInfoEmbedded `yaml:",inline"`
+	Servers          map[string]TypeInfoServer `yaml:"servers"`
+	List             []*TypeInfoServer         `yaml:"list" validate:"min=1"`
+	Level            TextUnmarshalerSlice      `yaml:"level"`
+	Ignored          string                    `yaml:"-"`
+	unexported       string
+}
+
+type TypeInfoEmbedded struct {
+	Name string `yaml:"name" validate:"min=1"`
+}
+
+const typeInfoSrc = "name: x\n" +
+	"servers:\n" +
+	"  a:\n" +
+	"    host: a.example\n" +
+	"    port: 8080\n" +
+	"list:\n" +
+	"  - host: b.example\n" +
+	"    port: 8081\n" +
+	"level: valid\n"
+
+func TestPrecomputeType(t *testing.T) {
+	info, err := yamagiconf.PrecomputeType[TypeInfoConfig]()
+	require.NoError(t, err)
+	require.Equal(t, "TypeInfoConfig", info.Type().Name())
+	require.Equal(t, []yamagiconf.FieldInfo{
+		{GoPath: "TypeInfoConfig.TypeInfoEmbedded"},
+		{GoPath: "TypeInfoConfig.TypeInfoEmbedded.Name", YAMLName: "name", Validate: "min=1"},
+		{GoPath: "TypeInfoConfig.Servers", YAMLName: "servers"},
+		{
+			GoPath: "TypeInfoConfig.Servers[value].Host", YAMLName: "host",
+			Env: "HOST", Validate: "required",
+		},
+		{GoPath: "TypeInfoConfig.Servers[value].Port", YAMLName: "port"},
+		{GoPath: "TypeInfoConfig.List", YAMLName: "list", Validate: "min=1"},
+		{
+			GoPath: "TypeInfoConfig.List.Host", YAMLName: "host",
+			Env: "HOST", Validate: "required",
+		},
+		{GoPath: "TypeInfoConfig.List.Port", YAMLName: "port"},
+		{GoPath: "TypeInfoConfig.Level", YAMLName: "level"},
+	}, info.Fields())
+
+	_, err = yamagiconf.PrecomputeType[struct {
+		Int int `yaml:"int"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeUnsupported)
+}
+
+func TestLoadWithTypeInfo(t *testing.T) {
+	info, err := yamagiconf.PrecomputeType[TypeInfoConfig]()
+	require.NoError(t, err)
+
+	var c TypeInfoConfig
+	err = yamagiconf.LoadWithTypeInfo(info, typeInfoSrc, &c)
+	require.NoError(t, err)
+	var expect TypeInfoConfig
+	require.NoError(t, yamagiconf.Load(typeInfoSrc, &expect))
+	require.Equal(t, expect, c)
+
+	t.Run("validation", func(t *testing.T) {
+		var c TypeInfoConfig
+		err := yamagiconf.LoadWithTypeInfo(info,
+			"name: x\nservers: {}\nlist: []\nlevel: valid\n", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 3:7: "list" violates validation rule: "min"`,
+			err.Error())
+	})
+
+	t.Run("unknown_field", func(t *testing.T) {
+		var c TypeInfoConfig
+		err := yamagiconf.LoadWithTypeInfo(info, "name: x\nservers: {}\n"+
+			"list:\n  - hots: c.example\n    port: 8082\nlevel: valid\n", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 4:5: malformed YAML: field "hots" not found `+
+			`in type yamagiconf_test.TypeInfoServer`, err.Error())
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		type TestConfig struct {
+			Name string `yaml:"name"`
+		}
+		var c TestConfig
+		err := yamagiconf.LoadWithTypeInfo(info, "name: x\n", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInfoMismatch)
+		require.Equal(t, "type info mismatch: "+
+			"computed for yamagiconf_test.TypeInfoConfig, "+
+			"expected yamagiconf_test.TestConfig", err.Error())
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		info, err := yamagiconf.PrecomputeType[Menu](
+			yamagiconf.WithAllowRecursiveTypes(4))
+		require.NoError(t, err)
+
+		var m Menu
+		err = yamagiconf.LoadWithTypeInfo(info, "title: x\nitems: []\nnext: null\n", &m)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInfoMismatch)
+		require.Equal(t, "type info mismatch: computed with different options",
+			err.Error())
+
+		err = yamagiconf.LoadWithTypeInfo(info, "title: x\nitems: []\nnext: null\n", &m,
+			yamagiconf.WithAllowRecursiveTypes(8))
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInfoMismatch)
+
+		err = yamagiconf.LoadWithTypeInfo(info, "title: x\nitems: []\nnext: null\n", &m,
+			yamagiconf.WithAllowRecursiveTypes(4))
+		require.NoError(t, err)
+	})
+
+	t.Run("enum_mapping", func(t *testing.T) {
+		type TestConfig struct {
+			Color Color `yaml:"color"`
+		}
+		info, err := yamagiconf.PrecomputeType[TestConfig](
+			yamagiconf.WithEnumMapping(colorNames))
+		require.NoError(t, err)
+
+		var c TestConfig
+		err = yamagiconf.LoadWithTypeInfo(info, "color: red\n", &c,
+			yamagiconf.WithEnumMapping(map[string]Color{"red": ColorRed}))
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInfoMismatch)
+
+		err = yamagiconf.LoadWithTypeInfo(info, "color: blue\n", &c,
+			yamagiconf.WithEnumMapping(colorNames))
+		require.NoError(t, err)
+		require.Equal(t, ColorBlue, c.Color)
+	})
+
+	t.Run("int_enum", func(t *testing.T) {
+		type TestConfig struct {
+			Level Level `yaml:"level"`
+		}
+		info, err := yamagiconf.PrecomputeType[TestConfig](
+			yamagiconf.WithIntEnum(LevelDebug, LevelInfo))
+		require.NoError(t, err)
+
+		var c TestConfig
+		err = yamagiconf.LoadWithTypeInfo(info, "level: 0\n", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInfoMismatch)
+
+		err = yamagiconf.LoadWithTypeInfo(info, "level: 0\n", &c,
+			yamagiconf.WithIntEnum(LevelInfo, LevelDebug))
+		require.NoError(t, err)
+	})
+}
+
+func BenchmarkLoad(b *testing.B) {
+	b.ReportAllocs()
+	for range b.N {
+		var c TypeInfoConfig
+		if err := yamagiconf.Load(typeInfoSrc, &c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadWithTypeInfo(b *testing.B) {
+	info, err := yamagiconf.PrecomputeType[TypeInfoConfig]()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		var c TypeInfoConfig
+		if err := yamagiconf.LoadWithTypeInfo(info, typeInfoSrc, &c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// ErrInvalidLogLevel is returned for unknown log level names.
+var ErrInvalidLogLevel = errors.New("invalid log level")
+
+// LogLevel is a logging severity level.
+// The zero value is LogLevelInfo.
+type LogLevel int8
+
+const (
+	LogLevelDebug LogLevel = -1
+	LogLevelInfo  LogLevel = 0
+	LogLevelWarn  LogLevel = 1
+	LogLevelError LogLevel = 2
+)
+
+// LogLevels lists all valid log levels ordered by severity.
+var LogLevels = [...]LogLevel{LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError}
+
+// String returns the lower case name of the log level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	}
+	return fmt.Sprintf("LogLevel(%d)", int8(l))
+}
+
+// Validate implements yamagiconf.Validator.
+func (l LogLevel) Validate() error {
+	switch l {
+	case LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError:
+		return nil
+	}
+	return fmt.Errorf("%w: %d", ErrInvalidLogLevel, int8(l))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (l LogLevel) MarshalText() ([]byte, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+// Accepts "debug", "info", "warn" and "error" case-insensitively.
+func (l *LogLevel) UnmarshalText(t []byte) error {
+	for _, v := range LogLevels {
+		if strings.EqualFold(string(t), v.String()) {
+			*l = v
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q, expected one of: debug, info, warn, error",
+		ErrInvalidLogLevel, string(t))
+}
+
+// SlogLevel returns the log/slog equivalent of l.
+func (l LogLevel) SlogLevel() slog.Level {
+	switch l {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelWarn:
+		return slog.LevelWarn
+	case LogLevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
@@ -0,0 +1,150 @@
+package types_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/romshark/yamagiconf/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevel(t *testing.T) {
+	type TestConfig struct {
+		Level    types.LogLevel                    `yaml:"level"`
+		LevelPtr *types.LogLevel                   `yaml:"level-ptr"`
+		Levels   []types.LogLevel                  `yaml:"levels"`
+		ByLevel  map[types.LogLevel]string         `yaml:"by-level"`
+		Env      types.LogLevel                    `yaml:"env" env:"LOG_LEVEL"`
+		Mapping  map[string]types.LogLevel         `yaml:"mapping"`
+		Unused   map[types.LogLevel]types.LogLevel `yaml:"unused"`
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		t.Setenv("LOG_LEVEL", "ERROR")
+		var c TestConfig
+		err := yamagiconf.Load(`
+level: Warn
+level-ptr: debug
+levels: [debug, INFO, warn, error]
+by-level:
+  info: i
+env: info
+mapping:
+  x: eRRoR
+unused: null
+`, &c)
+		require.NoError(t, err)
+		require.Equal(t, types.LogLevelWarn, c.Level)
+		require.Equal(t, types.LogLevelDebug, *c.LevelPtr)
+		require.Equal(t, []types.LogLevel{
+			types.LogLevelDebug, types.LogLevelInfo,
+			types.LogLevelWarn, types.LogLevelError,
+		}, c.Levels)
+		require.Equal(t, map[types.LogLevel]string{types.LogLevelInfo: "i"}, c.ByLevel)
+		require.Equal(t, types.LogLevelError, c.Env)
+		require.Equal(t, map[string]types.LogLevel{"x": types.LogLevelError}, c.Mapping)
+
+		b, err := yamagiconf.Marshal(c)
+		require.NoError(t, err)
+		require.Equal(t, `level: warn
+level-ptr: debug
+levels:
+  - debug
+  - info
+  - warn
+  - error
+by-level:
+  info: i
+env: error
+mapping:
+  x: error
+unused: null
+`, string(b))
+
+		var loaded TestConfig
+		t.Setenv("LOG_LEVEL", "error")
+		require.NoError(t, yamagiconf.Load(b, &loaded))
+		require.Equal(t, c, loaded)
+	})
+
+	t.Run("err_invalid", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.Load(`
+level: info
+level-ptr: null
+levels: [debug, verbose]
+by-level: {}
+env: info
+mapping: {}
+unused: null
+`, &c)
+		require.ErrorIs(t, err, types.ErrInvalidLogLevel)
+		require.Equal(t, `at 4:17: "levels" (TestConfig.Levels[1]): `+
+			`invalid log level "verbose", expected one of: `+
+			`debug, info, warn, error`, err.Error())
+	})
+
+	t.Run("err_invalid_map_key", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.Load(`
+level: info
+level-ptr: null
+levels: []
+by-level:
+  trace: x
+env: info
+mapping: {}
+unused: null
+`, &c)
+		require.ErrorIs(t, err, types.ErrInvalidLogLevel)
+		require.Equal(t, `at 6:3: "by-level" (TestConfig.ByLevel["trace"]): `+
+			`invalid log level "trace", expected one of: `+
+			`debug, info, warn, error`, err.Error())
+	})
+
+	t.Run("err_invalid_env", func(t *testing.T) {
+		t.Setenv("LOG_LEVEL", "loud")
+		var c TestConfig
+		err := yamagiconf.Load(`
+level: info
+level-ptr: null
+levels: []
+by-level: {}
+env: info
+mapping: {}
+unused: null
+`, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.ErrorIs(t, err, types.ErrInvalidLogLevel)
+	})
+
+	t.Run("err_invalid_in_code", func(t *testing.T) {
+		err := yamagiconf.Validate(struct {
+			Level types.LogLevel `yaml:"level"`
+		}{Level: 5})
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.ErrorIs(t, err, types.ErrInvalidLogLevel)
+
+		_, err = yamagiconf.Marshal(struct {
+			Level types.LogLevel `yaml:"level"`
+		}{Level: 5})
+		require.ErrorIs(t, err, types.ErrInvalidLogLevel)
+	})
+}
+
+func TestLogLevelString(t *testing.T) {
+	require.Equal(t, "debug", types.LogLevelDebug.String())
+	require.Equal(t, "info", types.LogLevelInfo.String())
+	require.Equal(t, "warn", types.LogLevelWarn.String())
+	require.Equal(t, "error", types.LogLevelError.String())
+	require.Equal(t, "LogLevel(5)", types.LogLevel(5).String())
+}
+
+func TestLogLevelSlogLevel(t *testing.T) {
+	require.Equal(t, slog.LevelDebug, types.LogLevelDebug.SlogLevel())
+	require.Equal(t, slog.LevelInfo, types.LogLevelInfo.SlogLevel())
+	require.Equal(t, slog.LevelWarn, types.LogLevelWarn.SlogLevel())
+	require.Equal(t, slog.LevelError, types.LogLevelError.SlogLevel())
+}
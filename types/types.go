@@ -0,0 +1,6 @@
+// Package types provides commonly needed configuration types that work
+// with yamagiconf out of the box. All types implement encoding.TextUnmarshaler
+// and encoding.TextMarshaler which makes them usable in YAML documents,
+// as map keys and in fields with `env` struct tags, and they implement
+// yamagiconf.Validator to reject invalid values assigned in code.
+package types
@@ -0,0 +1,40 @@
+package yamagiconf
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// URL is a URL parsed by url.Parse. Declare fields as *URL to allow `null`,
+// which leaves the field nil. The zero value has a nil inner URL and is invalid.
+//
+// Don't combine URL with the `url` validator struct tag since go-playground's
+// validator expects a string and panics on struct types, the value is already
+// guaranteed to be a valid URL. Use `required` to reject nil *URL fields.
+type URL struct{ *url.URL }
+
+// Validate implements Validator.
+func (u URL) Validate() error {
+	if u.URL == nil {
+		return fmt.Errorf("%w: nil", ErrInvalidURL)
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (u URL) MarshalText() ([]byte, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+	return []byte(u.URL.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *URL) UnmarshalText(t []byte) error {
+	p, err := url.Parse(string(t))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	u.URL = p
+	return nil
+}
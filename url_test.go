@@ -0,0 +1,84 @@
+package yamagiconf_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/romshark/yamagiconf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURL(t *testing.T) {
+	type TestConfig struct {
+		Endpoint yamagiconf.URL            `yaml:"endpoint"`
+		Proxy    *yamagiconf.URL           `yaml:"proxy"`
+		Mirrors  []yamagiconf.URL          `yaml:"mirrors"`
+		Env      yamagiconf.URL            `yaml:"env" env:"URL"`
+		ByName   map[string]yamagiconf.URL `yaml:"by-name"`
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		t.Setenv("URL", "postgres://db:5432/app")
+		var c TestConfig
+		err := yamagiconf.Load(`
+endpoint: https://example.com/api?v=1
+proxy: null
+mirrors: ["https://a.example.com", "/relative/path"]
+env: x
+by-name:
+  b: http://b.example.com
+`, &c)
+		require.NoError(t, err)
+		require.Equal(t, "https", c.Endpoint.Scheme)
+		require.Equal(t, "example.com", c.Endpoint.Host)
+		require.Equal(t, "/api", c.Endpoint.Path)
+		require.Nil(t, c.Proxy)
+		require.Equal(t, "/relative/path", c.Mirrors[1].Path)
+		require.Equal(t, "db:5432", c.Env.Host)
+		require.Equal(t, "b.example.com", c.ByName["b"].Host)
+
+		c.Proxy = &yamagiconf.URL{URL: &url.URL{Scheme: "http", Host: "proxy:3128"}}
+		b, err := yamagiconf.Marshal(c)
+		require.NoError(t, err)
+		require.Equal(t, `endpoint: https://example.com/api?v=1
+proxy: http://proxy:3128
+mirrors:
+  - https://a.example.com
+  - /relative/path
+env: postgres://db:5432/app
+by-name:
+  b: http://b.example.com
+`, string(b))
+
+		var loaded TestConfig
+		require.NoError(t, yamagiconf.Load(b, &loaded))
+		require.Equal(t, c, loaded)
+	})
+
+	t.Run("err_invalid", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.Load(`
+endpoint: "http://[::1"
+proxy: null
+mirrors: []
+env: x
+by-name: {}
+`, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrInvalidURL)
+		require.Equal(t, `at 2:11: "endpoint" (TestConfig.Endpoint): `+
+			`invalid URL: parse "http://[::1": missing ']' in host`, err.Error())
+	})
+
+	t.Run("err_invalid_in_code", func(t *testing.T) {
+		err := yamagiconf.Validate(struct {
+			Endpoint yamagiconf.URL `yaml:"endpoint"`
+		}{})
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.ErrorIs(t, err, yamagiconf.ErrInvalidURL)
+
+		_, err = yamagiconf.Marshal(struct {
+			Endpoint yamagiconf.URL `yaml:"endpoint"`
+		}{})
+		require.ErrorIs(t, err, yamagiconf.ErrInvalidURL)
+	})
+}
@@ -7,17 +7,23 @@ package yamagiconf
 
 import (
 	"bytes"
+	"cmp"
 	"encoding"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
-	"sort"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/go-playground/validator/v10"
 	"gopkg.in/yaml.v3"
@@ -28,9 +34,36 @@ import (
 // Errors in the Go target type begin with ErrType...
 // Errors in the env variables begin with ErrEnv...
 var (
-	ErrConfigNil     = errors.New("cannot load into nil config")
-	ErrValidation    = errors.New("validation")
-	ErrValidationTag = errors.New("violates validation rule")
+	ErrConfigNil                = errors.New("cannot load into nil config")
+	ErrValidation               = errors.New("validation")
+	ErrValidationTag            = errors.New("violates validation rule")
+	ErrValidationRequiredNull   = errors.New("required field must not be null")
+	ErrValidatorPanic           = errors.New("validator panicked")
+	ErrInvalidValidatorFunc     = errors.New("invalid validator function")
+	ErrRawValidation            = errors.New("raw validation")
+	ErrEditInvalidPath          = errors.New("invalid edit path")
+	ErrInvalidEnumValue         = errors.New("invalid enum value")
+	ErrEnumViolation            = errors.New("value not in enum")
+	ErrIntEnumValue             = errors.New("invalid value")
+	ErrInsecureFileMode         = errors.New("file permissions too permissive")
+	ErrInvalidDefaultValue      = errors.New("invalid default value")
+	ErrDirDuplicateKey          = errors.New("duplicate key in directory")
+	ErrScalarResolver           = errors.New("scalar resolver")
+	ErrPatternMismatch          = errors.New("does not match pattern")
+	ErrDecryption               = errors.New("decryption")
+	ErrFieldOrder               = errors.New("invalid field order")
+	ErrInvalidUUID              = errors.New("invalid UUID")
+	ErrInvalidPEM               = errors.New("invalid PEM block")
+	ErrInvalidPhases            = errors.New("invalid phases")
+	ErrNotMultipleOf            = errors.New("must be a multiple of")
+	ErrTooLong                  = errors.New("too long")
+	ErrNotSorted                = errors.New("not sorted")
+	ErrNotWithinCIDR            = errors.New("not within")
+	ErrInvalidIPAddress         = errors.New("invalid IP address")
+	ErrInvalidURL               = errors.New("invalid URL")
+	ErrBudgetExceeded           = errors.New("exceeds budget")
+	ErrReferencedFileMissing    = errors.New("referenced file missing")
+	ErrReferencedFileUnreadable = errors.New("referenced file not readable")
 
 	ErrYAMLMultidoc        = errors.New("multi-document YAML files are not supported")
 	ErrYAMLEmptyFile       = errors.New("empty file")
@@ -41,12 +74,15 @@ var (
 	ErrYAMLTagRedefined    = errors.New("a yaml struct tag must be unique")
 	ErrYAMLAnchorRedefined = errors.New("yaml anchors must be unique throughout " +
 		"the whole document")
-	ErrYAMLAnchorUnused   = errors.New("yaml anchors must be referenced at least once")
-	ErrYAMLAnchorNoValue  = errors.New("don't use anchors with implicit null value")
-	ErrYAMLMissingConfig  = errors.New("missing field in config file")
-	ErrYAMLBadBoolLiteral = errors.New("must be either false or true, " +
+	ErrYAMLAnchorUnused      = errors.New("yaml anchors must be referenced at least once")
+	ErrYAMLAnchorNoValue     = errors.New("don't use anchors with implicit null value")
+	ErrYAMLAnchorName        = errors.New("anchor name rejected by policy")
+	ErrYAMLAliasTypeMismatch = errors.New("alias used for incompatible type")
+	ErrYAMLMissingConfig     = errors.New("missing field in config file")
+	ErrYAMLBadBoolLiteral    = errors.New("must be either false or true, " +
 		"other variants of boolean literals of YAML are not supported")
 	ErrYAMLTagUsed          = errors.New("avoid using YAML tags")
+	ErrYAMLBadDuration      = errors.New("must be a duration such as 30s or 1h30m")
 	ErrYAMLNullOnNonPointer = errors.New("cannot assign null to non-pointer type")
 	ErrYAMLBadNullLiteral   = errors.New("must be null, " +
 		"any other variants of null are not supported")
@@ -54,6 +90,18 @@ var (
 		"target type implements encoding.TextUnmarshaler")
 	ErrYAMLMergeKey = errors.New("avoid using YAML merge keys")
 
+	// ErrYAMLTab is only reported by CheckYAMLSubset, Load accepts tabs.
+	ErrYAMLTab = errors.New("avoid using tabs for indentation and separation")
+
+	ErrYAMLRootNotSequence          = errors.New("root must be a sequence")
+	ErrYAMLTooDeep                  = errors.New("nesting too deep")
+	ErrYAMLInvalidQuotedNumber      = errors.New("invalid quoted number")
+	ErrYAMLDuplicateMapKey          = errors.New("duplicate map key")
+	ErrYAMLDuplicateKey             = errors.New("duplicate key")
+	ErrYAMLPolymorphic              = errors.New("invalid polymorphic value")
+	ErrYAMLEmptyValueForUnmarshaler = errors.New("empty value for " +
+		"non-pointer type implementing an unmarshaler interface")
+
 	// ErrYAMLEmptyArrayItem applies to both Go arrays and slices even though
 	// an empty item would be parsed correctly as zero-value in case of Go arrays
 	// to preserve consistency and avoid having exceptional behavior for arrays.
@@ -63,6 +111,8 @@ var (
 	ErrTypeRecursive   = errors.New("recursive type")
 	ErrTypeIllegalRoot = errors.New("root type must be a struct type and must not " +
 		"implement encoding.TextUnmarshaler and yaml.Unmarshaler")
+	ErrTypeIllegalScalarRoot = errors.New("scalar root type must be " +
+		"a primitive type, time.Duration or implement encoding.TextUnmarshaler")
 	ErrTypeMissingYAMLTag     = errors.New("missing yaml struct tag")
 	ErrTypeEnvTagOnUnexported = errors.New("env tag on unexported field")
 	ErrTypeTagOnInterfaceImpl = errors.New("implementations of interfaces " +
@@ -75,8 +125,34 @@ var (
 	ErrTypeEnvVarOnUnsupportedType = errors.New("env var on unsupported type")
 	ErrTypeUnsupported             = errors.New("unsupported type")
 	ErrTypeUnsupportedPtrType      = errors.New("unsupported pointer type")
+	ErrTypeInvalidNullStyleTag     = errors.New("invalid nullstyle struct tag")
+	ErrTypeInvalidDefaultTag       = errors.New("invalid default struct tag")
+	ErrTypeInvalidEnvDefaultTag    = errors.New("invalid envDefault struct tag")
+	ErrTypeInvalidValidateWhenTag  = errors.New("invalid validate_when struct tag")
+	ErrTypeInvalidRedactTag        = errors.New("invalid redact struct tag")
+	ErrTypeInvalidNormalizeTag     = errors.New("invalid normalize struct tag")
+	ErrTypeInvalidResolveTag       = errors.New("invalid resolve struct tag")
+	ErrTypeInvalidPatternTag       = errors.New("invalid pattern struct tag")
+	ErrTypeInvalidEncryptedTag     = errors.New("invalid encrypted struct tag")
+	ErrTypeInvalidOrderTag         = errors.New("invalid before or after struct tag")
+	ErrTypeInvalidFormatTag        = errors.New("invalid format struct tag")
+	ErrTypeInvalidMultipleOfTag    = errors.New("invalid multipleof struct tag")
+	ErrTypeInvalidMaxLenTag        = errors.New("invalid maxbytes or maxrunes struct tag")
+	ErrTypeInvalidSortedTag        = errors.New("invalid sorted struct tag")
+	ErrTypeInvalidCIDRTag          = errors.New("invalid cidr struct tag")
+	ErrTypeInvalidSumBudgetTag     = errors.New("invalid sumbudget struct tag")
+	ErrTypeInvalidKeyNormalizeTag  = errors.New("invalid keynormalize struct tag")
+	ErrTypeInvalidFileExistsTag    = errors.New("invalid fileexists struct tag")
+	ErrTypeInfoMismatch            = errors.New("type info mismatch")
+	ErrTypeInvalidEnum             = errors.New("invalid Enum implementation")
+	ErrTypeNoTextMarshaler         = errors.New("type implements " +
+		"encoding.TextUnmarshaler but not encoding.TextMarshaler")
 
 	ErrEnvInvalidVar = errors.New("invalid env var")
+	ErrEnvMissingVar = errors.New("missing env var")
+	ErrEnvConflict   = errors.New("conflicting env var")
+
+	ErrEnvInvalidReference = errors.New("invalid env var reference")
 )
 
 // LoadFile reads and validates the configuration of type T from a YAML file.
@@ -84,10 +160,12 @@ var (
 //   - ValidateType returns an error for T.
 //   - the yaml file is empty or not found.
 //   - the yaml file doesn't contain a field specified by T.
-//   - the yaml file is missing a field specified by T.
+//   - the yaml file is missing a field specified by T
+//     that has no `default` struct tag.
 //   - the yaml file contains values that don't pass validation.
 //   - the yaml file contains boolean literals other than `true` and `false`.
-//   - the yaml file contains null values other than `null` (`~`, etc.).
+//   - the yaml file contains unquoted null values other than `null` (`~`, etc.),
+//     except for `~` on fields with struct tag `nullstyle:"tilde"`.
 //   - the yaml file assigns `null` to a non-pointer Go type.
 //   - the yaml file contains any YAML tags (https://yaml.org/spec/1.2.2/#3212-tags).
 //   - the yaml file contains any redeclared anchors.
@@ -95,131 +173,768 @@ var (
 //   - the yaml file contains any anchors with implicit null value (no value).
 //   - the yaml file assigns non-string values to Go types implementing the
 //     encoding.TextUnmarshaler interface.
+//   - the yaml file contains distinct map keys that resolve to the same
+//     Go map key, such as `1` and `0x1` for map[int32]T.
 func LoadFile[T any](yamlFilePath string, config *T) error {
+	return LoadFileWithOptions(yamlFilePath, config)
+}
+
+// LoadFileWithOptions is similar to LoadFile but accepts options.
+func LoadFileWithOptions[T any](
+	yamlFilePath string, config *T, opts ...Option,
+) error {
 	if config == nil {
 		return ErrConfigNil
 	}
+	o := newOptions(opts)
+	o.baseDir = filepath.Dir(yamlFilePath)
+	if err := o.checkFileMode(yamlFilePath); err != nil {
+		return err
+	}
 
 	yamlSrcBytes, err := os.ReadFile(yamlFilePath)
 	if err != nil {
 		return fmt.Errorf("reading file %q: %w", yamlFilePath, err)
 	}
-	return Load(yamlSrcBytes, config)
+	return load(o, yamlSrcBytes, config)
+}
+
+// LoadFS is similar to LoadFile but reads the YAML file name from fsys,
+// such as an embed.FS. Errors returned by fsys are wrapped,
+// so a missing file can be detected using errors.Is(err, fs.ErrNotExist).
+func LoadFS[T any](fsys fs.FS, name string, config *T) error {
+	return LoadFSWithOptions(fsys, name, config)
+}
+
+// LoadFSWithOptions is similar to LoadFS but accepts options.
+// Option WithRequireFileMode doesn't apply since fsys may not
+// have file permissions.
+func LoadFSWithOptions[T any](
+	fsys fs.FS, name string, config *T, opts ...Option,
+) error {
+	if config == nil {
+		return ErrConfigNil
+	}
+	src, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("reading file %q: %w", name, err)
+	}
+	return load(newOptions(opts), src, config)
+}
+
+// LoadFileWithLocal is similar to LoadFileWithOptions but if a sibling file
+// with the ".local" suffix before the extension exists
+// (config.local.yaml for config.yaml) then it's deep-merged over yamlFilePath
+// before validation. The local file may therefore be partial.
+// Mappings are merged key by key, any other value in the local file
+// replaces the value in yamlFilePath entirely.
+// A missing or empty local file is not an error.
+// Error locations refer to the file the value originates from and anchors
+// only referenced by values replaced by the local file are reported as unused.
+func LoadFileWithLocal[T any](yamlFilePath string, config *T, opts ...Option) error {
+	o := newOptions(opts)
+	if config == nil {
+		return ErrConfigNil
+	}
+	if err := o.validateLoadType(reflect.TypeFor[T]()); err != nil {
+		return err
+	}
+
+	o.baseDir = filepath.Dir(yamlFilePath)
+	if err := o.checkFileMode(yamlFilePath); err != nil {
+		return err
+	}
+	node, err := parseFile(yamlFilePath, o.allowMultiDoc)
+	if err != nil {
+		return err
+	}
+
+	ext := filepath.Ext(yamlFilePath)
+	localPath := strings.TrimSuffix(yamlFilePath, ext) + ".local" + ext
+	localSrc, err := os.ReadFile(localPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("reading file %q: %w", localPath, err)
+	case len(bytes.TrimSpace(localSrc)) > 0:
+		if err := o.checkFileMode(localPath); err != nil {
+			return err
+		}
+		localNode, err := parseDocument(localSrc, o.allowMultiDoc)
+		if err != nil {
+			return fmt.Errorf("in file %q: %w", localPath, err)
+		}
+		node = mergeNodes(node, localNode)
+	}
+
+	// Values may originate from either file, byte offsets are unknown.
+	o.source = &sourceIndex{root: node}
+	return loadNode(o, config, node)
+}
+
+// LoadEnvBase64 is similar to LoadWithOptions but reads the YAML source from
+// the base64-encoded (standard encoding, padded) value of env var envVarName.
+// Returns ErrEnvMissingVar if the env var isn't set and ErrEnvInvalidVar
+// if its value isn't valid base64.
+func LoadEnvBase64[T any](envVarName string, config *T, opts ...Option) error {
+	if config == nil {
+		return ErrConfigNil
+	}
+	env, ok := newOptions(opts).lookupEnv(envVarName)
+	if !ok {
+		return fmt.Errorf("%w %s", ErrEnvMissingVar, envVarName)
+	}
+	src, err := base64.StdEncoding.DecodeString(strings.TrimSpace(env))
+	if err != nil {
+		return fmt.Errorf("%w %s: decoding base64: %w",
+			ErrEnvInvalidVar, envVarName, err)
+	}
+	return LoadWithOptions(src, config, opts...)
+}
+
+// parseFile reads and parses the YAML file at path.
+func parseFile(path string, allowMultiDoc bool) (*yaml.Node, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %q: %w", path, err)
+	}
+	if len(src) == 0 {
+		return nil, ErrYAMLEmptyFile
+	}
+	return parseDocument(src, allowMultiDoc)
 }
 
 // Load reads and validates the configuration of type T from yamlSource.
 // Load behaves similar to LoadFile.
 func Load[T any, S string | []byte](yamlSource S, config *T) error {
+	return LoadWithOptions(yamlSource, config)
+}
+
+// LoadWithOptions is similar to Load but accepts options.
+func LoadWithOptions[T any, S string | []byte](
+	yamlSource S, config *T, opts ...Option,
+) error {
+	return load(newOptions(opts), yamlSource, config)
+}
+
+// LoadReader is similar to Load but reads the YAML source from r
+// until io.EOF first. Returns ErrYAMLEmptyFile if r yields no bytes.
+func LoadReader[T any](r io.Reader, config *T) error {
+	return LoadReaderWithOptions(r, config)
+}
+
+// LoadReaderWithOptions is similar to LoadReader but accepts options.
+func LoadReaderWithOptions[T any](r io.Reader, config *T, opts ...Option) error {
 	if config == nil {
 		return ErrConfigNil
 	}
-	if len(yamlSource) == 0 {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading source: %w", err)
+	}
+	return load(newOptions(opts), src, config)
+}
+
+// LoadProjection is similar to LoadWithOptions but T may be a projection
+// of the full config type containing only the fields of interest.
+// Keys in the document that don't correspond to any field of T are ignored
+// (including their values) on all levels while the values of the fields of T
+// are checked just like by Load. This allows tools to read their part of
+// a shared config without depending on its full type.
+func LoadProjection[T any, S string | []byte](
+	yamlSource S, config *T, opts ...Option,
+) error {
+	o := newOptions(opts)
+	o.ignoreUnknownFields = true
+	return load(o, yamlSource, config)
+}
+
+// LoadWithReport is similar to LoadWithOptions but additionally returns
+// a report with statistics about the load that are useful for diagnosing
+// slow loads. The report is returned even if loading failed and
+// only covers the phases that were completed.
+func LoadWithReport[T any, S string | []byte](
+	yamlSource S, config *T, opts ...Option,
+) (*LoadReport, error) {
+	o := newOptions(opts)
+	o.report = new(LoadReport)
+	err := load(o, yamlSource, config)
+	return o.report, err
+}
+
+// LoadWithNode is similar to LoadWithOptions but additionally returns the root
+// content node of the parsed document for tools that need to inspect comments,
+// styles or positions without parsing the source again. The returned node
+// is a copy taken before validation and is therefore unaffected by
+// the normalizations applied during loading. The node is returned
+// even if loading failed as long as the source could be parsed.
+func LoadWithNode[T any](src []byte, config *T, opts ...Option) (*yaml.Node, error) {
+	o := newOptions(opts)
+	if config == nil {
+		return nil, ErrConfigNil
+	}
+	if len(src) == 0 && !o.allowEmptyFile {
+		return nil, ErrYAMLEmptyFile
+	}
+	if err := o.validateLoadType(reflect.TypeFor[T]()); err != nil {
+		return nil, err
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+	if len(src) > 0 {
+		var err error
+		if node, err = parseDocument(src, o.allowMultiDoc); err != nil {
+			return nil, err
+		}
+	}
+	o.source = newSourceIndex(src, node)
+	root := cloneNode(node)
+	return root, loadNode(o, config, node)
+}
+
+func load[T any, S string | []byte](o *options, yamlSource S, config *T) error {
+	if config == nil {
+		return ErrConfigNil
+	}
+	if len(yamlSource) == 0 && !o.allowEmptyFile {
 		return ErrYAMLEmptyFile
 	}
 
-	if err := ValidateType[T](); err != nil {
+	if err := o.validateLoadType(reflect.TypeFor[T]()); err != nil {
 		return err
 	}
 
-	dec := newDecoderYAML(yamlSource)
-	dec.KnownFields(true)
-	err := dec.Decode(config)
+	if len(yamlSource) == 0 {
+		// Treat as an empty mapping.
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: 1, Column: 1}
+		o.source = newSourceIndex(yamlSource, node)
+		return loadNode(o, config, node)
+	}
+
+	start := o.now()
+	node, err := parseDocument(yamlSource, o.allowMultiDoc)
+	o.since(phaseParse, start)
 	if err != nil {
-		return fmt.Errorf("%w: %w", ErrYAMLMalformed, err)
+		return err
 	}
+	o.source = newSourceIndex(yamlSource, node)
+	return loadNode(o, config, node)
+}
 
+// parseDocument parses yamlSource and returns the root content node
+// of the only document it contains. If allowMultiDoc is true then
+// the root content node of the first document is returned
+// and any subsequent documents are ignored without being parsed.
+func parseDocument[S string | []byte](
+	yamlSource S, allowMultiDoc bool,
+) (*yaml.Node, error) {
 	var rootNode yaml.Node
-	{
-		dec := newDecoderYAML(yamlSource)
-		if err := dec.Decode(&rootNode); err != nil {
-			return fmt.Errorf("decoding yaml structure: %w", err)
-		}
+	dec := newDecoderYAML(yamlSource)
+	if err := dec.Decode(&rootNode); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrYAMLMalformed, err)
+	}
+	if allowMultiDoc {
+		return rootNode.Content[0], nil
+	}
+
+	// Check if multi-doc
+	var n yaml.Node
+	if err := dec.Decode(&n); err == nil {
+		return nil, newSourceIndex(yamlSource, nil).errorAt(
+			n.Line, n.Column, "", ErrYAMLMultidoc)
+	} else if !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %w", ErrYAMLMultidoc, err)
+	}
+	return rootNode.Content[0], nil
+}
 
-		// Check if multi-doc
-		var n yaml.Node
-		if err = dec.Decode(&n); err == nil {
-			return fmt.Errorf("at %d:%d: %w", n.Line, n.Column, ErrYAMLMultidoc)
-		} else if !errors.Is(err, io.EOF) {
-			return fmt.Errorf("%w: %w", ErrYAMLMultidoc, err)
+// loadNode validates node and decodes it into config.
+// Assumes that the type of config has already been validated.
+func loadNode[T any](o *options, config *T, node *yaml.Node) error {
+	if !o.runs(PhaseValueRules) {
+		return nil
+	}
+
+	var override *sourceIndex
+	var original *yaml.Node
+	if o.jsonEnvOverride != "" && o.runs(PhaseEnv) {
+		var err error
+		if override, err = o.parseJSONEnvOverride(); err != nil {
+			return err
+		}
+		if override != nil {
+			// Validation modifies the document.
+			original = cloneNode(node)
 		}
 	}
 
-	configType := reflect.TypeOf(config).Elem()
+	if err := o.validateRaw(node); err != nil {
+		return err
+	}
 
+	configType := reflect.TypeOf(config).Elem()
 	configTypeName := getConfigTypeName(configType)
 
+	start := o.now()
 	anchors := make(map[string]*anchor)
-	err = validateYAMLValues(
-		anchors, "", configTypeName, configType, rootNode.Content[0],
-	)
+	err := validateDocumentValues(o, anchors, configTypeName, configType, node)
+	if o.report != nil {
+		o.report.Anchors = len(anchors)
+	}
+	o.since(phaseValues, start)
 	if err != nil {
 		return err
 	}
+	if err := checkUnusedAnchors(o, anchors); err != nil {
+		return err
+	}
+	if !o.runs(PhaseDecode) {
+		return nil
+	}
+
+	err = decodeAndValidate(o, configTypeName, reflect.ValueOf(config), node)
+	if err != nil || override == nil {
+		return err
+	}
+	return loadJSONEnvOverride(o, config, original, override)
+}
 
-	// Check for unused anchors
+// checkUnusedAnchors returns ErrYAMLAnchorUnused if any of anchors
+// isn't referenced unless WithAllowUnusedAnchors is used.
+func checkUnusedAnchors(o *options, anchors map[string]*anchor) error {
+	if o.allowUnusedAnchors {
+		return nil
+	}
 	for _, anchor := range anchors {
 		if !anchor.IsUsed {
-			return fmt.Errorf("at %d:%d: anchor %q: %w",
-				anchor.Line, anchor.Column, anchor.Anchor, ErrYAMLAnchorUnused)
+			return o.errorAt(anchor.Line, anchor.Column, anchor.Path,
+				fmt.Errorf("anchor %q: %w", anchor.Anchor, ErrYAMLAnchorUnused))
 		}
 	}
+	return nil
+}
 
-	err = unmarshalEnv(configTypeName, "", reflect.ValueOf(config).Elem())
+// decodeAndValidate decodes node into config and then overwrites env fields,
+// invokes all Validate methods and checks the validator struct tags.
+// config must be a pointer to a struct.
+// Assumes that validateYAMLValues was ran on node first.
+func decodeAndValidate(
+	o *options, path string, config reflect.Value, node *yaml.Node,
+) error {
+	start := o.now()
+	err := o.decodePolymorphic(config, node)
+	o.since(phaseDecode, start)
 	if err != nil {
-		return err
+		return fmt.Errorf("%w: %w", ErrYAMLMalformed, err)
 	}
 
-	err = invokeValidateRecursively(
-		configTypeName, reflect.ValueOf(config), rootNode.Content[0],
-	)
+	if !o.noEnvOverrides && o.runs(PhaseEnv) {
+		start = o.now()
+		err = unmarshalEnv(o, path, "", config.Elem())
+		o.since(phaseEnv, start)
+		var invalid *envInvalidError
+		if errors.As(err, &invalid) {
+			n, yamlTag, ok := resolveNodeByValidatorNamespace(
+				o, config.Type().Elem(), invalid.path, node,
+			)
+			if !ok {
+				// The field isn't in the document, such as `yaml:"-"` fields.
+				return err
+			}
+			return o.errorAt(n.Line, n.Column, invalid.path,
+				fmt.Errorf("%q (%s): %w", yamlTag, invalid.path, invalid.err))
+		}
+		var conflict *envConflictError
+		if errors.As(err, &conflict) {
+			line, column, yamlTag := mustFindLocationByValidatorNamespace(
+				o, config.Type().Elem(), conflict.path, node,
+			)
+			return o.errorAt(line, column, conflict.path,
+				fmt.Errorf("%q (%s): %w %s: value is also set in the config file",
+					yamlTag, conflict.path, ErrEnvConflict, conflict.envVar))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if o.lazyResolver != nil {
+		setLazyResolvers(config, o.lazyResolver)
+	} else if o.envLookup != nil {
+		setLazyResolvers(config, envRefResolver(o.envLookup))
+	}
+	normalizeStrings(config)
+
+	err = normalizeMapKeys(path, config.Elem(), func(p, key, pPrev, keyPrev string) error {
+		tp := config.Type().Elem()
+		n, yamlTag := findNodeByValidatorNamespace(o, tp, p, node)
+		prev, _ := findNodeByValidatorNamespace(o, tp, pPrev, node)
+		if prev.Line > n.Line || prev.Line == n.Line && prev.Column > n.Column {
+			// Report the entry that comes last in the document.
+			p, key, keyPrev = pPrev, keyPrev, key
+			n, prev = prev, n
+		}
+		if envVar, ok := o.envSource(p); ok {
+			return fmt.Errorf("at %s: %w %s: %w: %q normalizes to the same key as %q",
+				p, ErrEnvInvalidVar, envVar, ErrYAMLDuplicateMapKey, key, keyPrev)
+		}
+		return o.errorAt(n.Line, n.Column, p,
+			fmt.Errorf("%q: %w: %q normalizes to the same key as %q at %d:%d",
+				yamlTag, ErrYAMLDuplicateMapKey, key, keyPrev, prev.Line, prev.Column))
+	})
 	if err != nil {
 		return err
 	}
 
-	err = validator.New(
-		validator.WithRequiredStructEnabled(),
-	).Struct(config)
+	err = o.checkIntEnums(path, config.Elem(), func(p string, err error) error {
+		return o.fieldValueError(config, node, p, err)
+	})
 	if err != nil {
-		if errs, ok := err.(validator.ValidationErrors); ok {
-			err := errs[0]
-			line, column, yamlTag := mustFindLocationByValidatorNamespace[T](
-				err.StructNamespace(), &rootNode,
-			)
-			if yamlTag == "-" {
-				// TODO: report env var name if any.
+		return err
+	}
+
+	if o.runs(PhaseValidators) {
+		if err := checkFieldTags(o, path, config, node); err != nil {
+			return err
+		}
+	}
+
+	start = o.now()
+	defer o.since(phaseValidators, start)
+
+	var all *[]error
+	if o.allErrors {
+		all = new([]error)
+	}
+	if o.runs(PhaseValidators) {
+		err = invokeValidateRecursively(o, path, config, node, all)
+		if err != nil {
+			return err
+		}
+	}
+	if !o.runs(PhaseGoValidator) {
+		return nil
+	}
 
-				// Ignored field, use Go field name instead of tag.
-				return fmt.Errorf("at %s: %w: %q",
-					err.StructNamespace(), ErrValidationTag, err.Tag())
+	validate, err := o.newValidator()
+	if err != nil {
+		return err
+	}
+	err = validateStruct(validate, path, config.Interface())
+	if errs, ok := err.(validator.ValidationErrors); ok {
+		if all == nil {
+			if err := firstEnabledFieldError(errs, config); err != nil {
+				return fieldTagError(o, config, node, err)
 			}
-			return fmt.Errorf("at %d:%d: %q %w: %q",
-				line, column, yamlTag, ErrValidationTag, err.Tag())
+			return nil
+		}
+		for _, err := range enabledFieldErrors(errs, config) {
+			*all = append(*all, fieldTagError(o, config, node, err))
 		}
+	} else if err != nil {
 		return err
 	}
+
+	if all != nil && len(*all) > 0 {
+		return &MultiError{Errors: *all}
+	}
 	return nil
 }
 
+// fieldTagError returns the error for validator field error err
+// of config located in node.
+func fieldTagError(
+	o *options, config reflect.Value, node *yaml.Node, err validator.FieldError,
+) error {
+	if envVar, ok := o.envSource(err.StructNamespace()); ok {
+		// The YAML location would be misleading.
+		return fmt.Errorf("at %s: %w %s: %w: %q%s",
+			err.StructNamespace(), ErrEnvInvalidVar, envVar,
+			ErrValidationTag, err.Tag(), violationDetails(config.Type().Elem(), err))
+	}
+	namespace := err.StructNamespace()
+	details := violationDetails(config.Type().Elem(), err)
+	if err.Tag() == "unique" {
+		v := reflect.ValueOf(err.Value())
+		if dup, orig := findDuplicateItem(v, err.Param()); dup != -1 {
+			// Point at the duplicate instead of the list.
+			namespace += fmt.Sprintf("[%d]", dup)
+			details = fmt.Sprintf(": index %d duplicates index %d", dup, orig)
+		}
+	}
+	n, yamlTag := findNodeByValidatorNamespace(
+		o, config.Type().Elem(), namespace, node,
+	)
+	line, column := n.Line, n.Column
+	if err.Tag() == "required" && err.Kind() == reflect.Pointer &&
+		yamlTag != "" && yamlTag != "-" && isExplicitNull(n) {
+		// The generic message wouldn't explain that null is unset.
+		return o.errorAt(line, column, namespace,
+			fmt.Errorf("%q: %w", yamlTag, ErrValidationRequiredNull))
+	}
+	if yamlTag == "-" {
+		// Ignored field, use Go field name instead of tag.
+		return fmt.Errorf("at %s: %w: %q%s",
+			err.StructNamespace(), ErrValidationTag, err.Tag(), details)
+	}
+	if yamlTag == "" {
+		// Struct level violation on the root struct.
+		return o.errorAt(line, column, namespace,
+			fmt.Errorf("%w: %q", ErrValidationTag, err.Tag()))
+	}
+	return o.errorAt(line, column, namespace,
+		fmt.Errorf("%q %w: %q%s", yamlTag, ErrValidationTag, err.Tag(), details))
+}
+
+// checkFieldTags checks the values of config against the `pattern`, `format`,
+// `multipleof`, `maxbytes`, `maxrunes`, `sorted`, `cidr`, `fileexists`,
+// `before`, `after` and `sumbudget` struct tags, see fieldChecks.
+func checkFieldTags(
+	o *options, path string, config reflect.Value, node *yaml.Node,
+) error {
+	w := fieldWalker{
+		baseDir: o.baseDir,
+		name: func(f reflect.StructField) string {
+			return strconv.Quote(getYAMLFieldName(f.Tag))
+		},
+		report: func(p string, err error) error {
+			return o.fieldValueError(config, node, p, err)
+		},
+	}
+	return w.walk(path, config.Elem())
+}
+
+// fieldValueError returns err located at the value at Go path p of config,
+// which is either the env var it was read from or its node in the document.
+func (o *options) fieldValueError(
+	config reflect.Value, node *yaml.Node, p string, err error,
+) error {
+	if envVar, ok := o.envSource(p); ok {
+		return fmt.Errorf("at %s: %w %s: %w", p, ErrEnvInvalidVar, envVar, err)
+	}
+	line, column, yamlTag := mustFindLocationByValidatorNamespace(
+		o, config.Type().Elem(), p, node,
+	)
+	return o.errorAt(line, column, p, fmt.Errorf("%q: %w", yamlTag, err))
+}
+
 // Validate behaves similar to Load and LoadFile just without parsing YAML
 // and instead performing the same type and value checks on t.
 // Validate will obviously not report line:column error location.
-// Validate first validates type T, then validates t according to
-// go-playground/validator struct tags, then recursively
-// invokes all Validate methods returning an error if any.
-func Validate[T any](t T) error {
-	if err := ValidateType[T](); err != nil {
+// Validate first validates type T, then recursively invokes all Validate
+// methods, then validates t according to go-playground/validator struct tags
+// returning an error if any. Violations are reported as *Error
+// carrying the Go path of the invalid value.
+// Options such as WithStructValidation, WithValidatorFunc and WithAllErrors
+// apply, options that are specific to YAML are ignored.
+func Validate[T any](t T, opts ...Option) error {
+	o := newOptions(opts)
+	if err := o.validateType(reflect.TypeFor[T]()); err != nil {
+		return err
+	}
+	return o.validateConfig(reflect.ValueOf(t))
+}
+
+// validateConfig is Validate for config value v
+// assuming its type has already been validated.
+func (o *options) validateConfig(v reflect.Value) error {
+	var all *[]error
+	if o.allErrors {
+		all = new([]error)
+	}
+	typeName := getConfigTypeName(v.Type())
+	report := func(p string, err error) error {
+		err = &Error{GoPath: p, Err: err}
+		if all == nil {
+			return err
+		}
+		*all = append(*all, err)
+		return nil
+	}
+	if err := o.checkIntEnums(typeName, v, report); err != nil {
+		return err
+	}
+	w := fieldWalker{
+		baseDir: o.baseDir,
+		name:    func(f reflect.StructField) string { return f.Name },
+		report:  report,
+	}
+	if err := w.walk(typeName, v); err != nil {
+		return err
+	}
+	if err := invokeValidateRecursively(o, typeName, v, nil, all); err != nil {
 		return err
 	}
-	err := validator.New(validator.WithRequiredStructEnabled()).Struct(t)
+
+	validate, err := o.newValidator()
 	if err != nil {
-		if errs, ok := err.(validator.ValidationErrors); ok {
-			return fmt.Errorf("at %s: %w: %q",
-				errs[0].StructNamespace(), ErrValidationTag, errs[0].Tag())
+		return err
+	}
+	err = validateStruct(validate, typeName, v.Interface())
+	if errs, ok := err.(validator.ValidationErrors); ok {
+		for _, err := range enabledFieldErrors(errs, v) {
+			err := &Error{
+				GoPath: typeName + trimPathRoot(err.StructNamespace()),
+				Err: fmt.Errorf("%w: %q%s",
+					ErrValidationTag, err.Tag(), violationDetails(v.Type(), err)),
+			}
+			if all == nil {
+				return err
+			}
+			*all = append(*all, err)
 		}
+	} else if err != nil {
 		return err
 	}
-	typeName := getConfigTypeName(reflect.TypeOf(t))
-	return invokeValidateRecursively(typeName, reflect.ValueOf(t), nil)
+
+	if all != nil && len(*all) > 0 {
+		return &MultiError{Errors: *all}
+	}
+	return nil
+}
+
+// violationDetails returns the details of field error err of a field
+// of struct type tp such as the bounds of durations and map sizes
+// or an empty string if there are no details.
+func violationDetails(tp reflect.Type, err validator.FieldError) string {
+	if d := durationBound(err); d != "" {
+		return d
+	}
+	return mapEntriesBound(tp, err)
+}
+
+// mapEntriesBound returns the bounds on the number of entries violated by
+// map field error err formatted like
+// `: map must have between 1 and 10 entries, got 12`,
+// otherwise returns an empty string.
+func mapEntriesBound(tp reflect.Type, err validator.FieldError) string {
+	if err.Kind() != reflect.Map {
+		return ""
+	}
+	switch err.Tag() {
+	case "min", "max", "len", "gte", "lte", "eq":
+	default:
+		return ""
+	}
+	f, ok := findFieldByValidatorNamespace(tp, err.StructNamespace())
+	if !ok {
+		return ""
+	}
+	var lower, upper string
+	for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+		if rule == "dive" || rule == "keys" {
+			break // The following rules apply to keys and values.
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "gte":
+			lower = param
+		case "max", "lte":
+			upper = param
+		case "len", "eq":
+			lower, upper = param, param
+		}
+	}
+	got := reflect.ValueOf(err.Value()).Len()
+	switch {
+	case lower != "" && lower == upper:
+		return fmt.Sprintf(": map must have exactly %s, got %d", entries(lower), got)
+	case lower != "" && upper != "":
+		return fmt.Sprintf(": map must have between %s and %s entries, got %d",
+			lower, upper, got)
+	case lower != "":
+		return fmt.Sprintf(": map must have at least %s, got %d", entries(lower), got)
+	case upper != "":
+		return fmt.Sprintf(": map must have at most %s, got %d", entries(upper), got)
+	}
+	return ""
+}
+
+// entries returns "1 entry" for n = "1" and "<n> entries" otherwise.
+func entries(n string) string {
+	if n == "1" {
+		return "1 entry"
+	}
+	return n + " entries"
+}
+
+// findFieldByValidatorNamespace returns the struct field of type tp
+// the validator namespace (field type path) refers to.
+func findFieldByValidatorNamespace(
+	tp reflect.Type, validatorNamespace string,
+) (f reflect.StructField, ok bool) {
+	// Remove the type prefix, assuming validatorNamespace starts with the type name
+	_, validatorNamespace = leftmostPathElement(validatorNamespace)
+	for validatorNamespace != "" {
+		var element string
+		element, validatorNamespace = leftmostPathElement(validatorNamespace)
+		for tp.Kind() == reflect.Pointer {
+			tp = tp.Elem()
+		}
+		if tp.Kind() != reflect.Struct {
+			return reflect.StructField{}, false
+		}
+		fieldName, indexes := splitPathIndexes(element)
+		if f, ok = tp.FieldByName(fieldName); !ok {
+			return reflect.StructField{}, false
+		}
+		tp = f.Type
+		for range indexes {
+			for tp.Kind() == reflect.Pointer {
+				tp = tp.Elem()
+			}
+			tp = tp.Elem()
+		}
+	}
+	return f, ok
+}
+
+// durationBound returns the bound violated by time.Duration field error err
+// formatted as a duration like `: must be greater than 0s`, otherwise
+// returns an empty string since the bound would be reported in nanoseconds.
+func durationBound(err validator.FieldError) string {
+	if err.Type() != typeTimeDuration {
+		return ""
+	}
+	var relation string
+	switch err.Tag() {
+	case "gt":
+		relation = "greater than"
+	case "gte", "min":
+		relation = "greater than or equal to"
+	case "lt":
+		relation = "less than"
+	case "lte", "max":
+		relation = "less than or equal to"
+	case "eq":
+		relation = "equal to"
+	case "ne":
+		relation = "not equal to"
+	default:
+		return ""
+	}
+	d, parseErr := time.ParseDuration(err.Param())
+	if parseErr != nil {
+		// go-playground/validator also accepts nanoseconds without unit.
+		n, parseErr := strconv.ParseInt(err.Param(), 10, 64)
+		if parseErr != nil {
+			return ""
+		}
+		d = time.Duration(n)
+	}
+	return fmt.Sprintf(": must be %s %s", relation, d)
+}
+
+// validateStruct invokes v.Struct(s) converting panics into ErrValidatorPanic
+// located at the Go path of struct s.
+// go-playground/validator panics on invalid validation tags such as
+// undefined validation functions or params that can't be parsed.
+func validateStruct(v *validator.Validate, path string, s any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &Error{GoPath: path, Err: fmt.Errorf("%w: %v", ErrValidatorPanic, r)}
+		}
+	}()
+	return v.Struct(s)
 }
 
 // Validator defines the interface yamagiconf supports for custom validation code.
@@ -282,22 +997,35 @@ func getConfigTypeName(t reflect.Type) string {
 // every field of type that implements the Validator interface recursively.
 // Assumes type of v was validated first using ValidateType.
 // If node != nil then assumes validateYAMLValues was ran first on it.
-func invokeValidateRecursively(path string, v reflect.Value, node *yaml.Node) error {
+// If all != nil then errors are appended to it instead of
+// being returned and traversal continues.
+func invokeValidateRecursively(
+	o *options, path string, v reflect.Value, node *yaml.Node, all *[]error,
+) error {
 	tp := v.Type()
 
 	if v := asIface[Validator](v, false); v != nil {
 		if err := v.Validate(); err != nil {
-			if node == nil {
-				return fmt.Errorf("at %s: %w: %w", path, ErrValidation, err)
+			err := error(&Error{
+				GoPath: path, Err: fmt.Errorf("%w: %w", ErrValidation, err),
+			})
+			if node != nil {
+				err = o.errorAt(node.Line, node.Column, path, err)
+			}
+			if all == nil {
+				return err
 			}
-			return fmt.Errorf("at %d:%d: at %s: %w: %w",
-				node.Line, node.Column, path, ErrValidation, err)
+			*all = append(*all, err)
 		}
 	}
-	for tp.Kind() == reflect.Pointer {
+	for tp.Kind() == reflect.Pointer || tp.Kind() == reflect.Interface {
 		if v.IsNil() {
 			return nil
 		}
+		if tp.Kind() == reflect.Interface {
+			// Validate the concrete value of a polymorphic field.
+			return invokeValidateRecursively(o, path, v.Elem(), node, all)
+		}
 		tp, v = tp.Elem(), v.Elem()
 	}
 
@@ -312,6 +1040,9 @@ func invokeValidateRecursively(path string, v reflect.Value, node *yaml.Node) er
 			if !ft.IsExported() {
 				continue
 			}
+			if validateWhenDisabled(v, ft) {
+				continue
+			}
 			fv := v.Field(i)
 			yamlTag := getYAMLFieldName(ft.Tag)
 			var nodeValue *yaml.Node
@@ -322,7 +1053,7 @@ func invokeValidateRecursively(path string, v reflect.Value, node *yaml.Node) er
 				}
 			}
 			path := path + "." + ft.Name
-			if err := invokeValidateRecursively(path, fv, nodeValue); err != nil {
+			if err := invokeValidateRecursively(o, path, fv, nodeValue, all); err != nil {
 				return err
 			}
 		}
@@ -336,7 +1067,7 @@ func invokeValidateRecursively(path string, v reflect.Value, node *yaml.Node) er
 			if node != nil {
 				nodeItem = node.Content[i]
 			}
-			err := invokeValidateRecursively(path, v.Index(i), nodeItem)
+			err := invokeValidateRecursively(o, path, v.Index(i), nodeItem, all)
 			if err != nil {
 				return err
 			}
@@ -349,12 +1080,12 @@ func invokeValidateRecursively(path string, v reflect.Value, node *yaml.Node) er
 		mapKeys := mapKeysSorted(v)
 		if node == nil {
 			for _, k := range mapKeys {
-				err := invokeValidateRecursively(path, k, nil)
+				err := invokeValidateRecursively(o, path, k, nil, all)
 				if err != nil {
 					return err
 				}
 				path := fmt.Sprintf("%s[%v]", path, k)
-				err = invokeValidateRecursively(path, v.MapIndex(k), nil)
+				err = invokeValidateRecursively(o, path, v.MapIndex(k), nil, all)
 				if err != nil {
 					return err
 				}
@@ -365,13 +1096,13 @@ func invokeValidateRecursively(path string, v reflect.Value, node *yaml.Node) er
 					if k.String() != node.Content[i].Value {
 						continue
 					}
-					err := invokeValidateRecursively(path, k, node.Content[i])
+					err := invokeValidateRecursively(o, path, k, node.Content[i], all)
 					if err != nil {
 						return err
 					}
 					path := fmt.Sprintf("%s[%v]", path, k)
 					err = invokeValidateRecursively(
-						path, v.MapIndex(k), node.Content[i+1],
+						o, path, v.MapIndex(k), node.Content[i+1], all,
 					)
 					if err != nil {
 						return err
@@ -395,10 +1126,11 @@ func newDecoderYAML[S string | []byte](s S) *yaml.Decoder {
 }
 
 // unmarshalEnv traverses v and overwrites the values when an `env` struct tag
-// was specified for any given field.
+// was specified for any given field. envTag is the `env` struct tag of v.
 // Assumes that the config type has already been validated.
-func unmarshalEnv(path, envVar string, v reflect.Value) error {
+func unmarshalEnv(o *options, path, envTag string, v reflect.Value) error {
 	tp := v.Type()
+	names, required, exclusive := parseEnvTag(envTag)
 
 	textUnmarshaler := asIface[encoding.TextUnmarshaler](v, true)
 	if isPtr := tp.Kind() == reflect.Pointer; isPtr &&
@@ -406,16 +1138,27 @@ func unmarshalEnv(path, envVar string, v reflect.Value) error {
 		// Pointer to a struct type that doesn't implement encoding.TextUnmarshaler
 		v, tp = v.Elem(), tp.Elem()
 	} else if isPtr {
-		env, ok := os.LookupEnv(envVar)
+		envVar, env, ok, err := o.lookupEnvVars(names)
+		if err != nil {
+			return &envInvalidError{path: path, err: err}
+		}
+		if !ok && required {
+			return errMissingEnv(path, names)
+		}
 		if ok {
+			if exclusive && !v.IsNil() {
+				return &envConflictError{path: path, envVar: envVar}
+			}
 			if env == "null" {
 				v.Set(reflect.Zero(v.Type()))
+				o.setEnvSource(path, envVar)
 				return nil
 			} else if textUnmarshaler != nil {
 				if err := textUnmarshaler.UnmarshalText([]byte(env)); err != nil {
 					return errUnmarshalEnv(path, envVar, tp, err)
 				}
 				v.Set(reflect.ValueOf(textUnmarshaler))
+				o.setEnvSource(path, envVar)
 				return nil
 			}
 			newValue := reflect.New(tp.Elem())
@@ -425,149 +1168,55 @@ func unmarshalEnv(path, envVar string, v reflect.Value) error {
 		}
 	}
 
-	if textUnmarshaler != nil {
-		env, ok := os.LookupEnv(envVar)
+	if textUnmarshaler != nil || tp == typeTimeDuration ||
+		kindIsPrimitive(tp.Kind()) || kindIsPlatformInt(tp.Kind()) {
+		envVar, env, ok, err := o.lookupEnvVars(names)
+		if err != nil {
+			return &envInvalidError{path: path, err: err}
+		}
 		if !ok {
+			if required {
+				return errMissingEnv(path, names)
+			}
 			return nil
 		}
-		if err := textUnmarshaler.UnmarshalText([]byte(env)); err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
+		if exclusive && !v.IsZero() {
+			return &envConflictError{path: path, envVar: envVar}
 		}
-	}
-
-	if tp == typeTimeDuration {
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
+		if e := o.enums[tp]; e != nil {
+			i, err := e.value(env)
+			if err != nil {
+				return errUnmarshalEnv(path, envVar, tp, err)
+			}
+			v.SetInt(i)
+			o.setEnvSource(path, envVar)
 			return nil
 		}
-		d, err := time.ParseDuration(env)
-		if err != nil {
+		if err := setFromString(v, env); err != nil {
+			if errors.Is(err, errSyntax) {
+				return errUnmarshalEnv(path, envVar, tp, nil)
+			}
 			return errUnmarshalEnv(path, envVar, tp, err)
 		}
-		v.SetInt(int64(d))
+		if err := checkEnumValue(v); err != nil {
+			return errUnmarshalEnv(path, envVar, tp, err)
+		}
+		o.setEnvSource(path, envVar)
 		return nil
 	}
 
 	switch tp.Kind() {
-	case reflect.Bool:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		switch env {
-		case "true":
-			v.SetBool(true)
-		case "false":
-			v.SetBool(false)
-		default:
-			return errUnmarshalEnv(path, envVar, tp, nil)
-		}
-	case reflect.String:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		v.SetString(env)
-	case reflect.Float32:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		f, err := strconv.ParseFloat(env, 32)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
-		}
-		v.SetFloat(f)
-	case reflect.Float64:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		f, err := strconv.ParseFloat(env, 64)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
-		}
-		v.SetFloat(f)
-	case reflect.Int8:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		i, err := strconv.ParseInt(env, 10, 8)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
-		}
-		v.SetInt(int64(i))
-	case reflect.Uint8:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		i, err := strconv.ParseUint(env, 10, 8)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
-		}
-		v.SetUint(uint64(i))
-	case reflect.Int16:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		i, err := strconv.ParseInt(env, 10, 16)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
-		}
-		v.SetInt(int64(i))
-	case reflect.Uint16:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		i, err := strconv.ParseUint(env, 10, 16)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
-		}
-		v.SetUint(uint64(i))
-	case reflect.Int32:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		i, err := strconv.ParseInt(env, 10, 32)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
-		}
-		v.SetInt(int64(i))
-	case reflect.Uint32:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		i, err := strconv.ParseUint(env, 10, 32)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
-		}
-		v.SetUint(uint64(i))
-	case reflect.Int64:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
-			return nil
-		}
-		i, err := strconv.ParseInt(env, 10, 64)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
-		}
-		v.SetInt(int64(i))
-	case reflect.Uint64:
-		env, ok := os.LookupEnv(envVar)
-		if !ok {
+	case reflect.Interface:
+		if v.IsNil() {
 			return nil
 		}
-		i, err := strconv.ParseUint(env, 10, 64)
-		if err != nil {
-			return errUnmarshalEnv(path, envVar, tp, err)
+		// Interface values aren't addressable, unmarshal into a copy.
+		val := reflect.New(v.Elem().Type()).Elem()
+		val.Set(v.Elem())
+		if err := unmarshalEnv(o, path, "", val); err != nil {
+			return err
 		}
-		v.SetUint(uint64(i))
+		v.Set(val)
 	case reflect.Struct:
 		for i := range tp.NumField() {
 			f := tp.Field(i)
@@ -575,14 +1224,27 @@ func unmarshalEnv(path, envVar string, v reflect.Value) error {
 				continue
 			}
 			n := f.Tag.Get("env")
-			err := unmarshalEnv(path+"."+f.Name, n, v.Field(i))
+			// The defaults of fields that aren't ignored by yaml
+			// are applied by defaultTagNode if they're missing
+			// in the document.
+			if d, ok := f.Tag.Lookup("envDefault"); ok && getYAMLFieldName(f.Tag) == "-" {
+				names, _, _ := parseEnvTag(n)
+				// Errors are reported by unmarshalEnv.
+				if _, _, set, err := o.lookupEnvVars(names); !set && err == nil {
+					// The default value was checked by ValidateType already.
+					_ = setFromString(v.Field(i), d)
+					o.setSource(path+"."+f.Name, Provenance{Source: SourceDefault})
+					continue
+				}
+			}
+			err := unmarshalEnv(o, path+"."+f.Name, n, v.Field(i))
 			if err != nil {
 				return err
 			}
 		}
 	case reflect.Slice, reflect.Array:
 		for i := range v.Len() {
-			err := unmarshalEnv(fmt.Sprintf("%s[%d]", path, i), "", v.Index(i))
+			err := unmarshalEnv(o, fmt.Sprintf("%s[%d]", path, i), "", v.Index(i))
 			if err != nil {
 				return err
 			}
@@ -597,7 +1259,7 @@ func unmarshalEnv(path, envVar string, v reflect.Value) error {
 				if value.IsNil() {
 					continue
 				}
-				if err := unmarshalEnv(path, "", value.Elem()); err != nil {
+				if err := unmarshalEnv(o, path, "", value.Elem()); err != nil {
 					return err
 				}
 				continue
@@ -606,61 +1268,304 @@ func unmarshalEnv(path, envVar string, v reflect.Value) error {
 			val := reflect.New(value.Type()).Elem()
 			val.Set(value)
 
-			if err := unmarshalEnv(path, "", val); err != nil {
+			if err := unmarshalEnv(o, path, "", val); err != nil {
 				return err
 			}
 			v.SetMapIndex(key, val)
 		}
+		if o.indexedEnvOverrides {
+			// Apply in reverse order for earlier names to take precedence.
+			for _, name := range slices.Backward(names) {
+				if err := unmarshalEnvMapEntries(o, path, name, v); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// unmarshalEnvMapEntries sets the entries of map v from env vars
+// named <envVar>_<KEY> where KEY is the map key normalized by envVarMapKey.
+// Entries that don't exist in v yet are added with the lower case
+// KEY segment as key.
+func unmarshalEnvMapEntries(o *options, path, envVar string, v reflect.Value) error {
+	tp := v.Type()
+	prefix := envVar + "_"
+
+	// Map env var names to existing keys.
+	existing := make(map[string]reflect.Value, v.Len())
+	for _, key := range mapKeysSorted(v) {
+		name := prefix + envVarMapKey(key.String())
+		if prev, ok := existing[name]; ok {
+			return fmt.Errorf("at %s: %w %s: ambiguous, matches keys %q and %q",
+				path, ErrEnvInvalidVar, name, prev.String(), key.String())
+		}
+		existing[name] = key
+	}
+
+	var names []string
+	if o.envLookup != nil {
+		// Custom sources can't be listed, only existing entries are overwritten.
+		names = slices.Collect(maps.Keys(existing))
+	} else {
+		for _, e := range os.Environ() {
+			name, _, _ := strings.Cut(e, "=")
+			if o.envFileSecrets {
+				// Looked up by unmarshalEnv if the name without suffix isn't set.
+				name = strings.TrimSuffix(name, "_FILE")
+			}
+			if len(name) > len(prefix) && strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	for _, name := range names {
+		key, ok := existing[name]
+		if !ok {
+			key = reflect.ValueOf(strings.ToLower(name[len(prefix):])).
+				Convert(tp.Key())
+		}
+		path := fmt.Sprintf("%s[%s]", path, key.String())
+		val := reflect.New(tp.Elem()).Elem()
+		if ok {
+			val.Set(v.MapIndex(key))
+		}
+		if err := unmarshalEnv(o, path, name, val); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(tp))
+		}
+		v.SetMapIndex(key, val)
 	}
 	return nil
 }
 
+// envVarMapKey returns map key k normalized for use in env var names:
+// letters are upper-cased and any character that isn't
+// an ASCII letter or digit is replaced by an underscore.
+func envVarMapKey(k string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, k)
+}
+
 var typeTimeDuration = reflect.TypeOf(time.Duration(0))
 
+// errSyntax is returned by setFromString for
+// strings that aren't valid for the target type.
+var errSyntax = errors.New("invalid syntax")
+
+// setFromString parses s and assigns the result to v which must be settable
+// and either implement encoding.TextUnmarshaler, be a time.Duration,
+// a primitive type or a pointer to any of them. For pointers "null" assigns nil.
+func setFromString(v reflect.Value, s string) error {
+	tp := v.Type()
+	if tp.Kind() == reflect.Pointer {
+		if s == "null" {
+			v.Set(reflect.Zero(tp))
+			return nil
+		}
+		if u := asIface[encoding.TextUnmarshaler](v, true); u != nil {
+			if err := u.UnmarshalText([]byte(s)); err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(u))
+			return nil
+		}
+		n := reflect.New(tp.Elem())
+		if err := setFromString(n.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(n)
+		return nil
+	}
+
+	if u := asIface[encoding.TextUnmarshaler](v, true); u != nil {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	if tp == typeTimeDuration {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch tp.Kind() {
+	case reflect.Bool:
+		switch s {
+		case "true":
+			v.SetBool(true)
+		case "false":
+			v.SetBool(false)
+		default:
+			return errSyntax
+		}
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, tp.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, tp.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, tp.Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(i)
+	default:
+		return fmt.Errorf("%w: %s", ErrTypeUnsupported, tp.String())
+	}
+	return nil
+}
+
+// envConflictError is returned by unmarshalEnv when env var envVar is set
+// for a field with env struct tag option "exclusive" at path that
+// already has a non-zero value.
+type envConflictError struct{ path, envVar string }
+
+func (e *envConflictError) Error() string {
+	return fmt.Sprintf("at %s: %s %s", e.path, ErrEnvConflict, e.envVar)
+}
+
+func errMissingEnv(path string, names []string) error {
+	return fmt.Errorf("at %s: %w %s", path, ErrEnvMissingVar, strings.Join(names, " or "))
+}
+
 func errUnmarshalEnv(path, envVar string, tp reflect.Type, err error) error {
 	if err != nil {
-		return fmt.Errorf("at %s: %w %s: expected %s: %w",
-			path, ErrEnvInvalidVar, envVar, tp.String(), err)
+		err = fmt.Errorf("%w %s: expected %s: %w",
+			ErrEnvInvalidVar, envVar, tp.String(), err)
+	} else {
+		err = fmt.Errorf("%w %s: expected %s", ErrEnvInvalidVar, envVar, tp.String())
 	}
-	return fmt.Errorf("at %s: %w %s: expected %s",
-		path, ErrEnvInvalidVar, envVar, tp.String())
+	return &envInvalidError{path: path, err: err}
+}
+
+// envInvalidError is returned by unmarshalEnv when the value of an env var
+// can't be parsed for the field at path.
+type envInvalidError struct {
+	path string
+	err  error
 }
 
+func (e *envInvalidError) Error() string { return "at " + e.path + ": " + e.err.Error() }
+
+func (e *envInvalidError) Unwrap() error { return e.err }
+
 // mustFindLocationByValidatorNamespace finds the line and column numbers of the
-// validator namespace (field type path).
-func mustFindLocationByValidatorNamespace[T any](
-	validatorNamespace string, rootNode *yaml.Node,
+// validator namespace (field type path) of type tp in node.
+// Namespace elements may carry indexes (`List[2]`) and map keys (`Map[key]`)
+// in which case the location of the item is returned.
+func mustFindLocationByValidatorNamespace(
+	o *options, tp reflect.Type, validatorNamespace string, node *yaml.Node,
 ) (line int, column int, yamlTag string) {
-	var t T
-	tp := reflect.TypeOf(t)
+	n, yamlTag := findNodeByValidatorNamespace(o, tp, validatorNamespace, node)
+	return n.Line, n.Column, yamlTag
+}
 
+// isExplicitNull returns true if n is a null literal like `null` or `~`
+// as opposed to an implicit null value (like `field:`).
+func isExplicitNull(n *yaml.Node) bool {
+	if n.Alias != nil {
+		n = n.Alias
+	}
+	return n.Kind == yaml.ScalarNode && n.Tag == "!!null" && n.Value != ""
+}
+
+// findNodeByValidatorNamespace is similar to mustFindLocationByValidatorNamespace
+// but returns the node instead of its location. If the namespace can't be
+// resolved entirely the node of the last resolved element is returned.
+func findNodeByValidatorNamespace(
+	o *options, tp reflect.Type, validatorNamespace string, node *yaml.Node,
+) (n *yaml.Node, yamlTag string) {
+	n, yamlTag, _ = resolveNodeByValidatorNamespace(o, tp, validatorNamespace, node)
+	return n, yamlTag
+}
+
+// resolveNodeByValidatorNamespace is similar to findNodeByValidatorNamespace
+// but additionally returns whether the namespace was resolved entirely,
+// which isn't the case for fields that are ignored or missing in node.
+func resolveNodeByValidatorNamespace(
+	o *options, tp reflect.Type, validatorNamespace string, node *yaml.Node,
+) (n *yaml.Node, yamlTag string, resolved bool) {
 	// Remove the type prefix, assuming validatorNamespace starts with the type name
 	_, validatorNamespace = leftmostPathElement(validatorNamespace)
 
-	currentTp, currentNode := tp, rootNode.Content[0]
-	var fieldName string
+	currentTp, currentNode := tp, node
+	var element string
 
 FOR_PATH:
 	for {
-		fieldName, validatorNamespace = leftmostPathElement(validatorNamespace)
-		if fieldName == "" {
+		element, validatorNamespace = leftmostPathElement(validatorNamespace)
+		if element == "" {
+			resolved = true
+			break
+		}
+		if currentTp.Kind() == reflect.Interface {
+			n := currentNode
+			if n.Alias != nil {
+				n = n.Alias
+			}
+			p, ok := o.polymorphicNodes[n]
+			if !ok {
+				break
+			}
+			currentTp, currentNode = p.concrete, n
+		}
+		for currentTp.Kind() == reflect.Pointer {
+			currentTp = currentTp.Elem()
+		}
+		if currentTp.Kind() != reflect.Struct {
 			break
 		}
+		fieldName, indexes := splitPathIndexes(element)
 		f, _ := currentTp.FieldByName(fieldName)
 		yamlTag = getYAMLFieldName(f.Tag)
 		if yamlTag == "-" {
-			continue // Ignored field.
+			break // Ignored field.
 		}
 		for i := 0; i < len(currentNode.Content); i += 2 {
 			if currentNode.Content[i].Value == yamlTag {
 				currentTp = f.Type
 				currentNode = currentNode.Content[i+1]
+				for _, index := range indexes {
+					n := findItemNode(currentNode, index)
+					if n == nil {
+						break FOR_PATH
+					}
+					for currentTp.Kind() == reflect.Pointer {
+						currentTp = currentTp.Elem()
+					}
+					currentTp, currentNode = currentTp.Elem(), n
+				}
 				continue FOR_PATH
 			}
 		}
 		break // Not found
 	}
-	return currentNode.Line, currentNode.Column, yamlTag
+	return currentNode, yamlTag, resolved
 }
 
 func leftmostPathElement(s string) (element, rest string) {
@@ -670,49 +1575,188 @@ func leftmostPathElement(s string) (element, rest string) {
 	return s, ""
 }
 
+// splitPathIndexes splits path element `Name[a][b]` into `Name` and [`a`, `b`].
+func splitPathIndexes(element string) (name string, indexes []string) {
+	name, rest, _ := strings.Cut(element, "[")
+	for rest != "" {
+		var index string
+		index, rest, _ = strings.Cut(rest, "]")
+		indexes = append(indexes, index)
+		rest = strings.TrimPrefix(rest, "[")
+	}
+	return name, indexes
+}
+
+// findItemNode returns the item node at index of sequence node n
+// or the value node of key index of mapping node n.
+// Returns nil if there's no such item.
+func findItemNode(n *yaml.Node, index string) *yaml.Node {
+	if n.Alias != nil {
+		n = n.Alias
+	}
+	switch n.Kind {
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= len(n.Content) {
+			return nil
+		}
+		return n.Content[i]
+	case yaml.MappingNode:
+		for i := 0; i < len(n.Content); i += 2 {
+			if n.Content[i].Value == index {
+				return n.Content[i+1]
+			}
+		}
+	}
+	return nil
+}
+
+// findDuplicateItem returns the index of the first item of slice or array v
+// that equals a preceding item at index orig as checked by the validator tag
+// `unique`. If field isn't empty then the items are compared by the value of
+// their struct field named field. Pointers are compared by what they point to
+// like the validator does. Returns dup -1 if there are no duplicates
+// or the items aren't comparable.
+func findDuplicateItem(v reflect.Value, field string) (dup, orig int) {
+	if k := v.Kind(); k != reflect.Slice && k != reflect.Array {
+		return -1, -1
+	}
+	seen := make(map[any]int, v.Len())
+	for i := range v.Len() {
+		item := derefPointers(v.Index(i))
+		if field != "" {
+			if item.Kind() != reflect.Struct {
+				return -1, -1
+			}
+			item = item.FieldByName(field)
+			if !item.IsValid() {
+				return -1, -1
+			}
+			item = derefPointers(item)
+		}
+		if !item.CanInterface() || !item.Comparable() {
+			// Values of interfaces may not be comparable dynamically.
+			return -1, -1
+		}
+		key := item.Interface()
+		if o, ok := seen[key]; ok {
+			return i, o
+		}
+		seen[key] = i
+	}
+	return -1, -1
+}
+
+// derefPointers returns what v points to through any number of pointers
+// or v itself if it isn't a pointer or any of the pointers is nil.
+func derefPointers(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
 type anchor struct {
 	*yaml.Node
 	Defined bool
 	IsUsed  bool
+	Type    reflect.Type // Type of the field the anchor is defined on.
+	Path    string       // Go path of the field the anchor is defined on.
 }
 
 // validateYAMLValues returns an error if the yaml model contains illegal values
 // or is missing values specified by T. Assumes that tp has already been validated.
 func validateYAMLValues(
-	anchors map[string]*anchor, yamlTag, path string, tp reflect.Type, node *yaml.Node,
+	o *options, anchors map[string]*anchor,
+	yamlTag, path string, tp reflect.Type, node *yaml.Node,
 ) error {
-	if err := validateValue(tp, node); err != nil {
-		if yamlTag != "" {
-			return fmt.Errorf("at %d:%d: %q (%s): %w",
-				node.Line, node.Column, yamlTag, path, err)
+	if o.report != nil {
+		o.report.NodesWalked++
+	}
+
+	if o.maxDepth > 0 {
+		if o.depth > o.maxDepth {
+			err := fmt.Errorf("%w: exceeds maximum depth %d", ErrYAMLTooDeep, o.maxDepth)
+			return o.valueError(node, yamlTag, path, err)
 		}
-		return fmt.Errorf("at %d:%d: %s: %w",
-			node.Line, node.Column, path, err)
+		o.depth++
+		defer func() { o.depth-- }()
+	}
+
+	if o.trimTrailingSpace && node.Kind == yaml.ScalarNode && node.Style == 0 {
+		if v := strings.TrimRightFunc(node.Value, isHorizontalSpace); v != node.Value {
+			// Resolve the tag again since it may have changed
+			// from !!str to !!int for example.
+			node.Value, node.Tag = v, ""
+			node.Tag = node.ShortTag()
+		}
+	}
+
+	if o.extendedBooleans {
+		if v, ok := extendedBool(tp, node); ok {
+			node = o.coercible(node)
+			node.Tag, node.Value = "!!bool", v
+		}
+	}
+
+	if err := validateValue(tp, node); err != nil {
+		return o.valueError(node, yamlTag, path, err)
 	}
 
 	if node.Anchor != "" {
 		if p, ok := anchors[node.Anchor]; ok && p.Defined {
-			return fmt.Errorf("at %d:%d: redefined anchor %q at %d:%d: %w",
-				node.Line, node.Column,
-				node.Anchor,
-				p.Line, p.Column,
-				ErrYAMLAnchorRedefined)
+			return o.errorAt(node.Line, node.Column, path,
+				fmt.Errorf("redefined anchor %q at %d:%d: %w",
+					node.Anchor, p.Line, p.Column, ErrYAMLAnchorRedefined))
 		}
 		if node.Value == "" && node.Style != yaml.DoubleQuotedStyle &&
 			node.Style != yaml.SingleQuotedStyle && len(node.Content) < 1 {
-			return fmt.Errorf("at %d:%d: anchor %q: %w",
-				node.Line, node.Column, node.Anchor, ErrYAMLAnchorNoValue)
+			return o.errorAt(node.Line, node.Column, path,
+				fmt.Errorf("anchor %q: %w", node.Anchor, ErrYAMLAnchorNoValue))
+		}
+		if o.anchorNamePolicy != nil {
+			if err := o.anchorNamePolicy(node.Anchor); err != nil {
+				return o.errorAt(node.Line, node.Column, path,
+					fmt.Errorf("anchor %q: %w: %w", node.Anchor, ErrYAMLAnchorName, err))
+			}
+		}
+		usedBefore := anchors[node.Anchor] != nil && anchors[node.Anchor].IsUsed
+		anchors[node.Anchor] = &anchor{
+			Node: node, Defined: true, IsUsed: usedBefore, Type: tp, Path: path,
 		}
-		anchors[node.Anchor] = &anchor{Node: node, Defined: true}
 	}
 	if node.Alias != nil {
-		anchors[node.Alias.Anchor].IsUsed = true
+		a := anchors[node.Alias.Anchor]
+		if a == nil {
+			// The anchor is defined in a part of the document
+			// that wasn't visited (yet).
+			a = &anchor{Node: node.Alias}
+			anchors[node.Alias.Anchor] = a
+		}
+		a.IsUsed = true
+		if err := checkAliasType(o, a, yamlTag, path, tp, node); err != nil {
+			return err
+		}
+		if o.report != nil {
+			o.report.AliasResolutions = append(o.report.AliasResolutions,
+				AliasResolution{
+					GoPath:     path,
+					AnchorName: node.Alias.Anchor,
+					DefinedAt:  Position{Line: node.Alias.Line, Column: node.Alias.Column},
+				})
+		}
 	}
 
 	if implementsInterface[encoding.TextUnmarshaler](tp) &&
 		node.Kind != yaml.ScalarNode {
-		return fmt.Errorf("at %d:%d: %w: %s",
-			node.Line, node.Column, ErrYAMLNonStrOnTextUnmarsh, tp.String())
+		return o.errorAt(node.Line, node.Column, path,
+			fmt.Errorf("%w: %s", ErrYAMLNonStrOnTextUnmarsh, tp.String()))
+	}
+
+	if o.emptyStringAsNull && node.Kind == yaml.ScalarNode &&
+		node.Tag == "!!str" && node.Value == "" && isStringPointer(tp) {
+		// Make the decoder leave the pointer nil.
+		node.Tag, node.Style = "!!null", 0
 	}
 
 	if tp.Kind() == reflect.Pointer {
@@ -725,72 +1769,732 @@ func validateYAMLValues(
 		}
 	}
 
+	if p := o.polymorphic[tp]; p != nil {
+		if node.Tag == "!!null" {
+			return nil // Nil interface.
+		}
+		return validatePolymorphicValue(o, anchors, p, yamlTag, path, node)
+	}
+
+	if o.strictUnmarshalers && node.Kind == yaml.ScalarNode &&
+		node.Tag == "!!null" && node.Value == "" &&
+		(implementsInterface[encoding.TextUnmarshaler](tp) ||
+			implementsInterface[yaml.Unmarshaler](tp)) {
+		// The decoder wouldn't invoke the unmarshaler
+		// and silently leave the zero value instead.
+		return o.valueError(node, yamlTag, path, ErrYAMLEmptyValueForUnmarshaler)
+	}
+
+	scalar := node
+	if node.Alias != nil {
+		scalar = node.Alias
+	}
+
+	if o.quotedNumberCoercion && isQuotedNumber(tp, scalar) && o.enums[tp] == nil {
+		node = o.coercible(node)
+		if err := coerceQuotedNumber(tp, node); err != nil {
+			return o.valueError(node, yamlTag, path, err)
+		}
+	}
+
+	if e := o.enums[tp]; e != nil && scalar.Kind == yaml.ScalarNode &&
+		scalar.Tag != "!!null" {
+		node = o.coercible(node)
+		if err := e.resolveNode(node); err != nil {
+			return o.valueError(node, yamlTag, path, err)
+		}
+	}
+
+	if err := validateNodeKind(tp, node); err != nil {
+		return err
+	}
+
+	if err := o.expandEnvNode(tp, node); err != nil {
+		return o.valueError(node, yamlTag, path, err)
+	}
+
+	if err := checkEnumNode(tp, node); err != nil {
+		return o.valueError(node, yamlTag, path, err)
+	}
+
+	if err := validateTextUnmarshalerValue(tp, node); err != nil {
+		return o.valueError(node, yamlTag, path, err)
+	}
+
 	switch tp.Kind() {
 	case reflect.Struct:
 		if implementsInterface[encoding.TextUnmarshaler](tp) ||
 			implementsInterface[yaml.Unmarshaler](tp) {
 			return nil
 		}
-		for i := range tp.NumField() {
-			f := tp.Field(i)
-			if !f.IsExported() {
-				continue
+		if err := checkDuplicateKeys(o, path, node); err != nil {
+			return err
+		}
+		if err := validateKnownFields(o, path, tp, node); err != nil {
+			return err
+		}
+		return validateStructFields(o, anchors, path, tp, node)
+	case reflect.Slice, reflect.Array:
+		tp := tp.Elem()
+		for index, item := range node.Content {
+			if item.Tag == "!!null" && item.Value == "" {
+				// If it's a null item with no value then no zero value item would be
+				// appended to a Go slice.
+				return o.valueError(item, yamlTag, path, ErrYAMLEmptyArrayItem)
 			}
-			yamlTag := getYAMLFieldName(f.Tag)
-			if yamlTag == "-" {
-				continue // Ignored field.
+			path := fmt.Sprintf("%s[%d]", path, index)
+			if err := validateYAMLValues(o, anchors, yamlTag, path, tp, item); err != nil {
+				return err
 			}
-			path := path + "." + f.Name
-			contentNode := node
-			if !f.Anonymous {
-				contentNode = findContentNodeByTag(node, yamlTag)
-			}
-			if contentNode == nil {
-				return fmt.Errorf("at %s (as %q): %w",
-					path, yamlTag, ErrYAMLMissingConfig)
-			}
-			for _, n := range contentNode.Content {
-				if n.Tag == "!!merge" {
-					return fmt.Errorf("at %d:%d: %w",
-						n.Line, n.Column, ErrYAMLMergeKey)
-				}
+			node.Content[index] = o.replaceCoerced(item)
+		}
+	case reflect.Map:
+		if err := checkDuplicateKeys(o, path, node); err != nil {
+			return err
+		}
+		tpKey, tpVal := tp.Key(), tp.Elem()
+		keys := make(map[any]*yaml.Node, len(node.Content)/2)
+		for i := 0; i < len(node.Content); i += 2 {
+			path := fmt.Sprintf("%s[%q]", path, node.Content[i].Value)
+			// Validate key
+			err := validateYAMLValues(o, anchors, yamlTag, path, tpKey, node.Content[i])
+			if err != nil {
+				return err
+			}
+			node.Content[i] = o.replaceCoerced(node.Content[i])
+			if err := checkDuplicateMapKey(
+				o, keys, yamlTag, path, tpKey, node.Content[i],
+			); err != nil {
+				return err
 			}
-			err := validateYAMLValues(anchors, yamlTag, path, f.Type, contentNode)
+			// Validate value
+			err = validateYAMLValues(o, anchors, yamlTag, path, tpVal, node.Content[i+1])
 			if err != nil {
 				return err
 			}
+			node.Content[i+1] = o.replaceCoerced(node.Content[i+1])
 		}
-	case reflect.Slice, reflect.Array:
-		tp := tp.Elem()
-		for index, node := range node.Content {
-			if node.Tag == "!!null" && node.Value == "" {
-				// If it's a null item with no value then no zero value item would be
-				// appended to a Go slice.
-				return fmt.Errorf("at %d:%d: %q (%s): %w",
-					node.Line, node.Column, yamlTag, path, ErrYAMLEmptyArrayItem)
+	}
+	return nil
+}
+
+// isStringPointer returns true if tp is a pointer to a string type
+// that doesn't implement an unmarshaler interface.
+func isStringPointer(tp reflect.Type) bool {
+	if tp.Kind() != reflect.Pointer || tp.Elem().Kind() != reflect.String {
+		return false
+	}
+	return !implementsInterface[encoding.TextUnmarshaler](tp.Elem()) &&
+		!implementsInterface[yaml.Unmarshaler](tp.Elem())
+}
+
+// checkAliasType checks whether the scalar value of anchor a is compatible
+// with type tp of the field alias node is used for. Incompatible types are
+// reported as error if WithStrictAliasTypes is used or
+// as warning in the LoadReport otherwise.
+func checkAliasType(
+	o *options, a *anchor, yamlTag, path string, tp reflect.Type, node *yaml.Node,
+) error {
+	if a.Type == nil || a.Kind != yaml.ScalarNode || a.Tag == "!!null" {
+		return nil
+	}
+	defined, used := scalarClass(a.Type), scalarClass(tp)
+	if defined == "" || used == "" || defined == used ||
+		(defined == "integer" && used == "float") {
+		return nil
+	}
+	err := o.valueError(node, yamlTag, path, fmt.Errorf(
+		"%w: anchor %q defined at %d:%d for %s (%s), used for %s",
+		ErrYAMLAliasTypeMismatch,
+		a.Anchor, a.Line, a.Column, a.Path, a.Type.String(), tp.String()))
+	if o.strictAliasTypes {
+		return err
+	}
+	if o.report != nil {
+		o.report.Warnings = append(o.report.Warnings, err.Error())
+	}
+	return nil
+}
+
+// scalarClass returns the class of values a scalar of type tp accepts
+// or an empty string if tp isn't a scalar type.
+func scalarClass(tp reflect.Type) string {
+	for tp.Kind() == reflect.Pointer {
+		tp = tp.Elem()
+	}
+	switch {
+	case tp == typeTimeDuration:
+		return "duration"
+	case implementsInterface[encoding.TextUnmarshaler](tp),
+		implementsInterface[yaml.Unmarshaler](tp):
+		return ""
+	}
+	switch tp.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	}
+	return ""
+}
+
+// isQuotedNumber returns true if node is a quoted scalar
+// and tp is a numeric type other than time.Duration.
+func isQuotedNumber(tp reflect.Type, node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode ||
+		(node.Style != yaml.DoubleQuotedStyle && node.Style != yaml.SingleQuotedStyle) ||
+		tp == typeTimeDuration ||
+		implementsInterface[encoding.TextUnmarshaler](tp) ||
+		implementsInterface[yaml.Unmarshaler](tp) {
+		return false
+	}
+	switch tp.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// coerceQuotedNumber parses the value of quoted scalar node as number type tp
+// and turns node into a plain scalar holding the number.
+// node must not be shared, see coercible.
+func coerceQuotedNumber(tp reflect.Type, node *yaml.Node) error {
+	v := reflect.New(tp).Elem()
+	if err := setFromString(v, node.Value); err != nil {
+		return fmt.Errorf("%w: %w", ErrYAMLInvalidQuotedNumber, err)
+	}
+	switch tp.Kind() {
+	case reflect.Float32, reflect.Float64:
+		node.Tag = "!!float"
+		node.Value = formatFloat(v.Float(), 'g', -1, tp.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		node.Tag, node.Value = "!!int", strconv.FormatUint(v.Uint(), 10)
+	default:
+		node.Tag, node.Value = "!!int", strconv.FormatInt(v.Int(), 10)
+	}
+	node.Style = 0
+	return nil
+}
+
+// extendedBool returns `true` or `false` if plain scalar node holds one of
+// the YAML 1.1 boolean literals `yes`, `no`, `on` or `off` in any case
+// and tp is a bool type or a pointer to one.
+func extendedBool(tp reflect.Type, node *yaml.Node) (value string, ok bool) {
+	for tp.Kind() == reflect.Pointer {
+		tp = tp.Elem()
+	}
+	if tp.Kind() != reflect.Bool || node.Kind != yaml.ScalarNode || node.Style != 0 ||
+		implementsInterface[encoding.TextUnmarshaler](tp) ||
+		implementsInterface[yaml.Unmarshaler](tp) {
+		return "", false
+	}
+	switch strings.ToLower(node.Value) {
+	case "yes", "on":
+		return "true", true
+	case "no", "off":
+		return "false", true
+	}
+	return "", false
+}
+
+// coercible returns node for its value to be coerced in place.
+// The values of anchors and aliases are shared by all aliases
+// which may be of other types, therefore a copy of the shared scalar
+// is returned instead that replaces node in the document once
+// validateYAMLValues returns (see replaceCoerced).
+func (o *options) coercible(node *yaml.Node) *yaml.Node {
+	if node.Anchor == "" && node.Alias == nil {
+		return node
+	}
+	if c := o.coerced[node]; c != nil {
+		return c
+	}
+	c := *node
+	if node.Alias != nil {
+		c = *node.Alias
+		// Report errors at the location of the alias.
+		c.Anchor, c.Line, c.Column = "", node.Line, node.Column
+	}
+	if o.coerced == nil {
+		o.coerced = make(map[*yaml.Node]*yaml.Node)
+	}
+	o.coerced[node] = &c
+	return &c
+}
+
+// replaceCoerced returns the coerced copy of node if there is one,
+// otherwise returns node.
+func (o *options) replaceCoerced(node *yaml.Node) *yaml.Node {
+	if c := o.coerced[node]; c != nil {
+		return c
+	}
+	return node
+}
+
+// isHorizontalSpace returns true for all Unicode white space characters
+// except line breaks.
+func isHorizontalSpace(r rune) bool {
+	switch r {
+	case '\n', '\r', '\v', '\f', '\u0085', '\u2028', '\u2029':
+		return false
+	}
+	return unicode.IsSpace(r)
+}
+
+// checkDuplicateKeys returns ErrYAMLDuplicateKey if mapping node
+// contains the same key more than once, which the decoder would
+// otherwise either reject without a location or silently overwrite.
+// Keys are compared by their tag and value, aliases are resolved.
+func checkDuplicateKeys(o *options, path string, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	type key struct{ tag, value string }
+	keys := make(map[key]bool, len(node.Content)/2)
+	for i := 0; i < len(node.Content); i += 2 {
+		k := node.Content[i]
+		resolved := k
+		if resolved.Alias != nil {
+			resolved = resolved.Alias
+		}
+		if resolved.Kind != yaml.ScalarNode {
+			continue
+		}
+		id := key{tag: resolved.ShortTag(), value: resolved.Value}
+		if keys[id] {
+			return o.errorAt(k.Line, k.Column, path,
+				fmt.Errorf("%w %q", ErrYAMLDuplicateKey, resolved.Value))
+		}
+		keys[id] = true
+	}
+	return nil
+}
+
+// checkDuplicateMapKey decodes keyNode into key type tp and returns
+// ErrYAMLDuplicateMapKey if a different key in keys decoded to the same Go value,
+// for example `1` and `0x1` in a map[int]string. keyNode is then added to keys.
+func checkDuplicateMapKey(
+	o *options, keys map[any]*yaml.Node,
+	yamlTag, path string, tp reflect.Type, keyNode *yaml.Node,
+) error {
+	k := reflect.New(tp)
+	if err := keyNode.Decode(k.Interface()); err != nil {
+		// Decoding errors are reported by the decoder later.
+		return nil
+	}
+	key := k.Elem().Interface()
+	if tp.Kind() == reflect.Pointer {
+		// Pointer keys are distinct by address, compare what they point to.
+		for k.Elem().Kind() == reflect.Pointer && !k.Elem().IsNil() {
+			k = k.Elem()
+		}
+		if !k.Elem().Type().Comparable() {
+			return nil
+		}
+		key = k.Elem().Interface()
+	}
+	if f, ok := key.(float64); ok && f != f {
+		return nil // NaN never equals itself.
+	} else if f, ok := key.(float32); ok && f != f {
+		return nil
+	}
+	if prev, ok := keys[key]; ok {
+		return o.valueError(keyNode, yamlTag, path, fmt.Errorf(
+			"%w: %q resolves to the same key as %q at %d:%d",
+			ErrYAMLDuplicateMapKey, keyNode.Value, prev.Value, prev.Line, prev.Column))
+	}
+	keys[key] = keyNode
+	return nil
+}
+
+// validateStructFields validates the values of all fields of struct type tp
+// including the fields of inlined embedded structs which share the same node.
+func validateStructFields(
+	o *options, anchors map[string]*anchor,
+	path string, tp reflect.Type, node *yaml.Node,
+) error {
+	for i := range tp.NumField() {
+		f := tp.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		yamlTag := getYAMLFieldName(f.Tag)
+		if yamlTag == "-" {
+			continue // Ignored field.
+		}
+		path := path + "." + f.Name
+		if f.Anonymous {
+			t := f.Type
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
 			}
-			path := fmt.Sprintf("%s[%d]", path, index)
-			if err := validateYAMLValues(anchors, yamlTag, path, tp, node); err != nil {
+			if err := validateStructFields(o, anchors, path, t, node); err != nil {
+				return err
+			}
+			continue
+		}
+		contentNode := findContentNodeByTag(node, yamlTag)
+		if contentNode == nil && o.defaultProvider != nil &&
+			node.Kind == yaml.MappingNode {
+			n, err := provideDefault(o, path, yamlTag, f.Type, node)
+			if err != nil {
+				return err
+			}
+			if n != nil {
+				o.setSource(path, Provenance{Source: SourceDefault})
+			}
+			contentNode = n
+		}
+		if contentNode == nil && node.Kind == yaml.MappingNode {
+			n, err := defaultTagNode(o, path, yamlTag, f, node)
+			if err != nil {
+				return err
+			}
+			if n != nil {
+				o.setSource(path, Provenance{Source: SourceDefault})
+			}
+			contentNode = n
+		}
+		if contentNode == nil && yamlTagHasOption(f.Tag, "omitempty") {
+			// Marshal omits zero values of such fields,
+			// the decoder leaves them unchanged.
+			continue
+		}
+		if contentNode == nil {
+			err := fmt.Errorf("at %s (as %q): %w",
+				path, yamlTag, ErrYAMLMissingConfig)
+			if o.collectMissing(path, err) {
+				continue
+			}
+			return err
+		}
+		if f.Tag.Get("nullstyle") == nullStyleTilde &&
+			contentNode.Kind == yaml.ScalarNode &&
+			contentNode.Tag == "!!null" && contentNode.Value == "~" {
+			// The field explicitly accepts `~` as null.
+			contentNode.Value = "null"
+		}
+		for _, n := range contentNode.Content {
+			if n.Tag == "!!merge" {
+				return o.errorAt(n.Line, n.Column, path, ErrYAMLMergeKey)
+			}
+		}
+		err := validateYAMLValues(o, anchors, yamlTag, path, f.Type, contentNode)
+		if err != nil {
+			return err
+		}
+		if c := o.replaceCoerced(contentNode); c != contentNode {
+			replaceContentNode(node, contentNode, c)
+			contentNode = c
+		}
+		resolved, err := decryptScalar(o, path, yamlTag, f, contentNode)
+		if err != nil {
+			return err
+		}
+		resolved, err = resolveScalar(o, path, yamlTag, f, resolved)
+		if err != nil {
+			return err
+		}
+		if resolved != contentNode {
+			// Replace the value in the document leaving the original node
+			// intact since it may be an anchor referenced elsewhere.
+			replaceContentNode(node, contentNode, resolved)
+		}
+	}
+	return nil
+}
+
+// collectMissing adds ErrYAMLMissingConfig err of the field at path to
+// the missing fields and returns true if WithReportAllMissing is used.
+// A field is only reported once even if its key is misspelled.
+func (o *options) collectMissing(path string, err error) bool {
+	if !o.reportAllMissing {
+		return false
+	}
+	if o.missingPaths == nil {
+		o.missingPaths = make(map[string]bool)
+	}
+	if !o.missingPaths[path] {
+		o.missingPaths[path] = true
+		o.missing = append(o.missing, err)
+	}
+	return true
+}
+
+// validateDocumentValues is validateYAMLValues for the root node
+// returning all fields missing in the document as a *MultiError
+// if WithReportAllMissing is used.
+func validateDocumentValues(
+	o *options, anchors map[string]*anchor,
+	path string, tp reflect.Type, node *yaml.Node,
+) error {
+	o.missing, o.missingPaths = nil, nil
+	err := validateYAMLValues(o, anchors, "", path, tp, node)
+	if err == nil && len(o.missing) > 0 {
+		err = &MultiError{Errors: o.missing}
+	}
+	o.missing, o.missingPaths = nil, nil
+	return err
+}
+
+// replaceContentNode replaces value node old in mapping node with n.
+func replaceContentNode(node, old, n *yaml.Node) {
+	for i := 1; i < len(node.Content); i += 2 {
+		if node.Content[i] == old {
+			node.Content[i] = n
+			return
+		}
+	}
+}
+
+// provideDefault invokes the default provider for the field at path and
+// appends the provided value to mapping node parent under key yamlTag
+// to be validated and decoded like any other value.
+// Returns nil if the provider doesn't provide a value.
+func provideDefault(
+	o *options, path, yamlTag string, tp reflect.Type, parent *yaml.Node,
+) (*yaml.Node, error) {
+	x, ok := o.defaultProvider(path, tp)
+	if !ok {
+		return nil, nil
+	}
+	var value *yaml.Node
+	switch v := reflect.ValueOf(x); {
+	case x == nil:
+		switch tp.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			// Implicit null since it's not written in the document.
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}
+		default:
+			return nil, fmt.Errorf("at %s (as %q): %w: nil for %s",
+				path, yamlTag, ErrInvalidDefaultValue, tp.String())
+		}
+	case !v.Type().AssignableTo(tp):
+		return nil, fmt.Errorf("at %s (as %q): %w: %s is not assignable to %s",
+			path, yamlTag, ErrInvalidDefaultValue, v.Type().String(), tp.String())
+	default:
+		var err error
+		if value, err = marshalNode(o, path, v); err != nil {
+			return nil, fmt.Errorf("at %s (as %q): %w: %w",
+				path, yamlTag, ErrInvalidDefaultValue, err)
+		}
+	}
+	appendDefaultNode(yamlTag, value, parent)
+	return value, nil
+}
+
+// defaultTagNode appends the value of the `default` or `envDefault`
+// struct tag of field f to mapping node parent where the field is missing
+// and returns it. Env vars overwrite it when the config is decoded.
+// Returns nil if f has neither struct tag.
+func defaultTagNode(
+	o *options, path, yamlTag string, f reflect.StructField, parent *yaml.Node,
+) (*yaml.Node, error) {
+	d, ok := f.Tag.Lookup("default")
+	if !ok {
+		if d, ok = f.Tag.Lookup("envDefault"); !ok {
+			return nil, nil
+		}
+	}
+	v := reflect.New(f.Type).Elem()
+	// The default value was checked by ValidateType already.
+	_ = setFromString(v, d)
+	value, err := marshalNode(o, path, v)
+	if errors.Is(err, ErrTypeNoTextMarshaler) {
+		value, err = newStringNode(d), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("at %s (as %q): %w: %w",
+			path, yamlTag, ErrInvalidDefaultValue, err)
+	}
+	appendDefaultNode(yamlTag, value, parent)
+	return value, nil
+}
+
+// appendDefaultNode appends the key and value of a default for the field
+// with yamlTag to mapping node parent.
+func appendDefaultNode(yamlTag string, value, parent *yaml.Node) {
+	// Report errors at the location of the parent.
+	key := newStringNode(yamlTag)
+	key.Line, key.Column = parent.Line, parent.Column
+	value.Line, value.Column = parent.Line, parent.Column
+	parent.Content = append(parent.Content, key, value)
+}
+
+// validateNodeKind returns an error if node can't be decoded into a struct,
+// slice, array or map type tp because it's of a different kind.
+// The error is produced by the YAML decoder to report the mismatch.
+func validateNodeKind(tp reflect.Type, node *yaml.Node) error {
+	n := node
+	if n.Alias != nil {
+		n = n.Alias
+	}
+	switch tp.Kind() {
+	case reflect.Struct:
+		if n.Kind == yaml.MappingNode ||
+			implementsInterface[encoding.TextUnmarshaler](tp) ||
+			implementsInterface[yaml.Unmarshaler](tp) {
+			return nil
+		}
+	case reflect.Map:
+		if n.Kind == yaml.MappingNode ||
+			implementsInterface[encoding.TextUnmarshaler](tp) ||
+			implementsInterface[yaml.Unmarshaler](tp) {
+			return nil
+		}
+	case reflect.Slice, reflect.Array:
+		if n.Kind == yaml.SequenceNode ||
+			implementsInterface[encoding.TextUnmarshaler](tp) ||
+			implementsInterface[yaml.Unmarshaler](tp) {
+			return nil
+		}
+	default:
+		return nil
+	}
+	if err := n.Decode(reflect.New(tp).Interface()); err != nil {
+		return fmt.Errorf("%w: %w", ErrYAMLMalformed, err)
+	}
+	return nil
+}
+
+// validateTextUnmarshalerValue returns the error returned by UnmarshalText
+// if tp implements encoding.TextUnmarshaler with a pointer receiver and fails
+// to unmarshal the scalar value of node. This allows reporting the location
+// of the invalid value, which the decoder doesn't provide.
+// Implementations with copy receivers are not checked since they may depend on
+// the state of the value they're decoded into.
+// Invalid and empty values of net/netip types are reported as
+// ErrInvalidIPAddress.
+func validateTextUnmarshalerValue(tp reflect.Type, node *yaml.Node) error {
+	ti := reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	if tp.Implements(ti) || !reflect.PointerTo(tp).Implements(ti) {
+		return nil
+	}
+	n := node
+	if n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind != yaml.ScalarNode || n.Tag == "!!null" {
+		return nil
+	}
+	u := reflect.New(tp).Interface().(encoding.TextUnmarshaler)
+	return checkNetipValue(tp, n.Value, u.UnmarshalText([]byte(n.Value)))
+}
+
+// validateKnownFields returns an error if the mapping node contains keys
+// that don't correspond to any field of struct type tp.
+// If an unknown key is similar to the yaml name of a field that's missing
+// then ErrYAMLMissingConfig is returned instead suggesting the unknown key
+// since it's likely a typo. Unknown keys are ignored by LoadProjection
+// and with option WithAllowUnknownFields.
+func validateKnownFields(o *options, path string, tp reflect.Type, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	known := o.yamlFields[tp] // Precomputed by PrecomputeType.
+	if known == nil {
+		known = make(map[string]string, tp.NumField())
+		collectYAMLFieldNames("", tp, known)
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		k := node.Content[i]
+		if k.Tag == "!!merge" {
+			return o.errorAt(k.Line, k.Column, path, ErrYAMLMergeKey)
+		}
+		if p, ok := o.polymorphicNodes[node]; ok && k.Value == p.key {
+			continue // Discriminator of a polymorphic value.
+		}
+		if _, ok := known[k.Value]; !ok && !o.ignoreUnknownFields {
+			if name := findSimilarMissingField(known, node, k.Value); name != "" {
+				err := fmt.Errorf("at %s%s (as %q): %w: found similar key %q at %d:%d",
+					path, known[name], name, ErrYAMLMissingConfig,
+					k.Value, k.Line, k.Column)
+				if o.collectMissing(path+known[name], err) {
+					continue
+				}
 				return err
 			}
+			return o.errorAt(k.Line, k.Column, path,
+				fmt.Errorf("%w: field %q not found in type %s",
+					ErrYAMLMalformed, k.Value, tp.String()))
 		}
-	case reflect.Map:
-		tpKey, tpVal := tp.Key(), tp.Elem()
-		for i := 0; i < len(node.Content); i += 2 {
-			path := fmt.Sprintf("%s[%q]", path, node.Content[i].Value)
-			// Validate key
-			err := validateYAMLValues(anchors, yamlTag, path, tpKey, node.Content[i])
-			if err != nil {
-				return err
+	}
+	return nil
+}
+
+// findSimilarMissingField returns the name of the field in known that's
+// missing in mapping node and most similar to key or "" if there's none.
+// Names are considered similar if they're at most 2 edits apart,
+// but less than half of the length of the name.
+func findSimilarMissingField(known map[string]string, node *yaml.Node, key string) string {
+	best, bestDist := "", 0
+	for name := range known {
+		if findContentNodeByTag(node, name) != nil {
+			continue // Not missing.
+		}
+		d := levenshtein(key, name)
+		if d > 2 || d*2 >= len(name) {
+			continue
+		}
+		if best == "" || d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev, cur := make([]int, len(rb)+1), make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		cur[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
 			}
-			// Validate value
-			err = validateYAMLValues(anchors, yamlTag, path, tpVal, node.Content[i+1])
-			if err != nil {
-				return err
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// collectYAMLFieldNames adds all yaml field names of struct type tp
+// including the ones of inlined embedded structs to m
+// mapping them to the Go path of the field relative to path.
+func collectYAMLFieldNames(path string, tp reflect.Type, m map[string]string) {
+	for i := range tp.NumField() {
+		f := tp.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		yamlTag := getYAMLFieldName(f.Tag)
+		if yamlTag == "-" {
+			continue
+		}
+		path := path + "." + f.Name
+		if f.Anonymous {
+			t := f.Type
+			for t.Kind() == reflect.Pointer {
+				t = t.Elem()
 			}
+			collectYAMLFieldNames(path, t, m)
+			continue
 		}
+		m[yamlTag] = path
 	}
-	return nil
 }
 
 func validateValue(tp reflect.Type, node *yaml.Node) error {
@@ -806,12 +2510,14 @@ func validateValue(tp reflect.Type, node *yaml.Node) error {
 			return ErrYAMLNullOnNonPointer
 		}
 	}
-	if v := node.Value; v == "~" || strings.EqualFold(v, "null") {
+	quoted := node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|
+		yaml.LiteralStyle|yaml.FoldedStyle) != 0
+	if v := node.Value; !quoted && (v == "~" || strings.EqualFold(v, "null")) {
 		if v != "null" {
 			return ErrYAMLBadNullLiteral
 		}
 		switch kind {
-		case reflect.Pointer, reflect.Slice, reflect.Map:
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
 		default:
 			return ErrYAMLNullOnNonPointer
 		}
@@ -823,16 +2529,28 @@ func validateValue(tp reflect.Type, node *yaml.Node) error {
 			return ErrYAMLBadBoolLiteral
 		}
 	}
+	if tp.Kind() == reflect.Pointer && node.ShortTag() != "!!null" {
+		tp = tp.Elem()
+	}
+	if tp == typeTimeDuration && node.Kind == yaml.ScalarNode {
+		// Durations are parsed by time.ParseDuration just like env vars,
+		// numbers aren't accepted since the unit would be ambiguous.
+		_, err := time.ParseDuration(node.Value)
+		if err != nil || node.ShortTag() != "!!str" {
+			return fmt.Errorf("%w, got %q", ErrYAMLBadDuration, node.Value)
+		}
+	}
 	return nil
 }
 
 // ValidateType returns an error if...
 //   - T contains any struct field without a "yaml" struct tag.
 //   - T contains any struct field with an invalid "env" struct tag.
-//   - T is recursive.
+//   - T is recursive (see WithAllowRecursiveTypes for exceptions).
 //   - T contains any unsupported types (signed and unsigned integers with unspecified
-//     width, interface (including `any`), function, channel,
-//     unsafe.Pointer, pointer to pointer, pointer to slice, pointer to map).
+//     width unless WithAllowPlatformInts is used, interface (including `any`),
+//     function, channel, unsafe.Pointer, pointer to pointer, pointer to slice,
+//     pointer to map).
 //   - T is not a struct or implements yaml.Unmarshaler or encoding.TextUnmarshaler.
 //   - T contains any structs with no exported fields.
 //   - T contains any structs with yaml and/or env tags assigned to unexported fields.
@@ -840,128 +2558,324 @@ func validateValue(tp reflect.Type, node *yaml.Node) error {
 //     encoding.TextUnmarshaler that contains fields with yaml or env struct tags.
 //   - T contains any fields with env tag on a type that implements yaml.Unmarshaler.
 //   - T contains any struct containing multiple fields with the same yaml tag.
+//   - T contains any field with an unknown `nullstyle` struct tag value or
+//     with a `nullstyle` struct tag on a type that can't be null.
+//   - T contains any field with a `default` struct tag that can't be parsed.
+//   - T contains any field with an `envDefault` struct tag that can't be parsed,
+//     that has a `default` struct tag or has no `env` struct tag or
+//     an `env` struct tag with option `required`.
+//   - T contains any field with a `validate_when` struct tag that isn't a struct
+//     or doesn't reference a sibling bool field.
+//   - T contains any field with a `redact` struct tag other than true or false.
+//   - T contains any field with a `normalize` struct tag with unknown
+//     normalizations or on a type other than string.
+//   - T contains any field with a `keynormalize` struct tag with unknown
+//     normalizations or on a type other than a map with string keys.
+//   - T contains any field with an empty `resolve` struct tag or
+//     with a `resolve` struct tag on a type that isn't a scalar.
+//   - T contains any field with a `pattern` struct tag that isn't a valid
+//     regular expression or on a type other than string.
+//   - T contains any field with a `format` struct tag with an unknown format,
+//     an argument the format doesn't accept or on a type other than string.
+//   - T contains any field with a `multipleof` struct tag that isn't a positive
+//     integer or on a type other than an integer or a slice of integers.
+//   - T contains any field with a `maxbytes` or `maxrunes` struct tag that isn't
+//     a positive integer or on a type other than a string or a slice of strings.
+//   - T contains any field with an invalid `sorted` struct tag or on a type
+//     other than a slice or array of ordered values or of structs with
+//     an ordered field referenced by the tag.
+//   - T contains any field with a `cidr` struct tag that isn't a list of CIDR
+//     prefixes or on a type other than a string, net.IP, netip.Addr
+//     or a slice of those.
+//   - T contains any field with a `sumbudget` struct tag that doesn't reference
+//     a sibling time.Duration field or on a type other than time.Duration
+//     or a slice of time.Duration.
+//   - T contains any field with a `fileexists` struct tag other than
+//     true or readable or on a type other than string.
+//   - T contains any field with an `encrypted` struct tag other than true or
+//     false, on a type other than string or combined with a `resolve` struct tag.
+//   - T contains any field with a `before` or `after` struct tag that doesn't
+//     reference a sibling field of the same type or on a type other than
+//     time.Time, time.Duration or a number.
+//   - T contains any type implementing Enum that isn't a string or integer
+//     type or whose Enum method returns no values or values that can't be parsed.
+//
+// Interface types registered with WithPolymorphic are accepted by Load and
+// friends as long as all their concrete types are valid.
 func ValidateType[T any]() error {
-	stack := []reflect.Type{}
-	var traverse func(path string, tp reflect.Type) error
-	traverse = func(path string, tp reflect.Type) error {
-		if implementsInterface[encoding.TextUnmarshaler](tp) ||
-			implementsInterface[yaml.Unmarshaler](tp) {
-			return validateTypeImplementingIfaces(path, tp)
+	return newOptions(nil).validateType(reflect.TypeFor[T]())
+}
+
+// validateType is ValidateType for type tp respecting WithAllowRecursiveTypes
+// and WithAllowPlatformInts.
+func (o *options) validateType(tp reflect.Type) error {
+	v := typeValidator{
+		all: false, allowRecursive: o.maxDepth > 0, polymorphic: o.polymorphic,
+		allowPlatformInts: o.allowPlatformInts,
+	}
+	v.validate(tp)
+	if len(v.errs) > 0 {
+		return v.errs[0]
+	}
+	return nil
+}
+
+// ValidateTypeAll is similar to ValidateType but instead of returning the
+// first violation it returns a *MultiError listing every violation found
+// in T where each entry is an *Error carrying the Go path of the violation.
+func ValidateTypeAll[T any]() error {
+	v := typeValidator{all: true}
+	v.validate(reflect.TypeFor[T]())
+	if len(v.errs) > 0 {
+		return &MultiError{Errors: v.errs}
+	}
+	return nil
+}
+
+// typeValidator collects violations of the type rules.
+type typeValidator struct {
+	all               bool                          // If false, stops at the first violation.
+	allowRecursive    bool                          // See WithAllowRecursiveTypes.
+	polymorphic       map[reflect.Type]*polymorphic // See WithPolymorphic.
+	allowPlatformInts bool                          // See WithAllowPlatformInts.
+	stack             []reflect.Type
+	errs              []error
+}
+
+// fail records err for Go path and returns true if traversal must stop.
+func (v *typeValidator) fail(path string, err error) (stop bool) {
+	v.errs = append(v.errs, &Error{GoPath: path, Err: err})
+	return !v.all
+}
+
+func (v *typeValidator) validate(tp reflect.Type) {
+	n := tp.Name()
+	if n == "" {
+		// Anonymous type
+		n = "struct{...}"
+	}
+	if tp.Kind() != reflect.Struct ||
+		implementsInterface[encoding.TextUnmarshaler](tp) ||
+		implementsInterface[yaml.Unmarshaler](tp) {
+		v.fail(n, ErrTypeIllegalRoot)
+		return
+	}
+	v.traverse(n, tp)
+}
+
+// traverse validates tp recursively and returns true if traversal must stop.
+func (v *typeValidator) traverse(path string, tp reflect.Type) (stop bool) {
+	if err := validateEnumType(tp); err != nil {
+		return v.fail(path, err)
+	}
+	if implementsInterface[encoding.TextUnmarshaler](tp) ||
+		implementsInterface[yaml.Unmarshaler](tp) {
+		if err := validateTypeImplementingIfaces(tp); err != nil {
+			return v.fail(path, err)
 		}
+		return false
+	}
 
-		switch tp.Kind() {
-		case reflect.Struct:
-			for _, p := range stack {
-				if p == tp {
-					// Recursive type
-					return fmt.Errorf("at %s: %w",
-						path, ErrTypeRecursive)
-				}
+	switch tp.Kind() {
+	case reflect.Struct:
+		if slices.Contains(v.stack, tp) {
+			if v.allowRecursive {
+				return false // Already being validated.
 			}
-			stack = append(stack, tp) // Push stack
-
-			exportedFields := 0
-			yamlTags := map[string]string{} // tag -> path
-			for i := range tp.NumField() {
-				f := tp.Field(i)
-				yamlTag := getYAMLFieldName(f.Tag)
-				yamlIgnored := yamlTag == "-"
-				path := path + "." + f.Name
-				isExported := f.IsExported()
-				if !yamlIgnored {
-					isInline := yamlTagIsInline(f.Tag)
-					switch {
-					case isExported && f.Anonymous && (yamlTag != "" || !isInline):
-						return fmt.Errorf("at %s: %w", path, ErrYAMLInlineOpt)
-					case isExported && !f.Anonymous && isInline:
-						return fmt.Errorf("at %s: %w", path, ErrYAMLInlineNonAnon)
-					case yamlTag == "" && isExported && !f.Anonymous:
-						return fmt.Errorf("at %s: %w", path, ErrTypeMissingYAMLTag)
-					case yamlTag != "" && !isExported:
-						return fmt.Errorf("at %s: %w", path, ErrYAMLTagOnUnexported)
-					}
-				}
+			return v.fail(path, ErrTypeRecursive)
+		}
+		v.stack = append(v.stack, tp)                         // Push stack
+		defer func() { v.stack = v.stack[:len(v.stack)-1] }() // Pop stack
 
-				if err := validateEnvField(f); err != nil {
-					return fmt.Errorf("at %s: %w", path, err)
+		exportedFields := 0
+		yamlTags := map[string]string{} // tag -> path
+		for i := range tp.NumField() {
+			f := tp.Field(i)
+			yamlTag := getYAMLFieldName(f.Tag)
+			yamlIgnored := yamlTag == "-"
+			path := path + "." + f.Name
+			isExported := f.IsExported()
+			if !yamlIgnored {
+				isInline := yamlTagIsInline(f.Tag)
+				var err error
+				switch {
+				case isExported && f.Anonymous && (yamlTag != "" || !isInline):
+					err = ErrYAMLInlineOpt
+				case isExported && !f.Anonymous && isInline:
+					err = ErrYAMLInlineNonAnon
+				case yamlTag == "" && isExported && !f.Anonymous:
+					err = ErrTypeMissingYAMLTag
+				case yamlTag != "" && !isExported:
+					err = ErrYAMLTagOnUnexported
 				}
+				if err != nil && v.fail(path, err) {
+					return true
+				}
+			}
+
+			if err := v.validateEnvField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateNullStyleField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := v.validateDefaultField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := v.validateEnvDefaultField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			err := validateValidateWhenField(tp, f)
+			if err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateRedactField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateNormalizeField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			err = validateKeyNormalizeField(f)
+			if err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateResolveField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validatePatternField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateFormatField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateEncryptedField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateOrderField(tp, f); err != nil && v.fail(path, err) {
+				return true
+			}
+			err = validateSumBudgetField(tp, f)
+			if err != nil && v.fail(path, err) {
+				return true
+			}
+			err = validateMultipleOfField(f)
+			if err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateMaxLenField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateSortedField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			if err := validateCIDRField(f); err != nil && v.fail(path, err) {
+				return true
+			}
+			err = validateFileExistsField(f)
+			if err != nil && v.fail(path, err) {
+				return true
+			}
 
-				if !isExported || yamlIgnored {
+			if !isExported || yamlIgnored {
+				continue
+			}
+			exportedFields++
+
+			if f.Anonymous && v.allowRecursive {
+				t := f.Type
+				for t.Kind() == reflect.Pointer {
+					t = t.Elem()
+				}
+				if slices.Contains(v.stack, t) {
+					// Inlining a type into itself is never bounded.
+					if v.fail(path, ErrTypeRecursive) {
+						return true
+					}
 					continue
 				}
-				exportedFields++
-
-				// Avoid checking tag redifinition for embedded fields.
-				// For embedded fields yamlTag will always be == "".
-				if yamlTag != "" {
-					if previous, ok := yamlTags[yamlTag]; ok {
-						return fmt.Errorf(
-							"at %s: yaml tag %q previously defined on field %s: %w",
-							path, yamlTag, previous, ErrYAMLTagRedefined)
+			}
+
+			// Avoid checking tag redifinition for embedded fields.
+			// For embedded fields yamlTag will always be == "".
+			if yamlTag != "" {
+				if previous, ok := yamlTags[yamlTag]; ok {
+					if v.fail(path, fmt.Errorf(
+						"yaml tag %q previously defined on field %s: %w",
+						yamlTag, previous, ErrYAMLTagRedefined,
+					)) {
+						return true
 					}
+				} else {
 					yamlTags[yamlTag] = path
 				}
-				err := traverse(path, f.Type)
-				if err != nil {
-					return err
-				}
 			}
-			if exportedFields < 1 {
-				return fmt.Errorf("at %s: %w", path, ErrTypeNoExportedFields)
+			if v.traverse(path, f.Type) {
+				return true
 			}
-			stack = stack[:len(stack)-1] // Pop stack
-			return nil
-		case reflect.Chan,
-			reflect.Func,
-			reflect.Interface,
-			reflect.UnsafePointer:
-			return fmt.Errorf("at %s: %w: %s", path, ErrTypeUnsupported, tp.String())
-		case reflect.Pointer:
-			tp = tp.Elem()
-			switch tp.Kind() {
-			case reflect.Pointer, reflect.Slice, reflect.Map:
-				return fmt.Errorf("at %s: %w", path, ErrTypeUnsupportedPtrType)
-			}
-			return traverse(path, tp)
-		case reflect.Int:
-			return fmt.Errorf("at %s: %w: %s, %s",
-				path, ErrTypeUnsupported, tp.String(),
-				"use integer type with specified width, "+
-					"such as int8, int16, int32 or int64 instead of int")
-		case reflect.Uint:
-			return fmt.Errorf("at %s: %w: %s, %s",
-				path, ErrTypeUnsupported, tp.String(),
-				"use unsigned integer type with specified width, "+
-					"such as uint8, uint16, uint32 or uint64 instead of uint")
-		case reflect.Slice, reflect.Array:
-			return traverse(path, tp.Elem())
-		case reflect.Map:
-			if err := traverse(path+"[key]", tp.Key()); err != nil {
-				return err
+		}
+		if exportedFields < 1 {
+			return v.fail(path, ErrTypeNoExportedFields)
+		}
+		return false
+	case reflect.Interface:
+		p := v.polymorphic[tp]
+		if p == nil {
+			return v.fail(path, fmt.Errorf("%w: %s", ErrTypeUnsupported, tp.String()))
+		}
+		for _, name := range p.sortedNames {
+			concrete := p.registry[name]
+			if err := validateConcreteType(tp, name, concrete); err != nil {
+				if v.fail(path, err) {
+					return true
+				}
+				continue
+			}
+			if v.traverse(path, concrete) {
+				return true
 			}
-			return traverse(path+"[value]", tp.Elem())
 		}
-		return nil
-	}
-	var t T
-	tp := reflect.TypeOf(t)
-
-	n := tp.Name()
-	if n == "" {
-		// Anonymous type
-		n = "struct{...}"
-	}
-	if tp.Kind() != reflect.Struct ||
-		implementsInterface[encoding.TextUnmarshaler](tp) ||
-		implementsInterface[yaml.Unmarshaler](tp) {
-		return fmt.Errorf("at %s: %w", n, ErrTypeIllegalRoot)
+		return false
+	case reflect.Chan,
+		reflect.Func,
+		reflect.UnsafePointer:
+		return v.fail(path, fmt.Errorf("%w: %s", ErrTypeUnsupported, tp.String()))
+	case reflect.Pointer:
+		tp = tp.Elem()
+		switch tp.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			return v.fail(path, ErrTypeUnsupportedPtrType)
+		}
+		return v.traverse(path, tp)
+	case reflect.Int:
+		if v.allowPlatformInts {
+			return false
+		}
+		return v.fail(path, fmt.Errorf("%w: %s, %s",
+			ErrTypeUnsupported, tp.String(),
+			"use integer type with specified width, "+
+				"such as int8, int16, int32 or int64 instead of int"))
+	case reflect.Uint:
+		if v.allowPlatformInts {
+			return false
+		}
+		return v.fail(path, fmt.Errorf("%w: %s, %s",
+			ErrTypeUnsupported, tp.String(),
+			"use unsigned integer type with specified width, "+
+				"such as uint8, uint16, uint32 or uint64 instead of uint"))
+	case reflect.Slice, reflect.Array:
+		return v.traverse(path, tp.Elem())
+	case reflect.Map:
+		if v.traverse(path+"[key]", tp.Key()) {
+			return true
+		}
+		return v.traverse(path+"[value]", tp.Elem())
 	}
-	return traverse(n, tp)
+	return false
 }
 
 // validateTypeImplementingIfaces assumes that implementer is
 // implementing either encoding.TextUnmarshaler or yaml.Unmarshaler
-func validateTypeImplementingIfaces(path string, implementer reflect.Type) error {
+func validateTypeImplementingIfaces(implementer reflect.Type) error {
 	implementedIface := "yaml.Unmarshaler"
 	if implementsInterface[encoding.TextUnmarshaler](implementer) {
 		implementedIface = "encoding.TextUnmarshaler"
@@ -972,13 +2886,13 @@ func validateTypeImplementingIfaces(path string, implementer reflect.Type) error
 	for i := range implementer.NumField() {
 		f := implementer.Field(i)
 		if tag := getYAMLFieldName(f.Tag); tag != "" && tag != "-" {
-			return fmt.Errorf("at %s: struct implements %s but field contains tag "+
-				"\"yaml\" (%q): %w", path, implementedIface, tag,
+			return fmt.Errorf("struct implements %s but field contains tag "+
+				"\"yaml\" (%q): %w", implementedIface, tag,
 				ErrTypeTagOnInterfaceImpl)
 		}
 		if tag := f.Tag.Get("env"); tag != "" {
-			return fmt.Errorf("at %s: struct implements %s but field contains tag "+
-				"\"env\" (%q): %w", path, implementedIface, tag,
+			return fmt.Errorf("struct implements %s but field contains tag "+
+				"\"env\" (%q): %w", implementedIface, tag,
 				ErrTypeTagOnInterfaceImpl)
 		}
 	}
@@ -1004,17 +2918,21 @@ func getYAMLFieldName(t reflect.StructTag) string {
 }
 
 func yamlTagIsInline(t reflect.StructTag) bool {
+	return yamlTagHasOption(t, "inline")
+}
+
+func yamlTagHasOption(t reflect.StructTag, option string) bool {
 	yamlTag := t.Get("yaml")
 	opts := strings.Split(yamlTag, ",")
-	for _, opt := range opts {
-		if opt == "inline" {
+	for _, opt := range opts[1:] {
+		if opt == option {
 			return true
 		}
 	}
 	return false
 }
 
-func validateEnvField(f reflect.StructField) error {
+func (v *typeValidator) validateEnvField(f reflect.StructField) error {
 	n, ok := f.Tag.Lookup("env")
 	if !ok {
 		return nil
@@ -1024,30 +2942,282 @@ func validateEnvField(f reflect.StructField) error {
 		return ErrTypeEnvTagOnUnexported
 	}
 
-	if n == "" || !regexEnvVarPOSIX.MatchString(n) {
+	names, required, exclusive := parseEnvTag(n)
+	if len(names) < 1 {
 		return ErrTypeInvalidEnvTag
 	}
+	for _, name := range names {
+		if !regexEnvVarPOSIX.MatchString(name) {
+			return ErrTypeInvalidEnvTag
+		}
+	}
 
 	if implementsInterface[yaml.Unmarshaler](f.Type) {
 		return fmt.Errorf("%w: %s", ErrTypeEnvOnYAMLUnmarsh, f.Type.String())
 	}
 
-	switch k := f.Type.Kind(); {
-	case kindIsPrimitive(k):
+	if v.isEnvSupportedType(f.Type) {
+		return nil
+	}
+	if f.Type.Kind() == reflect.Map && f.Type.Key().Kind() == reflect.String &&
+		!implementsInterface[encoding.TextUnmarshaler](f.Type.Key()) &&
+		v.isEnvSupportedType(f.Type.Elem()) {
+		// Map entries are overwritten by env vars when using
+		// option WithIndexedEnvOverrides.
+		if required {
+			return fmt.Errorf("%w: option required on %s",
+				ErrTypeEnvVarOnUnsupportedType, f.Type.String())
+		}
+		if exclusive {
+			return fmt.Errorf("%w: option exclusive on %s",
+				ErrTypeEnvVarOnUnsupportedType, f.Type.String())
+		}
 		return nil
-	case k == reflect.Pointer && kindIsPrimitive(f.Type.Elem().Kind()):
+	}
+	return fmt.Errorf("%w: %s", ErrTypeEnvVarOnUnsupportedType, f.Type.String())
+}
+
+// parseEnvTag parses `env` struct tag tag of the form `A,B,required,exclusive`
+// into the list of env var names to look up in order and
+// whether options "required" and "exclusive" are set.
+func parseEnvTag(tag string) (names []string, required, exclusive bool) {
+	if tag == "" {
+		return nil, false, false
+	}
+	for _, n := range strings.Split(tag, ",") {
+		switch n {
+		case "required":
+			required = true
+		case "exclusive":
+			exclusive = true
+		default:
+			names = append(names, n)
+		}
+	}
+	return names, required, exclusive
+}
+
+// isEnvSupportedType is isEnvSupportedType respecting WithAllowPlatformInts.
+func (v *typeValidator) isEnvSupportedType(t reflect.Type) bool {
+	if v.allowPlatformInts {
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if kindIsPlatformInt(t.Kind()) {
+			return true
+		}
+	}
+	return isEnvSupportedType(t)
+}
+
+// isEnvSupportedType returns true if values of type t can be parsed from env vars.
+func isEnvSupportedType(t reflect.Type) bool {
+	switch k := t.Kind(); {
+	case kindIsPrimitive(k):
+		return true
+	case k == reflect.Pointer && kindIsPrimitive(t.Elem().Kind()):
 		// Pointer to primitve
+		return true
+	case implementsInterface[encoding.TextUnmarshaler](t):
+		return true
+	}
+	return false
+}
+
+func (v *typeValidator) validateDefaultField(f reflect.StructField) error {
+	d, ok := f.Tag.Lookup("default")
+	if !ok {
+		return nil
+	}
+	if !f.IsExported() {
+		return fmt.Errorf("%w: unexported field", ErrTypeInvalidDefaultTag)
+	}
+	return v.checkTagValue(ErrTypeInvalidDefaultTag, f.Type, d)
+}
+
+// validateEnvDefaultField checks the `envDefault` struct tag of field f
+// which is only allowed on fields that have an `env` struct tag without
+// option "required" and no `default` struct tag.
+func (v *typeValidator) validateEnvDefaultField(f reflect.StructField) error {
+	d, ok := f.Tag.Lookup("envDefault")
+	if !ok {
 		return nil
-	case implementsInterface[encoding.TextUnmarshaler](f.Type):
+	}
+	envTag, ok := f.Tag.Lookup("env")
+	if !ok {
+		return fmt.Errorf("%w: missing env struct tag", ErrTypeInvalidEnvDefaultTag)
+	}
+	if _, ok := f.Tag.Lookup("default"); ok {
+		return fmt.Errorf("%w: conflicts with default struct tag",
+			ErrTypeInvalidEnvDefaultTag)
+	}
+	if _, required, _ := parseEnvTag(envTag); required {
+		return fmt.Errorf("%w: env var is required", ErrTypeInvalidEnvDefaultTag)
+	}
+	return v.checkTagValue(ErrTypeInvalidEnvDefaultTag, f.Type, d)
+}
+
+// checkTagValue returns an error wrapping errTag if type tp isn't
+// supported by `env` struct tags or s can't be parsed as tp.
+func (v *typeValidator) checkTagValue(errTag error, tp reflect.Type, s string) error {
+	if !v.isEnvSupportedType(tp) {
+		return fmt.Errorf("%w: unsupported type %s", errTag, tp.String())
+	}
+	if err := setFromString(reflect.New(tp).Elem(), s); err != nil {
+		if errors.Is(err, errSyntax) {
+			return fmt.Errorf("%w: expected %s: %q", errTag, tp.String(), s)
+		}
+		return fmt.Errorf("%w: expected %s: %w", errTag, tp.String(), err)
+	}
+	return nil
+}
+
+// validateValidateWhenField checks the `validate_when` struct tag of field f
+// which must be a struct or a pointer to a struct and must reference
+// a sibling bool field of struct type parent.
+func validateValidateWhenField(parent reflect.Type, f reflect.StructField) error {
+	name, ok := f.Tag.Lookup("validate_when")
+	if !ok {
 		return nil
 	}
-	return fmt.Errorf("%w: %s", ErrTypeEnvVarOnUnsupportedType, f.Type.String())
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %s is not a struct",
+			ErrTypeInvalidValidateWhenTag, f.Type.String())
+	}
+	g, ok := parent.FieldByName(name)
+	if !ok || !g.IsExported() || name == f.Name {
+		return fmt.Errorf("%w: field %q not found",
+			ErrTypeInvalidValidateWhenTag, name)
+	}
+	if g.Type.Kind() != reflect.Bool {
+		return fmt.Errorf("%w: field %q is not a bool",
+			ErrTypeInvalidValidateWhenTag, name)
+	}
+	return nil
+}
+
+func validateRedactField(f reflect.StructField) error {
+	switch r, ok := f.Tag.Lookup("redact"); {
+	case !ok, r == "true", r == "false":
+		return nil
+	default:
+		return fmt.Errorf("%w: %q, expected true or false", ErrTypeInvalidRedactTag, r)
+	}
+}
+
+// validateWhenDisabled returns true if field f of struct v has
+// a `validate_when` struct tag referencing a sibling bool field that is false.
+func validateWhenDisabled(v reflect.Value, f reflect.StructField) bool {
+	name, ok := f.Tag.Lookup("validate_when")
+	if !ok {
+		return false
+	}
+	return !v.FieldByName(name).Bool()
+}
+
+// firstEnabledFieldError returns the first error of errs that isn't within
+// a section of v disabled by a `validate_when` struct tag or nil if there's none.
+func firstEnabledFieldError(
+	errs validator.ValidationErrors, v reflect.Value,
+) validator.FieldError {
+	if enabled := enabledFieldErrors(errs, v); len(enabled) > 0 {
+		return enabled[0]
+	}
+	return nil
+}
+
+// enabledFieldErrors returns errs without the errors of fields within
+// sections of v disabled by a `validate_when` struct tag.
+func enabledFieldErrors(
+	errs validator.ValidationErrors, v reflect.Value,
+) []validator.FieldError {
+	var disabled []string
+	collectDisabledSections(&disabled, "", v)
+	var enabled []validator.FieldError
+	for _, err := range errs {
+		ns := err.StructNamespace()
+		if i := strings.IndexByte(ns, '.'); i != -1 {
+			ns = ns[i:] // Remove the type name.
+		}
+		if !slices.ContainsFunc(disabled, func(p string) bool {
+			return ns == p || strings.HasPrefix(ns, p+".")
+		}) {
+			enabled = append(enabled, err)
+		}
+	}
+	return enabled
+}
+
+// collectDisabledSections appends the paths of all fields within v
+// disabled by a `validate_when` struct tag to paths.
+func collectDisabledSections(paths *[]string, path string, v reflect.Value) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		tp := v.Type()
+		for i := range tp.NumField() {
+			f := tp.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			path := path + "." + f.Name
+			if validateWhenDisabled(v, f) {
+				*paths = append(*paths, path)
+				continue
+			}
+			collectDisabledSections(paths, path, v.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			collectDisabledSections(paths, fmt.Sprintf("%s[%d]", path, i), v.Index(i))
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			path := fmt.Sprintf("%s[%v]", path, k.Interface())
+			collectDisabledSections(paths, path, v.MapIndex(k))
+		}
+	}
+}
+
+// nullStyleTilde is the value of the `nullstyle` struct tag that makes
+// a field accept `~` in addition to `null`.
+const nullStyleTilde = "tilde"
+
+func validateNullStyleField(f reflect.StructField) error {
+	n, ok := f.Tag.Lookup("nullstyle")
+	if !ok {
+		return nil
+	}
+	if n != nullStyleTilde {
+		return fmt.Errorf("%w: unknown style %q", ErrTypeInvalidNullStyleTag, n)
+	}
+	switch f.Type.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not nilable",
+		ErrTypeInvalidNullStyleTag, f.Type.String())
 }
 
 const regexEnvVarPOSIXPattern = `^[A-Z_][A-Z0-9_]*$`
 
 var regexEnvVarPOSIX = regexp.MustCompile(regexEnvVarPOSIXPattern)
 
+// kindIsPlatformInt returns true for int and uint,
+// which are only supported with option WithAllowPlatformInts.
+func kindIsPlatformInt(k reflect.Kind) bool {
+	return k == reflect.Int || k == reflect.Uint
+}
+
 func kindIsPrimitive(k reflect.Kind) bool {
 	switch k {
 	case reflect.String,
@@ -1069,8 +3239,37 @@ func kindIsPrimitive(k reflect.Kind) bool {
 
 func mapKeysSorted(m reflect.Value) []reflect.Value {
 	keys := m.MapKeys()
-	sort.Slice(keys, func(i, j int) bool {
-		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
-	})
+	slices.SortFunc(keys, compareMapKeys)
 	return keys
 }
+
+// compareMapKeys compares map keys a and b of the same type by their value
+// for numeric, bool and string kinds and by their formatted value otherwise.
+func compareMapKeys(a, b reflect.Value) int {
+	for a.Kind() == reflect.Pointer || a.Kind() == reflect.Interface {
+		if a.IsNil() || b.IsNil() {
+			return cmp.Compare(boolToInt(!a.IsNil()), boolToInt(!b.IsNil()))
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.Bool:
+		return cmp.Compare(boolToInt(a.Bool()), boolToInt(b.Bool()))
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	}
+	return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
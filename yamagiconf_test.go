@@ -2,12 +2,17 @@ package yamagiconf_test
 
 import (
 	"encoding"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 	"unsafe"
 
@@ -603,6 +608,32 @@ func TestLoadInvalidEnvTag(t *testing.T) {
 			"must match the POSIX env var regexp: ^[A-Z_][A-Z0-9_]*$", err.Error())
 	})
 
+	t.Run("fallback_invalid", func(t *testing.T) {
+		type TestConfig struct {
+			Wrong string `yaml:"wrong" env:"OK,not-ok"`
+		}
+		_, err := LoadSrc[TestConfig]("wrong: ok\n")
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnvTag)
+		require.Equal(t, "at TestConfig.Wrong: invalid env struct tag: "+
+			"must match the POSIX env var regexp: ^[A-Z_][A-Z0-9_]*$", err.Error())
+	})
+
+	t.Run("fallback_empty", func(t *testing.T) {
+		type TestConfig struct {
+			Wrong string `yaml:"wrong" env:"OK,"`
+		}
+		_, err := LoadSrc[TestConfig]("wrong: ok\n")
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnvTag)
+	})
+
+	t.Run("required_only", func(t *testing.T) {
+		type TestConfig struct {
+			Wrong string `yaml:"wrong" env:"required"`
+		}
+		_, err := LoadSrc[TestConfig]("wrong: ok\n")
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnvTag)
+	})
+
 	t.Run("level_1", func(t *testing.T) {
 		type Container struct {
 			Wrong string `yaml:"wrong" env:"NOT-OK"`
@@ -1329,10 +1360,34 @@ func TestAnonymousStructErrorPath(t *testing.T) {
 	require.Equal(t, err, yamagiconf.Validate(c))
 }
 
+func TestLoadMissingOmitEmpty(t *testing.T) {
+	type Container struct {
+		Str string `yaml:"str,omitempty"`
+	}
+	type TestConfig struct {
+		Str       string            `yaml:"str,omitempty"`
+		Ptr       *string           `yaml:"ptr,omitempty"`
+		Slice     []string          `yaml:"slice,omitempty"`
+		Map       map[string]string `yaml:"map,omitempty"`
+		Container Container         `yaml:"container,omitempty"`
+		Required  string            `yaml:"required"`
+	}
+
+	c, err := LoadSrc[TestConfig]("required: x\ncontainer: {}\n")
+	require.NoError(t, err)
+	require.Equal(t, &TestConfig{Required: "x"}, c)
+
+	_, err = LoadSrc[TestConfig]("str: x\n")
+	require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+	require.Equal(t,
+		`at TestConfig.Required (as "required"): missing field in config file`,
+		err.Error())
+}
+
 func TestLoadErrMissingConfig(t *testing.T) {
 	type TestConfig struct {
-		OK      string `yaml:"ok,omitempty"`
-		Missing string `yaml:"missing,omitempty"`
+		OK      string `yaml:"ok"`
+		Missing string `yaml:"missing"`
 	}
 	t.Run("struct", func(t *testing.T) {
 		_, err := LoadSrc[TestConfig]("ok: 'OK'")
@@ -1431,6 +1486,55 @@ func TestLoadErrMissingConfig(t *testing.T) {
 	})
 }
 
+func TestLoadErrMissingConfigSimilarKey(t *testing.T) {
+	type Embedded struct {
+		Retries uint8 `yaml:"retries"`
+	}
+	type Server struct {
+		Embedded `yaml:",inline"`
+		Host     string        `yaml:"host"`
+		Timeout  time.Duration `yaml:"timeout"`
+	}
+	type TestConfig struct {
+		Server Server `yaml:"server"`
+	}
+
+	t.Run("typo", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("server:\n  host: x\n  retries: 1\n" +
+			"  timout: 5s\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at TestConfig.Server.Timeout (as "timeout"): `+
+			`missing field in config file: found similar key "timout" at 4:3`,
+			err.Error())
+	})
+
+	t.Run("typo_inline", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("server:\n  host: x\n  retires: 1\n" +
+			"  timeout: 5s\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at TestConfig.Server.Embedded.Retries (as "retries"): `+
+			`missing field in config file: found similar key "retires" at 3:3`,
+			err.Error())
+	})
+
+	t.Run("not_similar", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("server:\n  host: x\n  retries: 1\n" +
+			"  deadline: 5s\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 4:3: malformed YAML: field "deadline" not found `+
+			`in type yamagiconf_test.Server`, err.Error())
+	})
+
+	t.Run("similar_but_present", func(t *testing.T) {
+		// "hosts" is similar to "host" but "host" isn't missing.
+		_, err := LoadSrc[TestConfig]("server:\n  host: x\n  hosts: y\n" +
+			"  retries: 1\n  timeout: 5s\n")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 3:3: malformed YAML: field "hosts" not found `+
+			`in type yamagiconf_test.Server`, err.Error())
+	})
+}
+
 func TestLoadNullOnNonPointer(t *testing.T) {
 	t.Run("on_string", func(t *testing.T) {
 		type TestConfig struct {
@@ -1456,6 +1560,59 @@ func TestLoadNullOnNonPointer(t *testing.T) {
 	})
 }
 
+func TestLoadDuration(t *testing.T) {
+	type TestConfig struct {
+		Timeout time.Duration   `yaml:"timeout"`
+		Retry   *time.Duration  `yaml:"retry"`
+		Steps   []time.Duration `yaml:"steps"`
+	}
+
+	c, err := LoadSrc[TestConfig]("timeout: 1h30m\nretry: '30s'\nsteps: [1ms, 0s]\n")
+	require.NoError(t, err)
+	require.Equal(t, 90*time.Minute, c.Timeout)
+	require.Equal(t, PtrTo(30*time.Second), c.Retry)
+	require.Equal(t, []time.Duration{time.Millisecond, 0}, c.Steps)
+
+	c, err = LoadSrc[TestConfig]("timeout: 0s\nretry: null\nsteps: []\n")
+	require.NoError(t, err)
+	require.Nil(t, c.Retry)
+
+	for _, td := range []struct {
+		name, src, expect string
+	}{
+		{
+			name: "unknown_unit",
+			src:  "timeout: 10minutes\nretry: null\nsteps: []\n",
+			expect: `at 1:10: "timeout" (TestConfig.Timeout): ` +
+				`must be a duration such as 30s or 1h30m, got "10minutes"`,
+		},
+		{
+			name: "integer",
+			src:  "timeout: 30\nretry: null\nsteps: []\n",
+			expect: `at 1:10: "timeout" (TestConfig.Timeout): ` +
+				`must be a duration such as 30s or 1h30m, got "30"`,
+		},
+		{
+			name: "pointer",
+			src:  "timeout: 1s\nretry: 1.5\nsteps: []\n",
+			expect: `at 2:8: "retry" (TestConfig.Retry): ` +
+				`must be a duration such as 30s or 1h30m, got "1.5"`,
+		},
+		{
+			name: "slice_item",
+			src:  "timeout: 1s\nretry: null\nsteps: [1s, x]\n",
+			expect: `at 3:13: "steps" (TestConfig.Steps[1]): ` +
+				`must be a duration such as 30s or 1h30m, got "x"`,
+		},
+	} {
+		t.Run(td.name, func(t *testing.T) {
+			_, err := LoadSrc[TestConfig](td.src)
+			require.ErrorIs(t, err, yamagiconf.ErrYAMLBadDuration)
+			require.Equal(t, td.expect, err.Error())
+		})
+	}
+}
+
 func TestLoadErrYAMLTagUsed(t *testing.T) {
 	type TestConfig struct {
 		Str string `yaml:"str"`
@@ -1568,6 +1725,50 @@ func TestLoadFileErrNotExist(t *testing.T) {
 	require.Zero(t, c)
 }
 
+func TestLoadFS(t *testing.T) {
+	type TestConfig struct {
+		Host string `yaml:"host" validate:"required"`
+		Port uint16 `yaml:"port"`
+	}
+	fsys := fstest.MapFS{
+		"config/default.yaml": {Data: []byte("host: example.com\nport: 443\n")},
+		"config/invalid.yaml": {Data: []byte("host: ''\nport: 443\n")},
+		"config/empty.yaml":   {Data: []byte{}},
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfig
+		require.NoError(t, yamagiconf.LoadFS(fsys, "config/default.yaml", &c))
+		require.Equal(t, TestConfig{Host: "example.com", Port: 443}, c)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadFS(fsys, "config/invalid.yaml", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:7: "host" violates validation rule: "required"`,
+			err.Error())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadFS(fsys, "config/empty.yaml", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyFile)
+	})
+
+	t.Run("not exist", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadFS(fsys, "config/missing.yaml", &c)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		require.Zero(t, c)
+	})
+
+	t.Run("nil config", func(t *testing.T) {
+		err := yamagiconf.LoadFS[TestConfig](fsys, "config/default.yaml", nil)
+		require.ErrorIs(t, err, yamagiconf.ErrConfigNil)
+	})
+}
+
 func TestLoadErr(t *testing.T) {
 	type TestConfig struct {
 		Foo int8 `yaml:"foo"`
@@ -1715,6 +1916,191 @@ foo: 1
 	})
 }
 
+func TestErrValidatorPanic(t *testing.T) {
+	t.Run("undefined_validation", func(t *testing.T) {
+		type TestConfig struct {
+			Name string `yaml:"name" validate:"nonexistent"`
+		}
+		_, err := LoadSrc[TestConfig]("name: x\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidatorPanic)
+		require.Equal(t, `at TestConfig: validator panicked: Undefined validation function `+
+			`'nonexistent' on field 'Name'`, err.Error())
+
+		err = yamagiconf.Validate(TestConfig{Name: "x"})
+		require.ErrorIs(t, err, yamagiconf.ErrValidatorPanic)
+		require.Equal(t, `at TestConfig: validator panicked: Undefined validation function `+
+			`'nonexistent' on field 'Name'`, err.Error())
+	})
+
+	t.Run("bad_param", func(t *testing.T) {
+		type TestConfig struct {
+			Port uint16 `yaml:"port" validate:"min=abc"`
+		}
+		_, err := LoadSrc[TestConfig]("port: 8080\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidatorPanic)
+		require.True(t, strings.HasPrefix(err.Error(), "at TestConfig: validator panicked: "),
+			err.Error())
+	})
+
+	t.Run("sequence_item", func(t *testing.T) {
+		type Record struct {
+			Name string `yaml:"name" validate:"nonexistent"`
+		}
+		err := yamagiconf.LoadSequence(strings.NewReader("- name: x\n"),
+			func(int, Record) error { return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrValidatorPanic)
+		require.True(t, strings.HasPrefix(err.Error(), "at Record[0]: validator panicked: "),
+			err.Error())
+	})
+}
+
+func TestValidateMatchesLoad(t *testing.T) {
+	type Server struct {
+		Host ValidatedString `yaml:"host"`
+		Port uint16          `yaml:"port" validate:"min=1"`
+	}
+	type TestConfig struct {
+		Name    string            `yaml:"name" validate:"required"`
+		Server  Server            `yaml:"server"`
+		Servers map[string]Server `yaml:"servers" validate:"dive"`
+	}
+
+	t.Run("validator", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`name: x
+server:
+  host: invalid
+  port: 0
+servers: {}
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.Equal(t, `at 3:9: at TestConfig.Server.Host: `+
+			`validation: is not 'valid'`, err.Error())
+
+		errValidate := yamagiconf.Validate(*c)
+		require.ErrorIs(t, errValidate, yamagiconf.ErrValidation)
+		require.Equal(t, `at TestConfig.Server.Host: validation: is not 'valid'`,
+			errValidate.Error())
+		// Load only prefixes the location.
+		require.Equal(t, "at 3:9: "+errValidate.Error(), err.Error())
+
+		var e *yamagiconf.Error
+		require.True(t, errors.As(errValidate, &e))
+		require.Equal(t, "TestConfig.Server.Host", e.GoPath)
+	})
+
+	t.Run("validator_tag", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`name: x
+server:
+  host: valid
+  port: 1
+servers:
+  a:
+    host: valid
+    port: 0
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 8:11: "port" violates validation rule: "min"`,
+			err.Error())
+
+		errValidate := yamagiconf.Validate(*c)
+		require.ErrorIs(t, errValidate, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Servers[a].Port: `+
+			`violates validation rule: "min"`, errValidate.Error())
+
+		var e *yamagiconf.Error
+		require.True(t, errors.As(errValidate, &e))
+		require.Equal(t, "TestConfig.Servers[a].Port", e.GoPath)
+	})
+
+	t.Run("all_errors", func(t *testing.T) {
+		c := TestConfig{
+			Server: Server{Host: "invalid", Port: 0},
+			Servers: map[string]Server{
+				"a": {Host: "invalid", Port: 1},
+				"b": {Host: "valid", Port: 0},
+			},
+		}
+		// Without the option only the first error is returned.
+		err := yamagiconf.Validate(c)
+		require.Equal(t, `at TestConfig.Server.Host: validation: is not 'valid'`,
+			err.Error())
+
+		err = yamagiconf.Validate(c, yamagiconf.WithAllErrors())
+		var multi *yamagiconf.MultiError
+		require.True(t, errors.As(err, &multi))
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Server.Host: validation: is not 'valid'
+at TestConfig.Servers[a].Host: validation: is not 'valid'
+at TestConfig.Name: violates validation rule: "required"
+at TestConfig.Server.Port: violates validation rule: "min"
+at TestConfig.Servers[b].Port: violates validation rule: "min"`, err.Error())
+
+		paths := make([]string, len(multi.Errors))
+		for i, err := range multi.Errors {
+			var e *yamagiconf.Error
+			require.True(t, errors.As(err, &e))
+			paths[i] = e.GoPath
+		}
+		require.Equal(t, []string{
+			"TestConfig.Server.Host",
+			"TestConfig.Servers[a].Host",
+			"TestConfig.Name",
+			"TestConfig.Server.Port",
+			"TestConfig.Servers[b].Port",
+		}, paths)
+
+		require.NoError(t, yamagiconf.Validate(TestConfig{
+			Name:   "ok",
+			Server: Server{Host: "valid", Port: 1},
+		}, yamagiconf.WithAllErrors()))
+	})
+
+	t.Run("load_all_errors", func(t *testing.T) {
+		src := `name: ""
+server:
+  host: invalid
+  port: 0
+servers:
+  a:
+    host: invalid
+    port: 1
+  b:
+    host: valid
+    port: 0
+`
+		// Without the option only the first error is returned.
+		_, err := LoadSrc[TestConfig](src)
+		require.Equal(t, `at 3:9: at TestConfig.Server.Host: `+
+			`validation: is not 'valid'`, err.Error())
+
+		var c TestConfig
+		err = yamagiconf.LoadWithOptions(src, &c, yamagiconf.WithAllErrors())
+		var multi *yamagiconf.MultiError
+		require.True(t, errors.As(err, &multi))
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 3:9: at TestConfig.Server.Host: validation: is not 'valid'
+at 7:11: at TestConfig.Servers[a].Host: validation: is not 'valid'
+at 1:7: "name" violates validation rule: "required"
+at 4:9: "port" violates validation rule: "min"
+at 11:11: "port" violates validation rule: "min"`, err.Error())
+
+		for _, err := range multi.Errors {
+			var e *yamagiconf.Error
+			require.True(t, errors.As(err, &e), err.Error())
+		}
+	})
+
+	t.Run("struct_validation", func(t *testing.T) {
+		c := TestConfigWithRange{Name: "x", Range: TestRange{Min: 2, Max: 1}}
+		require.NoError(t, yamagiconf.Validate(c))
+		err := yamagiconf.Validate(c,
+			yamagiconf.WithStructValidation(validateTestRange, TestRange{}))
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+	})
+}
+
 func TestValidation(t *testing.T) {
 	type MapValVal map[ValidatedString]ValidatedString
 	type Container struct {
@@ -2632,7 +3018,8 @@ container:
       - duration: 12m
 `)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, `at TestConfig.Container.Map[bar][0].Duration: `+
+		require.Equal(t, `at 7:19: "duration" `+
+			`(TestConfig.Container.Map[bar][0].Duration): `+
 			`invalid env var DURATION: expected time.Duration: time: `+
 			`unknown unit "minutes" in duration "10minutes"`, err.Error())
 	})
@@ -2659,12 +3046,324 @@ container:
         duration: 12m
 `)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, `at TestConfig.Container.Map[bar][bazz].Duration: `+
+		require.Equal(t, `at 9:19: "duration" `+
+			`(TestConfig.Container.Map[bar][bazz].Duration): `+
 			`invalid env var DURATION: expected time.Duration: time: `+
 			`unknown unit "minutes" in duration "10minutes"`, err.Error())
 	})
 }
 
+func TestLoadEnvVarFallback(t *testing.T) {
+	type TestConfig struct {
+		Host string  `yaml:"host" env:"PROD_DB_HOST,DB_HOST"`
+		Port *uint16 `yaml:"port" env:"PROD_DB_PORT,DB_PORT,required"`
+	}
+	const src = "host: yaml\nport: null\n"
+
+	t.Run("first", func(t *testing.T) {
+		t.Setenv("PROD_DB_HOST", "prod")
+		t.Setenv("DB_HOST", "fallback")
+		t.Setenv("PROD_DB_PORT", "5432")
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Host: "prod", Port: PtrTo(uint16(5432))}, *c)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		t.Setenv("DB_HOST", "fallback")
+		t.Setenv("DB_PORT", "5433")
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Host: "fallback", Port: PtrTo(uint16(5433))}, *c)
+	})
+
+	t.Run("first_set_but_empty", func(t *testing.T) {
+		t.Setenv("PROD_DB_HOST", "")
+		t.Setenv("DB_HOST", "fallback")
+		t.Setenv("DB_PORT", "5433")
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		require.Equal(t, "", c.Host)
+	})
+
+	t.Run("none", func(t *testing.T) {
+		t.Setenv("DB_PORT", "5433")
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		require.Equal(t, "yaml", c.Host)
+	})
+
+	t.Run("err_required", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](src)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvMissingVar)
+		require.Equal(t, "at TestConfig.Port: missing env var "+
+			"PROD_DB_PORT or DB_PORT", err.Error())
+	})
+
+	t.Run("err_invalid_reports_name", func(t *testing.T) {
+		t.Setenv("DB_PORT", "x")
+		_, err := LoadSrc[TestConfig](src)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, "at 2:7: \"port\" (TestConfig.Port): invalid env var DB_PORT: "+
+			"expected uint16: strconv.ParseUint: parsing \"x\": invalid syntax",
+			err.Error())
+	})
+}
+
+func TestLoadEnvDefault(t *testing.T) {
+	type TestConfig struct {
+		Name    string  `yaml:"name"`
+		Port    uint16  `yaml:"-" env:"PORT" envDefault:"8080"`
+		Limit   *int32  `yaml:"-" env:"LIMIT" envDefault:"10"`
+		Timeout *string `yaml:"-" env:"PROD_TIMEOUT,TIMEOUT" envDefault:"30s"`
+	}
+	const src = "name: x\n"
+
+	t.Run("unset_with_default", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Name:    "x",
+			Port:    8080,
+			Limit:   PtrTo(int32(10)),
+			Timeout: PtrTo("30s"),
+		}, *c)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		t.Setenv("PORT", "9090")
+		t.Setenv("LIMIT", "5")
+		t.Setenv("TIMEOUT", "1m")
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{
+			Name:    "x",
+			Port:    9090,
+			Limit:   PtrTo(int32(5)),
+			Timeout: PtrTo("1m"),
+		}, *c)
+	})
+
+	t.Run("set_empty", func(t *testing.T) {
+		t.Setenv("TIMEOUT", "")
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		require.Equal(t, PtrTo(""), c.Timeout)
+	})
+
+	t.Run("set_to_null", func(t *testing.T) {
+		t.Setenv("LIMIT", "null")
+		t.Setenv("PROD_TIMEOUT", "null")
+		c, err := LoadSrc[TestConfig](src)
+		require.NoError(t, err)
+		require.Nil(t, c.Limit)
+		require.Nil(t, c.Timeout)
+	})
+
+	t.Run("yaml_backed", func(t *testing.T) {
+		type TestConfig struct {
+			Port  uint16 `yaml:"port" env:"PORT" envDefault:"8080"`
+			Limit *int32 `yaml:"limit" env:"LIMIT" envDefault:"10"`
+		}
+
+		t.Run("missing", func(t *testing.T) {
+			c, err := LoadSrc[TestConfig]("port: 80\n")
+			require.NoError(t, err)
+			require.Equal(t, TestConfig{Port: 80, Limit: PtrTo(int32(10))}, *c)
+		})
+
+		t.Run("missing_env_set", func(t *testing.T) {
+			t.Setenv("LIMIT", "5")
+			c, err := LoadSrc[TestConfig]("port: 80\n")
+			require.NoError(t, err)
+			require.Equal(t, TestConfig{Port: 80, Limit: PtrTo(int32(5))}, *c)
+		})
+
+		t.Run("missing_env_null", func(t *testing.T) {
+			t.Setenv("LIMIT", "null")
+			c, err := LoadSrc[TestConfig]("port: 80\n")
+			require.NoError(t, err)
+			require.Equal(t, TestConfig{Port: 80, Limit: nil}, *c)
+		})
+
+		t.Run("yaml_null", func(t *testing.T) {
+			c, err := LoadSrc[TestConfig]("port: 80\nlimit: null\n")
+			require.NoError(t, err)
+			require.Equal(t, TestConfig{Port: 80, Limit: nil}, *c)
+		})
+
+		t.Run("env_over_yaml", func(t *testing.T) {
+			t.Setenv("PORT", "9090")
+			c, err := LoadSrc[TestConfig]("port: 80\nlimit: 1\n")
+			require.NoError(t, err)
+			require.Equal(t, TestConfig{Port: 9090, Limit: PtrTo(int32(1))}, *c)
+		})
+	})
+
+	t.Run("validated", func(t *testing.T) {
+		type TestConfig struct {
+			Name string `yaml:"name"`
+			Port uint16 `yaml:"-" env:"PORT" envDefault:"80" validate:"gt=1024"`
+		}
+		_, err := LoadSrc[TestConfig](src)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Port: violates validation rule: "gt"`,
+			err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidEnvDefaultTag(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		errMsg string
+		check  func() error
+	}{
+		{"missing_env", "missing env struct tag", func() error {
+			return yamagiconf.ValidateType[struct {
+				Port uint16 `yaml:"-" envDefault:"80"`
+			}]()
+		}},
+		{"default", "conflicts with default struct tag", func() error {
+			return yamagiconf.ValidateType[struct {
+				Port uint16 `yaml:"port" env:"PORT" envDefault:"80" default:"80"`
+			}]()
+		}},
+		{"required", "env var is required", func() error {
+			return yamagiconf.ValidateType[struct {
+				Port uint16 `yaml:"-" env:"PORT,required" envDefault:"80"`
+			}]()
+		}},
+		{"syntax", `expected uint16: strconv.ParseUint: ` +
+			`parsing "x": invalid syntax`, func() error {
+			return yamagiconf.ValidateType[struct {
+				Port uint16 `yaml:"-" env:"PORT" envDefault:"x"`
+			}]()
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check()
+			require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidEnvDefaultTag)
+			require.Equal(t, "at struct{...}.Port: invalid envDefault struct tag: "+
+				tt.errMsg, err.Error())
+		})
+	}
+}
+
+func TestLoadErrValidationTagFromEnv(t *testing.T) {
+	type Server struct {
+		Mode string            `yaml:"mode" env:"SERVER_MODE" validate:"oneof=dev prod"`
+		Tier *string           `yaml:"tier" env:"SERVER_TIER" validate:"omitempty,oneof=a b"`
+		Tags map[string]string `yaml:"tags" env:"SERVER_TAGS" validate:"dive,max=3"`
+	}
+	type TestConfig struct {
+		Server Server `yaml:"server"`
+	}
+	const src = "server:\n  mode: dev\n  tier: a\n  tags:\n    x: abc\n"
+
+	t.Run("oneof", func(t *testing.T) {
+		t.Setenv("SERVER_MODE", "staging")
+		_, err := LoadSrc[TestConfig](src)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.Server.Mode: invalid env var SERVER_MODE: `+
+			`violates validation rule: "oneof"`, err.Error())
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		t.Setenv("SERVER_TIER", "c")
+		_, err := LoadSrc[TestConfig](src)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.Server.Tier: invalid env var SERVER_TIER: `+
+			`violates validation rule: "oneof"`, err.Error())
+	})
+
+	t.Run("map_entry", func(t *testing.T) {
+		t.Setenv("SERVER_TAGS_X", "abcd")
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions(src, &c,
+			yamagiconf.WithIndexedEnvOverrides())
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.Server.Tags[x]: invalid env var SERVER_TAGS_X: `+
+			`violates validation rule: "max"`, err.Error())
+	})
+
+	t.Run("yaml_value", func(t *testing.T) {
+		// Values not from env are still reported at their YAML location.
+		t.Setenv("SERVER_TIER", "b")
+		_, err := LoadSrc[TestConfig]("server:\n  mode: test\n  tier: a\n  tags: {}\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.NotErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at 2:9: "mode" violates validation rule: "oneof"`,
+			err.Error())
+	})
+}
+
+func TestLoadEnvVarRequired(t *testing.T) {
+	type TestConfig struct {
+		Secret string `yaml:"secret" env:"SECRET,required"`
+	}
+	_, err := LoadSrc[TestConfig]("secret: ''\n")
+	require.ErrorIs(t, err, yamagiconf.ErrEnvMissingVar)
+	require.Equal(t, "at TestConfig.Secret: missing env var SECRET", err.Error())
+
+	t.Setenv("SECRET", "s3cr3t")
+	c, err := LoadSrc[TestConfig]("secret: ''\n")
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", c.Secret)
+}
+
+func TestLoadEnvVarExclusive(t *testing.T) {
+	type Database struct {
+		Password string  `yaml:"password" env:"DB_PASSWORD,exclusive"`
+		Token    *string `yaml:"token" env:"DB_TOKEN,exclusive"`
+	}
+	type TestConfig struct {
+		Database Database `yaml:"database"`
+	}
+
+	t.Run("env_only", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "s3cr3t")
+		t.Setenv("DB_TOKEN", "t0k3n")
+		c, err := LoadSrc[TestConfig]("database:\n  password: ''\n  token: null\n")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Database: Database{
+			Password: "s3cr3t", Token: PtrTo("t0k3n"),
+		}}, *c)
+	})
+
+	t.Run("yaml_only", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("database:\n  password: s3cr3t\n  token: t0k3n\n")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Database: Database{
+			Password: "s3cr3t", Token: PtrTo("t0k3n"),
+		}}, *c)
+	})
+
+	t.Run("neither", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("database:\n  password: ''\n  token: null\n")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{}, *c)
+	})
+
+	t.Run("both", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "s3cr3t")
+		_, err := LoadSrc[TestConfig]("database:\n  password: other\n  token: null\n")
+		require.ErrorIs(t, err, yamagiconf.ErrEnvConflict)
+		require.Equal(t, `at 2:13: "password" (TestConfig.Database.Password): `+
+			`conflicting env var DB_PASSWORD: value is also set in the config file`,
+			err.Error())
+	})
+
+	t.Run("both_pointer", func(t *testing.T) {
+		t.Setenv("DB_TOKEN", "t0k3n")
+		_, err := LoadSrc[TestConfig]("database:\n  password: ''\n  token: ''\n")
+		require.ErrorIs(t, err, yamagiconf.ErrEnvConflict)
+		require.Equal(t, `at 3:10: "token" (TestConfig.Database.Token): `+
+			`conflicting env var DB_TOKEN: value is also set in the config file`,
+			err.Error())
+	})
+}
+
 func TestLoadErrInvalidEnvVar(t *testing.T) {
 	t.Run("bool", func(t *testing.T) {
 		type TestConfig struct {
@@ -2674,7 +3373,7 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		_, err := LoadSrc[TestConfig](`bool: false`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
 		require.Equal(t,
-			"at TestConfig.Bool: invalid env var BOOL: expected bool",
+			"at 1:7: \"bool\" (TestConfig.Bool): invalid env var BOOL: expected bool",
 			err.Error())
 	})
 
@@ -2685,7 +3384,8 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("FLOAT_32", "not_a_float32")
 		_, err := LoadSrc[TestConfig](`float32: 3.14`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Float32: invalid env var FLOAT_32: "+
+		require.Equal(t, `at 1:10: "float32" (TestConfig.Float32): `+
+			"invalid env var FLOAT_32: "+
 			"expected float32: "+
 			"strconv.ParseFloat: parsing \"not_a_float32\": invalid syntax", err.Error())
 	})
@@ -2697,7 +3397,8 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("FLOAT_64", "not_a_float64")
 		_, err := LoadSrc[TestConfig](`float64: 3.14`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Float64: invalid env var FLOAT_64: "+
+		require.Equal(t, `at 1:10: "float64" (TestConfig.Float64): `+
+			"invalid env var FLOAT_64: "+
 			"expected float64: "+
 			"strconv.ParseFloat: parsing \"not_a_float64\": invalid syntax", err.Error())
 	})
@@ -2709,7 +3410,7 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("INT_8", "257")
 		_, err := LoadSrc[TestConfig](`int8: 0`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Int8: invalid env var INT_8: "+
+		require.Equal(t, "at 1:7: \"int8\" (TestConfig.Int8): invalid env var INT_8: "+
 			"expected int8: "+
 			"strconv.ParseInt: parsing \"257\": value out of range", err.Error())
 	})
@@ -2721,7 +3422,7 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("UINT_8", "-1")
 		_, err := LoadSrc[TestConfig](`uint8: 0`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Uint8: invalid env var UINT_8: "+
+		require.Equal(t, "at 1:8: \"uint8\" (TestConfig.Uint8): invalid env var UINT_8: "+
 			"expected uint8: "+
 			"strconv.ParseUint: parsing \"-1\": invalid syntax", err.Error())
 	})
@@ -2733,7 +3434,7 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("INT_16", "65536")
 		_, err := LoadSrc[TestConfig](`int16: 0`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Int16: invalid env var INT_16: "+
+		require.Equal(t, "at 1:8: \"int16\" (TestConfig.Int16): invalid env var INT_16: "+
 			"expected int16: "+
 			"strconv.ParseInt: parsing \"65536\": value out of range", err.Error())
 	})
@@ -2745,7 +3446,8 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("UINT_16", "-1")
 		_, err := LoadSrc[TestConfig](`uint16: 0`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Uint16: invalid env var UINT_16: "+
+		require.Equal(t, `at 1:9: "uint16" (TestConfig.Uint16): `+
+			"invalid env var UINT_16: "+
 			"expected uint16: "+
 			"strconv.ParseUint: parsing \"-1\": invalid syntax", err.Error())
 	})
@@ -2757,7 +3459,7 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("INT_32", "4294967296")
 		_, err := LoadSrc[TestConfig](`int32: 0`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Int32: invalid env var INT_32: "+
+		require.Equal(t, "at 1:8: \"int32\" (TestConfig.Int32): invalid env var INT_32: "+
 			"expected int32: "+
 			"strconv.ParseInt: parsing \"4294967296\": value out of range", err.Error())
 	})
@@ -2769,7 +3471,8 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("UINT_32", "-1")
 		_, err := LoadSrc[TestConfig](`uint32: 0`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Uint32: invalid env var UINT_32: "+
+		require.Equal(t, `at 1:9: "uint32" (TestConfig.Uint32): `+
+			"invalid env var UINT_32: "+
 			"expected uint32: "+
 			"strconv.ParseUint: parsing \"-1\": invalid syntax", err.Error())
 	})
@@ -2782,7 +3485,7 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("INT_64", "9223372036854775808")
 		_, err := LoadSrc[TestConfig](`int64: 0`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Int64: invalid env var INT_64: "+
+		require.Equal(t, "at 1:8: \"int64\" (TestConfig.Int64): invalid env var INT_64: "+
 			"expected int64: "+
 			"strconv.ParseInt: parsing \"9223372036854775808\": value out of range",
 			err.Error())
@@ -2795,7 +3498,8 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("UINT_64", "-1")
 		_, err := LoadSrc[TestConfig](`uint64: 0`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.Uint64: invalid env var UINT_64: "+
+		require.Equal(t, `at 1:9: "uint64" (TestConfig.Uint64): `+
+			"invalid env var UINT_64: "+
 			"expected uint64: "+
 			"strconv.ParseUint: parsing \"-1\": invalid syntax", err.Error())
 	})
@@ -2807,7 +3511,8 @@ func TestLoadErrInvalidEnvVar(t *testing.T) {
 		t.Setenv("PTR_UINT_64", "-1")
 		_, err := LoadSrc[TestConfig](`uint64: 0`)
 		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
-		require.Equal(t, "at TestConfig.PtrUint64: invalid env var PTR_UINT_64: "+
+		require.Equal(t, `at 1:9: "uint64" (TestConfig.PtrUint64): `+
+			"invalid env var PTR_UINT_64: "+
 			"expected uint64: "+
 			"strconv.ParseUint: parsing \"-1\": invalid syntax", err.Error())
 	})
@@ -3173,3 +3878,1369 @@ container:
 
 	require.Zero(t, c.Container)
 }
+
+func TestLoadSequence(t *testing.T) {
+	type Record struct {
+		ID   string          `yaml:"id" validate:"required"`
+		Name string          `yaml:"name"`
+		Tags []string        `yaml:"tags"`
+		Val  ValidatedString `yaml:"val"`
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var ids, names []string
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- id: first
+  name: &name shared
+  tags: [a, b]
+  val: valid
+- id: second
+  name: *name
+  tags: []
+  val: valid
+`), func(index int, r Record) error {
+			require.Equal(t, len(ids), index)
+			ids, names = append(ids, r.ID), append(names, r.Name)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"first", "second"}, ids)
+		require.Equal(t, []string{"shared", "shared"}, names)
+	})
+
+	t.Run("alias_to_previous_item", func(t *testing.T) {
+		var items []Record
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- id: &id first
+  name: a
+  tags: &tags [a, b]
+  val: valid
+- id: second
+  name: *id
+  tags: *tags
+  val: valid
+`), func(index int, r Record) error {
+			items = append(items, r)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []Record{
+			{ID: "first", Name: "a", Tags: []string{"a", "b"}, Val: "valid"},
+			{ID: "second", Name: "first", Tags: []string{"a", "b"}, Val: "valid"},
+		}, items)
+	})
+
+	t.Run("early_termination", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- {id: first, name: a, tags: [], val: valid}
+- {id: second, name: b, tags: [], val: valid}
+- {id: third, name: c, tags: [], val: valid}
+`), func(index int, r Record) error {
+			calls++
+			if index == 1 {
+				return errStop
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, errStop)
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("item_validation_error", func(t *testing.T) {
+		var ids []string
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- {id: first, name: a, tags: [], val: valid}
+- {id: second, name: b, tags: [], val: invalid}
+`), func(index int, r Record) error {
+			ids = append(ids, r.ID)
+			return nil
+		})
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.Equal(t, "at 3:40: at Record[1].Val: validation: is not 'valid'",
+			err.Error())
+		require.Equal(t, []string{"first"}, ids)
+	})
+
+	t.Run("item_validation_tag_error", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- {id: first, name: a, tags: [], val: valid}
+- {id: '', name: b, tags: [], val: valid}
+`), func(index int, r Record) error { return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 3:8: "id" violates validation rule: "required"`,
+			err.Error())
+	})
+
+	t.Run("item_missing_field", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- {id: first, name: a, tags: [], val: valid}
+- {id: second, tags: [], val: valid}
+`), func(index int, r Record) error { return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at Record[1].Name (as "name"): missing field in config file`,
+			err.Error())
+	})
+
+	t.Run("item_unknown_field", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- {id: first, name: a, tags: [], val: valid, unknown: x}
+`), func(index int, r Record) error { return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 2:46: malformed YAML: field "unknown" not found `+
+			`in type yamagiconf_test.Record`, err.Error())
+	})
+
+	t.Run("empty_item", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- {id: first, name: a, tags: [], val: valid}
+-
+`), func(index int, r Record) error { return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyArrayItem)
+	})
+
+	t.Run("anchor_unused", func(t *testing.T) {
+		calls := 0
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- {id: first, name: &unused a, tags: [], val: valid}
+`), func(index int, r Record) error { calls++; return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLAnchorUnused)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("root_not_sequence", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader(`id: first`),
+			func(index int, r Record) error { return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLRootNotSequence)
+		require.Equal(t, "at 1:1: root must be a sequence", err.Error())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader(""),
+			func(index int, r Record) error { return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyFile)
+	})
+
+	t.Run("multidocument", func(t *testing.T) {
+		calls := 0
+		err := yamagiconf.LoadSequence(strings.NewReader(
+			"- {id: first, name: a, tags: [], val: valid}\n---\n- {}\n"),
+			func(index int, r Record) error { calls++; return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMultidoc)
+		require.Equal(t, "at 2:1: multi-document YAML files are not supported",
+			err.Error())
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("multidocument_after_end", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader(
+			"- {id: first, name: a, tags: [], val: valid}\n...\n# end\n- {}\n"),
+			func(index int, r Record) error { return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMultidoc)
+		require.Equal(t, "at 4:1: multi-document YAML files are not supported",
+			err.Error())
+	})
+
+	t.Run("flow_sequence", func(t *testing.T) {
+		var ids []string
+		err := yamagiconf.LoadSequence(strings.NewReader(`[
+  {id: first, name: a, tags: [], val: valid},
+  {id: second, name: b, tags: [], val: invalid},
+]`), func(index int, r Record) error {
+			ids = append(ids, r.ID)
+			return nil
+		})
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.Equal(t, "at 3:40: at Record[1].Val: validation: is not 'valid'",
+			err.Error())
+		require.Equal(t, []string{"first"}, ids)
+	})
+
+	t.Run("malformed_item", func(t *testing.T) {
+		calls := 0
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- {id: first, name: a, tags: [], val: valid}
+- {id: second, name: b, tags: [, val: valid}
+`), func(index int, r Record) error { calls++; return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("reads_incrementally", func(t *testing.T) {
+		errStop := errors.New("stop")
+		r := &sequenceGenerator{max: 100_000}
+		err := yamagiconf.LoadSequence(r, func(index int, r Record) error {
+			if index == 1 {
+				return errStop
+			}
+			return nil
+		})
+		require.ErrorIs(t, err, errStop)
+		// Reading is buffered, only the first few items may have been read.
+		require.Less(t, r.generated, 1000)
+	})
+
+	t.Run("phase_decode_excluded", func(t *testing.T) {
+		calls := 0
+		err := yamagiconf.LoadSequence(strings.NewReader(`
+- {id: first, name: a, tags: [], val: invalid}
+`), func(index int, r Record) error { calls++; return nil },
+			yamagiconf.WithPhases(yamagiconf.PhaseTypeCheck|yamagiconf.PhaseValueRules))
+		require.NoError(t, err)
+		require.Zero(t, calls)
+	})
+
+	t.Run("illegal_type", func(t *testing.T) {
+		err := yamagiconf.LoadSequence(strings.NewReader("- 1"),
+			func(index int, r int) error { return nil })
+		require.ErrorIs(t, err, yamagiconf.ErrTypeIllegalRoot)
+	})
+}
+
+// sequenceGenerator is an io.Reader producing a sequence of up to max
+// Record items of TestLoadSequence on demand.
+type sequenceGenerator struct {
+	max, generated int
+	buf            []byte
+}
+
+func (g *sequenceGenerator) Read(p []byte) (int, error) {
+	if len(g.buf) == 0 {
+		if g.generated == g.max {
+			return 0, io.EOF
+		}
+		g.buf = fmt.Appendf(nil,
+			"- {id: item%d, name: a, tags: [], val: valid}\n", g.generated)
+		g.generated++
+	}
+	n := copy(p, g.buf)
+	g.buf = g.buf[n:]
+	return n, nil
+}
+
+func TestLoadErrUnknownField(t *testing.T) {
+	type Embedded struct {
+		Foo string `yaml:"foo"`
+	}
+	type Container struct {
+		Bar string `yaml:"bar"`
+	}
+	type TestConfig struct {
+		Embedded  `yaml:",inline"`
+		Container Container `yaml:"container"`
+	}
+
+	t.Run("root", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("foo: ok\ncontainer:\n  bar: ok\nbazz: x")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 4:1: malformed YAML: field "bazz" not found `+
+			`in type yamagiconf_test.TestConfig`, err.Error())
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("foo: ok\ncontainer:\n  bar: ok\n  foo: x")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 4:3: malformed YAML: field "foo" not found `+
+			`in type yamagiconf_test.Container`, err.Error())
+	})
+
+	t.Run("kind_mismatch", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("foo: ok\ncontainer: x")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, "malformed YAML: yaml: unmarshal errors:\n"+
+			"  line 2: cannot unmarshal !!str `x` into yamagiconf_test.Container",
+			err.Error())
+	})
+}
+
+func TestNullStyleTilde(t *testing.T) {
+	type TestConfig struct {
+		Tilde      *string           `yaml:"tilde" nullstyle:"tilde"`
+		TildeSlice []string          `yaml:"tilde-slice" nullstyle:"tilde"`
+		TildeMap   map[string]string `yaml:"tilde-map" nullstyle:"tilde"`
+		Strict     *string           `yaml:"strict"`
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](
+			"tilde: ~\ntilde-slice: ~\ntilde-map: ~\nstrict: null")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{}, *c)
+	})
+
+	t.Run("null_still_accepted", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](
+			"tilde: null\ntilde-slice: null\ntilde-map: null\nstrict: null")
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{}, *c)
+	})
+
+	t.Run("err_untagged_field", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](
+			"tilde: ~\ntilde-slice: ~\ntilde-map: ~\nstrict: ~")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadNullLiteral)
+		require.Equal(t, `at 4:9: "strict" (TestConfig.Strict): `+
+			`must be null, any other variants of null are not supported`, err.Error())
+	})
+
+	t.Run("err_other_variants", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](
+			"tilde: Null\ntilde-slice: ~\ntilde-map: ~\nstrict: null")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadNullLiteral)
+	})
+
+	t.Run("err_nested_in_container", func(t *testing.T) {
+		type TestConfig struct {
+			Slice []*string `yaml:"slice" nullstyle:"tilde"`
+		}
+		_, err := LoadSrc[TestConfig]("slice:\n  - ~")
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadNullLiteral)
+	})
+}
+
+func TestValidateTypeErrInvalidNullStyleTag(t *testing.T) {
+	t.Run("unknown_style", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			Ptr *string `yaml:"ptr" nullstyle:"none"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidNullStyleTag)
+		require.Equal(t, `at struct{...}.Ptr: invalid nullstyle struct tag: `+
+			`unknown style "none"`, err.Error())
+	})
+
+	t.Run("non_nilable", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			Str string `yaml:"str" nullstyle:"tilde"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidNullStyleTag)
+		require.Equal(t, `at struct{...}.Str: invalid nullstyle struct tag: `+
+			`string is not nilable`, err.Error())
+	})
+}
+
+func TestLoadErrDuplicateMapKey(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		type TestConfig struct {
+			Map map[string]string `yaml:"map"`
+		}
+		_, err := LoadSrc[TestConfig](`
+map:
+  true: a
+  "true": b
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateMapKey)
+		require.Equal(t, `at 4:3: "map" (TestConfig.Map["true"]): `+
+			`duplicate map key: "true" resolves to the same key as "true" at 3:3`,
+			err.Error())
+	})
+
+	t.Run("int", func(t *testing.T) {
+		type TestConfig struct {
+			Map map[int32]string `yaml:"map"`
+		}
+		_, err := LoadSrc[TestConfig](`
+map:
+  1: a
+  2: b
+  0x1: c
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateMapKey)
+		require.Equal(t, `at 5:3: "map" (TestConfig.Map["0x1"]): `+
+			`duplicate map key: "0x1" resolves to the same key as "1" at 3:3`,
+			err.Error())
+	})
+
+	t.Run("float", func(t *testing.T) {
+		type TestConfig struct {
+			Map map[float64]string `yaml:"map"`
+		}
+		_, err := LoadSrc[TestConfig](`
+map:
+  1.0: a
+  1: b
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateMapKey)
+		require.Equal(t, `at 4:3: "map" (TestConfig.Map["1"]): `+
+			`duplicate map key: "1" resolves to the same key as "1.0" at 3:3`,
+			err.Error())
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		type TestConfig struct {
+			Map map[time.Duration]string `yaml:"map"`
+		}
+		_, err := LoadSrc[TestConfig](`
+map:
+  1m: a
+  60s: b
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateMapKey)
+		require.Equal(t, `at 4:3: "map" (TestConfig.Map["60s"]): `+
+			`duplicate map key: "60s" resolves to the same key as "1m" at 3:3`,
+			err.Error())
+	})
+
+	t.Run("text_unmarshaler", func(t *testing.T) {
+		type TestConfig struct {
+			Map map[TextUnmarshaler]string `yaml:"map"`
+		}
+		_, err := LoadSrc[TestConfig](`
+map:
+  foo: a
+  bar: b
+`)
+		require.NoError(t, err)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		type TestConfig struct {
+			Slice []map[uint8]string `yaml:"slice"`
+		}
+		_, err := LoadSrc[TestConfig](`
+slice:
+  - 0o10: a
+    8: b
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateMapKey)
+		require.Equal(t, `at 4:5: "slice" (TestConfig.Slice[0]["8"]): `+
+			`duplicate map key: "8" resolves to the same key as "0o10" at 3:5`,
+			err.Error())
+	})
+
+	t.Run("distinct", func(t *testing.T) {
+		type TestConfig struct {
+			Map map[string]int32 `yaml:"map"`
+		}
+		c, err := LoadSrc[TestConfig](`
+map:
+  1: 1
+  "01": 2
+  0x1: 3
+`)
+		require.NoError(t, err)
+		require.Equal(t, map[string]int32{"1": 1, "01": 2, "0x1": 3}, c.Map)
+	})
+}
+
+func TestLoadErrDuplicateKey(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host"`
+	}
+	type TestConfig struct {
+		Name    string            `yaml:"name"`
+		Server  Server            `yaml:"server"`
+		Labels  map[string]string `yaml:"labels"`
+		Servers []Server          `yaml:"servers"`
+	}
+
+	t.Run("struct", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`name: a
+server: {host: x}
+labels: {}
+servers: []
+name: b
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateKey)
+		require.Equal(t, `at 5:1: duplicate key "name"`, err.Error())
+	})
+
+	t.Run("nested_struct", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`name: a
+server:
+  host: x
+  host: y
+labels: {}
+servers: []
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateKey)
+		require.Equal(t, `at 4:3: duplicate key "host"`, err.Error())
+	})
+
+	t.Run("map", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`name: a
+server: {host: x}
+labels:
+  env: prod
+  "env": dev
+servers: []
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateKey)
+		require.Equal(t, `at 5:3: duplicate key "env"`, err.Error())
+	})
+
+	t.Run("alias_key", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`name: &n name
+server: {host: x}
+labels:
+  *n : a
+  name: b
+servers: []
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLDuplicateKey)
+		require.Equal(t, `at 5:3: duplicate key "name"`, err.Error())
+	})
+
+	t.Run("aliases", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`name: &n n
+server: {host: *n}
+labels:
+  *n : *n
+  m: *n
+servers:
+  - host: *n
+  - host: *n
+`)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"n": "n", "m": "n"}, c.Labels)
+		require.Equal(t, []Server{{Host: "n"}, {Host: "n"}}, c.Servers)
+	})
+}
+
+func TestLoadErrValidationTagInPointerStruct(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host" validate:"required"`
+	}
+	type TestConfig struct {
+		Server *Server `yaml:"server"`
+	}
+	_, err := LoadSrc[TestConfig]("server:\n  host: ''\n")
+	require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+	require.Equal(t, `at 2:9: "host" violates validation rule: "required"`,
+		err.Error())
+}
+
+func TestLoadErrValidationTagUnique(t *testing.T) {
+	type Item struct {
+		ID   string `yaml:"id"`
+		Name string `yaml:"name"`
+	}
+	type TestConfig struct {
+		IDs   []string `yaml:"ids" validate:"unique"`
+		Items []Item   `yaml:"items" validate:"unique=ID"`
+	}
+
+	t.Run("strings", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`ids:
+  - a
+  - b
+  - a
+items: []
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 4:5: "ids" violates validation rule: "unique": `+
+			`index 2 duplicates index 0`, err.Error())
+	})
+
+	t.Run("flow_strings", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("ids: [a, b, c, b]\nitems: []\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:16: "ids" violates validation rule: "unique": `+
+			`index 3 duplicates index 1`, err.Error())
+	})
+
+	t.Run("structs_by_field", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`ids: []
+items:
+  - id: x
+    name: first
+  - id: y
+    name: second
+  - id: x
+    name: third
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 7:5: "items" violates validation rule: "unique": `+
+			`index 2 duplicates index 0`, err.Error())
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`ids: [a, b]
+items:
+  - id: x
+    name: same
+  - id: y
+    name: same
+`)
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, c.IDs)
+	})
+
+	t.Run("pointer_field", func(t *testing.T) {
+		type Backend struct {
+			Port *uint16 `yaml:"port"`
+		}
+		type TestConfig struct {
+			Backends []Backend `yaml:"backends" validate:"unique=Port"`
+		}
+		_, err := LoadSrc[TestConfig](`backends:
+  - port: 80
+  - port: 443
+  - port: 80
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 4:5: "backends" violates validation rule: "unique": `+
+			`index 2 duplicates index 0`, err.Error())
+	})
+}
+
+func TestLoadErrValidationTagMapEntries(t *testing.T) {
+	type Backend struct {
+		URL string `yaml:"url"`
+	}
+	type TestConfig struct {
+		Backends map[string]Backend `yaml:"backends" validate:"min=1,max=3"`
+		Labels   map[string]string  `yaml:"labels" validate:"max=1,dive,keys,min=1,endkeys"`
+		Pinned   map[string]string  `yaml:"pinned" validate:"len=2"`
+	}
+	const tail = "labels: {}\npinned: {a: x, b: y}\n"
+
+	t.Run("under", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("backends: {}\n" + tail)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:11: "backends" violates validation rule: "min": `+
+			`map must have between 1 and 3 entries, got 0`, err.Error())
+	})
+
+	t.Run("over", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`backends:
+  a: {url: a}
+  b: {url: b}
+  c: {url: c}
+  d: {url: d}
+` + tail)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 2:3: "backends" violates validation rule: "max": `+
+			`map must have between 1 and 3 entries, got 4`, err.Error())
+	})
+
+	t.Run("at_most", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("backends: {a: {url: a}}\n" +
+			"labels: {x: x, y: y}\npinned: {a: x, b: y}\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 2:9: "labels" violates validation rule: "max": `+
+			`map must have at most 1 entry, got 2`, err.Error())
+	})
+
+	t.Run("exactly", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("backends: {a: {url: a}}\n" +
+			"labels: {}\npinned: {a: x}\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 3:9: "pinned" violates validation rule: "len": `+
+			`map must have exactly 2 entries, got 1`, err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{
+			Backends: map[string]Backend{}, Pinned: map[string]string{"a": "", "b": ""},
+		})
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Backends: violates validation rule: "min": `+
+			`map must have between 1 and 3 entries, got 0`, err.Error())
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("backends: {a: {url: a}}\n" + tail)
+		require.NoError(t, err)
+		require.Equal(t, map[string]Backend{"a": {URL: "a"}}, c.Backends)
+	})
+}
+
+func TestLoadErrValidationTagDuration(t *testing.T) {
+	type TestConfig struct {
+		Timeout  time.Duration  `yaml:"timeout" validate:"gt=0"`
+		Interval *time.Duration `yaml:"interval" validate:"omitempty,gte=1s,lte=1h"`
+		Retries  int8           `yaml:"retries" validate:"gt=0"`
+	}
+
+	t.Run("zero", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("timeout: 0s\ninterval: null\nretries: 1\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:10: "timeout" violates validation rule: "gt": `+
+			`must be greater than 0s`, err.Error())
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("timeout: -5s\ninterval: null\nretries: 1\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:10: "timeout" violates validation rule: "gt": `+
+			`must be greater than 0s`, err.Error())
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("timeout: 1s\ninterval: 2h\nretries: 1\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 2:11: "interval" violates validation rule: "lte": `+
+			`must be less than or equal to 1h0m0s`, err.Error())
+	})
+
+	t.Run("not_duration", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("timeout: 1s\ninterval: null\nretries: 0\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 3:10: "retries" violates validation rule: "gt"`,
+			err.Error())
+	})
+
+	t.Run("env", func(t *testing.T) {
+		type TestConfig struct {
+			Timeout time.Duration `yaml:"timeout" env:"TIMEOUT" validate:"gt=0"`
+		}
+		t.Setenv("TIMEOUT", "0s")
+		_, err := LoadSrc[TestConfig]("timeout: 1s\n")
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `at TestConfig.Timeout: invalid env var TIMEOUT: `+
+			`violates validation rule: "gt": must be greater than 0s`, err.Error())
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		err := yamagiconf.Validate(TestConfig{Timeout: -time.Second, Retries: 1})
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Timeout: violates validation rule: "gt": `+
+			`must be greater than 0s`, err.Error())
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("timeout: 1s\ninterval: 1m\nretries: 1\n")
+		require.NoError(t, err)
+		require.Equal(t, time.Second, c.Timeout)
+	})
+}
+
+func TestLoadErrValidationRequiredNull(t *testing.T) {
+	type Server struct {
+		Host *string `yaml:"host" validate:"required" nullstyle:"tilde"`
+	}
+	type TestConfig struct {
+		Server Server `yaml:"server"`
+	}
+
+	t.Run("null", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("server:\n  host: null\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationRequiredNull)
+		require.Equal(t, `at 2:9: "host": required field must not be null`,
+			err.Error())
+	})
+
+	t.Run("tilde", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("server:\n  host: ~\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationRequiredNull)
+		require.Equal(t, `at 2:9: "host": required field must not be null`,
+			err.Error())
+	})
+
+	t.Run("implicit_null", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig]("server:\n  host:\n")
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.NotErrorIs(t, err, yamagiconf.ErrValidationRequiredNull)
+	})
+
+	t.Run("absent", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadWithOptions("server: {}\n", &c,
+			yamagiconf.WithDefaultProvider(
+				func(goPath string, fieldType reflect.Type) (any, bool) {
+					return nil, true
+				}))
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.NotErrorIs(t, err, yamagiconf.ErrValidationRequiredNull)
+		require.Equal(t, `at 1:9: "host" violates validation rule: "required"`,
+			err.Error())
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig]("server:\n  host: example.com\n")
+		require.NoError(t, err)
+		require.Equal(t, PtrTo("example.com"), c.Server.Host)
+	})
+}
+
+func TestLoadErrValidationTagDive(t *testing.T) {
+	type Item struct {
+		ID string `yaml:"id" validate:"required"`
+	}
+	type TestConfig struct {
+		Items []Item          `yaml:"items" validate:"dive"`
+		Map   map[string]Item `yaml:"map" validate:"dive"`
+	}
+
+	_, err := LoadSrc[TestConfig](`items:
+  - id: a
+  - id: ''
+map: {}
+`)
+	require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+	require.Equal(t, `at 3:9: "id" violates validation rule: "required"`,
+		err.Error())
+
+	_, err = LoadSrc[TestConfig](`items: []
+map:
+  a:
+    id: a
+  b:
+    id: ''
+`)
+	require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+	require.Equal(t, `at 6:9: "id" violates validation rule: "required"`,
+		err.Error())
+}
+
+func TestLoadWithNode(t *testing.T) {
+	type TestConfig struct {
+		Name  string `yaml:"name" validate:"required"`
+		Color Color  `yaml:"color"`
+	}
+	opt := yamagiconf.WithEnumMapping(colorNames)
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfig
+		root, err := yamagiconf.LoadWithNode([]byte("# Service name.\n"+
+			"name: 'service'\ncolor: blue # Accent.\n"), &c, opt)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Name: "service", Color: ColorBlue}, c)
+
+		require.Equal(t, yaml.MappingNode, root.Kind)
+		require.Len(t, root.Content, 4)
+		require.Equal(t, "# Service name.", root.Content[0].HeadComment)
+		require.Equal(t, yaml.SingleQuotedStyle, root.Content[1].Style)
+		// The enum name isn't replaced by its value.
+		require.Equal(t, "blue", root.Content[3].Value)
+		require.Equal(t, "# Accent.", root.Content[3].LineComment)
+		require.Equal(t, 3, root.Content[3].Line)
+	})
+
+	t.Run("validation_error", func(t *testing.T) {
+		var c TestConfig
+		root, err := yamagiconf.LoadWithNode([]byte("name: ''\ncolor: red\n"), &c, opt)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:7: "name" violates validation rule: "required"`,
+			err.Error())
+		require.NotNil(t, root)
+		require.Equal(t, "name", root.Content[0].Value)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		var c TestConfig
+		root, err := yamagiconf.LoadWithNode([]byte("name: [\n"), &c, opt)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Nil(t, root)
+	})
+}
+
+func TestLoadProjection(t *testing.T) {
+	type Database struct {
+		Host string `yaml:"host" validate:"required"`
+	}
+	type Projection struct {
+		Name     string   `yaml:"name"`
+		Debug    bool     `yaml:"debug"`
+		Database Database `yaml:"database"`
+	}
+	const src = `name: service
+version: 3
+region: eu-west-1
+debug: false
+replicas: 2
+timeout: 5s
+retries: 3
+log_level: info
+log_format: json
+metrics: true
+tracing: false
+owners: [a, b]
+labels:
+  team: core
+shared: &host db.example.com
+cache:
+  host: cache.example.com
+  size: 128
+database:
+  host: *host
+  port: 5432
+  pool: 10
+queue:
+  url: amqp://example.com
+features:
+  - name: x
+    enabled: true
+limits:
+  cpu: 2
+  memory: 1Gi
+tls:
+  cert: /etc/cert.pem
+`
+
+	t.Run("ok", func(t *testing.T) {
+		var c Projection
+		err := yamagiconf.LoadProjection(src, &c)
+		require.NoError(t, err)
+		require.Equal(t, Projection{
+			Name: "service", Database: Database{Host: "db.example.com"},
+		}, c)
+
+		// The full document is rejected by Load.
+		err = yamagiconf.Load(src, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+	})
+
+	t.Run("err_missing", func(t *testing.T) {
+		var c Projection
+		err := yamagiconf.LoadProjection(
+			"name: service\nversion: 3\ndatabase:\n  host: x\n", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at Projection.Debug (as "debug"): `+
+			`missing field in config file`, err.Error())
+	})
+
+	t.Run("err_value", func(t *testing.T) {
+		var c Projection
+		err := yamagiconf.LoadProjection(
+			"name: service\nversion: 3\ndebug: yes\ndatabase:\n  host: x\n", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLBadBoolLiteral)
+		require.Equal(t, `at 3:8: "debug" (Projection.Debug): `+
+			`must be either false or true, other variants of `+
+			`boolean literals of YAML are not supported`, err.Error())
+	})
+
+	t.Run("err_validation", func(t *testing.T) {
+		var c Projection
+		err := yamagiconf.LoadProjection(
+			"name: service\ndebug: true\ndatabase:\n  host: ''\n  port: 1\n", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 4:9: "host" violates validation rule: "required"`,
+			err.Error())
+	})
+}
+
+func TestLoadFileWithLocal(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host"`
+		Port uint16 `yaml:"port"`
+	}
+	type TestConfig struct {
+		Server Server   `yaml:"server"`
+		Tags   []string `yaml:"tags"`
+		Debug  bool     `yaml:"debug"`
+	}
+	const base = `
+server:
+  host: example.com
+  port: 443
+tags: [a, b]
+debug: false
+`
+
+	write := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, content := range files {
+			p := filepath.Join(dir, name)
+			require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+		}
+		return filepath.Join(dir, "config.yaml")
+	}
+
+	t.Run("no_local", func(t *testing.T) {
+		p := write(t, map[string]string{"config.yaml": base})
+		var c TestConfig
+		require.NoError(t, yamagiconf.LoadFileWithLocal(p, &c))
+		require.Equal(t, TestConfig{
+			Server: Server{Host: "example.com", Port: 443},
+			Tags:   []string{"a", "b"},
+		}, c)
+	})
+
+	t.Run("empty_local", func(t *testing.T) {
+		p := write(t, map[string]string{
+			"config.yaml":       base,
+			"config.local.yaml": "\n",
+		})
+		var c TestConfig
+		require.NoError(t, yamagiconf.LoadFileWithLocal(p, &c))
+		require.Equal(t, "example.com", c.Server.Host)
+	})
+
+	t.Run("partial_local", func(t *testing.T) {
+		p := write(t, map[string]string{
+			"config.yaml": base,
+			"config.local.yaml": `
+server:
+  host: localhost
+tags: [c]
+debug: true
+`,
+		})
+		var c TestConfig
+		require.NoError(t, yamagiconf.LoadFileWithLocal(p, &c))
+		require.Equal(t, TestConfig{
+			Server: Server{Host: "localhost", Port: 443},
+			Tags:   []string{"c"},
+			Debug:  true,
+		}, c)
+	})
+
+	t.Run("partial_base", func(t *testing.T) {
+		// Only the merged result must be complete.
+		p := write(t, map[string]string{
+			"config.yaml":       "server:\n  host: example.com\ntags: []\n",
+			"config.local.yaml": "server:\n  port: 8080\ndebug: true\n",
+		})
+		var c TestConfig
+		require.NoError(t, yamagiconf.LoadFileWithLocal(p, &c))
+		require.Equal(t, TestConfig{
+			Server: Server{Host: "example.com", Port: 8080},
+			Tags:   []string{},
+			Debug:  true,
+		}, c)
+	})
+
+	t.Run("err_missing_in_merged", func(t *testing.T) {
+		p := write(t, map[string]string{
+			"config.yaml":       "server:\n  host: example.com\ntags: []\n",
+			"config.local.yaml": "debug: true\n",
+		})
+		var c TestConfig
+		err := yamagiconf.LoadFileWithLocal(p, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMissingConfig)
+		require.Equal(t, `at TestConfig.Server.Port (as "port"): `+
+			`missing field in config file`, err.Error())
+	})
+
+	t.Run("err_unknown_field_in_local", func(t *testing.T) {
+		p := write(t, map[string]string{
+			"config.yaml":       base,
+			"config.local.yaml": "server:\n  hots: localhost\n",
+		})
+		var c TestConfig
+		err := yamagiconf.LoadFileWithLocal(p, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.Equal(t, `at 2:3: malformed YAML: `+
+			`field "hots" not found in type yamagiconf_test.Server`, err.Error())
+	})
+
+	t.Run("err_malformed_local", func(t *testing.T) {
+		p := write(t, map[string]string{
+			"config.yaml":       base,
+			"config.local.yaml": "server: [\n",
+		})
+		var c TestConfig
+		err := yamagiconf.LoadFileWithLocal(p, &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLMalformed)
+		require.True(t, strings.HasPrefix(err.Error(),
+			fmt.Sprintf("in file %q: malformed YAML: ",
+				strings.TrimSuffix(p, ".yaml")+".local.yaml")), err.Error())
+	})
+
+	t.Run("err_base_not_found", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadFileWithLocal(
+			filepath.Join(t.TempDir(), "config.yaml"), &c)
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestLoadEnvBase64(t *testing.T) {
+	type TestConfig struct {
+		Host string `yaml:"host" validate:"required"`
+		Port uint16 `yaml:"port"`
+	}
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		t.Setenv("CONFIG_B64", encode("host: example.com\nport: 443\n")+"\n")
+		var c TestConfig
+		require.NoError(t, yamagiconf.LoadEnvBase64("CONFIG_B64", &c))
+		require.Equal(t, TestConfig{Host: "example.com", Port: 443}, c)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		t.Setenv("CONFIG_B64", encode("host: ''\nport: 443\n"))
+		var c TestConfig
+		err := yamagiconf.LoadEnvBase64("CONFIG_B64", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:7: "host" violates validation rule: "required"`,
+			err.Error())
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadEnvBase64("CONFIG_B64_MISSING", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvMissingVar)
+		require.Equal(t, `missing env var CONFIG_B64_MISSING`, err.Error())
+	})
+
+	t.Run("invalid_base64", func(t *testing.T) {
+		t.Setenv("CONFIG_B64", "host: x")
+		var c TestConfig
+		err := yamagiconf.LoadEnvBase64("CONFIG_B64", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrEnvInvalidVar)
+		require.Equal(t, `invalid env var CONFIG_B64: decoding base64: `+
+			`illegal base64 data at input byte 4`, err.Error())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		t.Setenv("CONFIG_B64", "")
+		var c TestConfig
+		err := yamagiconf.LoadEnvBase64("CONFIG_B64", &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyFile)
+	})
+
+	t.Run("nil_config", func(t *testing.T) {
+		err := yamagiconf.LoadEnvBase64[TestConfig]("CONFIG_B64", nil)
+		require.ErrorIs(t, err, yamagiconf.ErrConfigNil)
+	})
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestLoadReader(t *testing.T) {
+	type TestConfig struct {
+		Host string `yaml:"host" validate:"required"`
+		Port uint16 `yaml:"port" env:"PORT"`
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadReader(strings.NewReader("host: example.com\nport: 443\n"), &c)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Host: "example.com", Port: 443}, c)
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("PORT", "8080")
+		var c TestConfig
+		err := yamagiconf.LoadReader(strings.NewReader("host: example.com\nport: 443\n"), &c)
+		require.NoError(t, err)
+		require.Equal(t, TestConfig{Host: "example.com", Port: 8080}, c)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadReader(strings.NewReader("host: ''\nport: 443\n"), &c)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 1:7: "host" violates validation rule: "required"`,
+			err.Error())
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var c TestConfig
+		err := yamagiconf.LoadReader(strings.NewReader(""), &c)
+		require.ErrorIs(t, err, yamagiconf.ErrYAMLEmptyFile)
+		require.Equal(t, "empty file", err.Error())
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		var c TestConfig
+		errRead := errors.New("connection reset")
+		err := yamagiconf.LoadReader(errReader{err: errRead}, &c)
+		require.ErrorIs(t, err, errRead)
+		require.Equal(t, "reading source: connection reset", err.Error())
+	})
+
+	t.Run("nil config", func(t *testing.T) {
+		err := yamagiconf.LoadReader[TestConfig](strings.NewReader("port: 443\n"), nil)
+		require.ErrorIs(t, err, yamagiconf.ErrConfigNil)
+	})
+}
+
+func TestValidateWhen(t *testing.T) {
+	type Feature struct {
+		URL   string          `yaml:"url" validate:"required,url"`
+		Token ValidatedString `yaml:"token"`
+	}
+	type TestConfig struct {
+		Enabled bool     `yaml:"enabled"`
+		Feature Feature  `yaml:"feature" validate_when:"Enabled"`
+		Ptr     *Feature `yaml:"ptr" validate_when:"Enabled" validate:"required"`
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`
+enabled: false
+feature:
+  url: ""
+  token: invalid
+ptr: null
+`)
+		require.NoError(t, err)
+		require.False(t, c.Enabled)
+		require.Equal(t, ValidatedString("invalid"), c.Feature.Token)
+
+		require.NoError(t, yamagiconf.Validate(*c))
+	})
+
+	t.Run("enabled_ok", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`
+enabled: true
+feature:
+  url: https://example.com
+  token: valid
+ptr:
+  url: https://example.com
+  token: valid
+`)
+		require.NoError(t, err)
+	})
+
+	t.Run("enabled_err_tag", func(t *testing.T) {
+		c, err := LoadSrc[TestConfig](`
+enabled: true
+feature:
+  url: ""
+  token: valid
+ptr:
+  url: https://example.com
+  token: valid
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at 4:8: "url" violates validation rule: "required"`,
+			err.Error())
+
+		err = yamagiconf.Validate(*c)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationTag)
+		require.Equal(t, `at TestConfig.Feature.URL: violates validation rule: "required"`,
+			err.Error())
+	})
+
+	t.Run("enabled_err_required", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`
+enabled: true
+feature:
+  url: https://example.com
+  token: valid
+ptr: null
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrValidationRequiredNull)
+		require.Equal(t, `at 6:6: "ptr": required field must not be null`,
+			err.Error())
+	})
+
+	t.Run("enabled_err_validate_method", func(t *testing.T) {
+		_, err := LoadSrc[TestConfig](`
+enabled: true
+feature:
+  url: https://example.com
+  token: invalid
+ptr: null
+`)
+		require.ErrorIs(t, err, yamagiconf.ErrValidation)
+		require.Equal(t, "at 5:10: at TestConfig.Feature.Token: "+
+			"validation: is not 'valid'", err.Error())
+	})
+}
+
+func TestValidateWhenFieldTags(t *testing.T) {
+	t.Run("pattern", func(t *testing.T) {
+		testValidateWhenFieldTag[struct {
+			V string `yaml:"v" pattern:"^a$"`
+		}](t, "  v: b\n", yamagiconf.ErrPatternMismatch)
+	})
+	t.Run("format", func(t *testing.T) {
+		testValidateWhenFieldTag[struct {
+			V string `yaml:"v" format:"uuid"`
+		}](t, "  v: x\n", yamagiconf.ErrInvalidUUID)
+	})
+	t.Run("multipleof", func(t *testing.T) {
+		testValidateWhenFieldTag[struct {
+			V int32 `yaml:"v" multipleof:"2"`
+		}](t, "  v: 3\n", yamagiconf.ErrNotMultipleOf)
+	})
+	t.Run("maxbytes", func(t *testing.T) {
+		testValidateWhenFieldTag[struct {
+			V string `yaml:"v" maxbytes:"1"`
+		}](t, "  v: ab\n", yamagiconf.ErrTooLong)
+	})
+	t.Run("sorted", func(t *testing.T) {
+		testValidateWhenFieldTag[struct {
+			V []int32 `yaml:"v" sorted:"asc"`
+		}](t, "  v: [2, 1]\n", yamagiconf.ErrNotSorted)
+	})
+	t.Run("cidr", func(t *testing.T) {
+		testValidateWhenFieldTag[struct {
+			V string `yaml:"v" cidr:"10.0.0.0/8"`
+		}](t, "  v: 192.168.0.1\n", yamagiconf.ErrNotWithinCIDR)
+	})
+	t.Run("fileexists", func(t *testing.T) {
+		testValidateWhenFieldTag[struct {
+			V string `yaml:"v" fileexists:"true"`
+		}](t, "  v: ./does-not-exist\n", yamagiconf.ErrReferencedFileMissing)
+	})
+	t.Run("before", func(t *testing.T) {
+		testValidateWhenFieldTag[struct {
+			A int32 `yaml:"a" before:"B"`
+			B int32 `yaml:"b"`
+		}](t, "  a: 2\n  b: 1\n", yamagiconf.ErrFieldOrder)
+	})
+	t.Run("sumbudget", func(t *testing.T) {
+		testValidateWhenFieldTag[struct {
+			A time.Duration `yaml:"a" sumbudget:"B"`
+			B time.Duration `yaml:"b"`
+		}](t, "  a: 2s\n  b: 1s\n", yamagiconf.ErrBudgetExceeded)
+	})
+}
+
+// testValidateWhenFieldTag asserts that the violation of section
+// is only reported if the section is enabled by `validate_when`.
+func testValidateWhenFieldTag[S any](t *testing.T, section string, expect error) {
+	t.Helper()
+	type TestConfig struct {
+		Enabled bool `yaml:"enabled"`
+		Section S    `yaml:"section" validate_when:"Enabled"`
+	}
+
+	c, err := LoadSrc[TestConfig]("enabled: false\nsection:\n" + section)
+	require.NoError(t, err)
+	require.NoError(t, yamagiconf.Validate(*c))
+
+	c.Enabled = true
+	require.ErrorIs(t, yamagiconf.Validate(*c), expect)
+	_, err = LoadSrc[TestConfig]("enabled: true\nsection:\n" + section)
+	require.ErrorIs(t, err, expect)
+}
+
+func TestValidateTypeErrInvalidValidateWhenTag(t *testing.T) {
+	type Feature struct {
+		URL string `yaml:"url"`
+	}
+
+	t.Run("not_found", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			Feature Feature `yaml:"feature" validate_when:"Enabled"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidValidateWhenTag)
+		require.Equal(t, `at struct{...}.Feature: invalid validate_when struct tag: `+
+			`field "Enabled" not found`, err.Error())
+	})
+
+	t.Run("not_bool", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			Enabled string  `yaml:"enabled"`
+			Feature Feature `yaml:"feature" validate_when:"Enabled"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidValidateWhenTag)
+		require.Equal(t, `at struct{...}.Feature: invalid validate_when struct tag: `+
+			`field "Enabled" is not a bool`, err.Error())
+	})
+
+	t.Run("not_struct", func(t *testing.T) {
+		err := yamagiconf.ValidateType[struct {
+			Enabled bool   `yaml:"enabled"`
+			URL     string `yaml:"url" validate_when:"Enabled"`
+		}]()
+		require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidValidateWhenTag)
+		require.Equal(t, `at struct{...}.URL: invalid validate_when struct tag: `+
+			`string is not a struct`, err.Error())
+	})
+}
+
+func TestValidateTypeErrInvalidRedactTag(t *testing.T) {
+	err := yamagiconf.ValidateType[struct {
+		Password string `yaml:"password" redact:"yes"`
+	}]()
+	require.ErrorIs(t, err, yamagiconf.ErrTypeInvalidRedactTag)
+	require.Equal(t, `at struct{...}.Password: invalid redact struct tag: `+
+		`"yes", expected true or false`, err.Error())
+}